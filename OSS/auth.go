@@ -116,7 +116,7 @@ func (OSSClient OSSClient) doAuthTemporary(method, bucketName, objectKey string,
 }
 
 func (OSSClient OSSClient) doAuth(method, bucketName, objectKey string, params map[string]string,
-	headers map[string][]string, hostName string) (requestURL string, err error) {
+	headers map[string][]string, hostName string, urlHolder *urlHolder) (requestURL string, err error) {
 	sh := OSSClient.getSecurity()
 	isAkSkEmpty := sh.ak == "" || sh.sk == ""
 	if isAkSkEmpty == false && sh.securityToken != "" {
@@ -127,7 +127,7 @@ func (OSSClient OSSClient) doAuth(method, bucketName, objectKey string, params m
 		}
 	}
 	isOSS := OSSClient.conf.signature == SignatureOSS
-	requestURL, canonicalizedURL := OSSClient.conf.formatUrls(bucketName, objectKey, params, true)
+	requestURL, canonicalizedURL := OSSClient.conf.formatHostUrls(urlHolder, bucketName, objectKey, params, true)
 	parsedRequestURL, err := url.Parse(requestURL)
 	if err != nil {
 		return "", err
@@ -0,0 +1,235 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// checkpointVersion is the version of the checkpoint format written by this SDK.
+// Version 1 is the raw XML checkpoint written by the earlier versions, which is still accepted.
+const checkpointVersion = 2
+
+// ErrCheckpointNotFound will be returned by CheckpointStore.Load if the checkpoint does not exist
+var ErrCheckpointNotFound = errors.New("Checkpoint is not found")
+
+var errCheckpointIsFolder = errors.New("checkpoint file can not be a folder")
+
+// CheckpointStore stores the checkpoints of the resumable transfers, the key of a checkpoint is the
+// CheckpointFile of the input of the transfer.
+//
+// Load returns ErrCheckpointNotFound if the checkpoint does not exist. Save must replace the checkpoint
+// atomically, so that a crash while saving never leaves a partial checkpoint. Delete returns nil if the
+// checkpoint does not exist. The methods may be called concurrently by the tasks of a transfer.
+type CheckpointStore interface {
+	Load(key string) ([]byte, error)
+	Save(key string, data []byte) error
+	Delete(key string) error
+}
+
+// FileCheckpointStore stores the checkpoints in local files, the key of a checkpoint is the path of its file.
+// It is the default CheckpointStore.
+type FileCheckpointStore struct {
+}
+
+// Load implements CheckpointStore.
+func (store FileCheckpointStore) Load(key string) ([]byte, error) {
+	stat, err := os.Stat(key)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrCheckpointNotFound
+		}
+		return nil, err
+	}
+	if stat.IsDir() {
+		return nil, errCheckpointIsFolder
+	}
+	return os.ReadFile(key)
+}
+
+// Save implements CheckpointStore, the data is written to a temp file which is then renamed to the checkpoint file.
+func (store FileCheckpointStore) Save(key string, data []byte) (err error) {
+	fd, err := os.CreateTemp(filepath.Dir(key), filepath.Base(key)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if _err := os.Remove(fd.Name()); _err != nil && !os.IsNotExist(_err) {
+				doLog(LEVEL_WARN, "Failed to remove temp checkpoint file with error [%v].", _err)
+			}
+		}
+	}()
+	if _, err = fd.Write(data); err == nil {
+		err = fd.Sync()
+	}
+	if errMsg := fd.Close(); errMsg != nil && err == nil {
+		err = errMsg
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(fd.Name(), key)
+}
+
+// Delete implements CheckpointStore.
+func (store FileCheckpointStore) Delete(key string) error {
+	if err := os.Remove(key); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MemoryCheckpointStore stores the checkpoints in memory, so a transfer can only be resumed in the same process.
+type MemoryCheckpointStore struct {
+	lock        sync.Mutex
+	checkpoints map[string][]byte
+}
+
+// NewMemoryCheckpointStore creates a MemoryCheckpointStore instance
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string][]byte)}
+}
+
+// Load implements CheckpointStore.
+func (store *MemoryCheckpointStore) Load(key string) ([]byte, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	data, ok := store.checkpoints[key]
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Save implements CheckpointStore.
+func (store *MemoryCheckpointStore) Save(key string, data []byte) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.checkpoints[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// Delete implements CheckpointStore.
+func (store *MemoryCheckpointStore) Delete(key string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.checkpoints, key)
+	return nil
+}
+
+func getCheckpointStore(store CheckpointStore) CheckpointStore {
+	if store == nil {
+		return FileCheckpointStore{}
+	}
+	return store
+}
+
+// checkpointEnvelope wraps the XML of a checkpoint with its version and its SHA-256 checksum.
+type checkpointEnvelope struct {
+	XMLName  xml.Name `xml:"Checkpoint"`
+	Version  int      `xml:"Version"`
+	Checksum string   `xml:"Checksum"`
+	Data     string   `xml:"Data"`
+}
+
+func encodeCheckpoint(fc interface{}) ([]byte, error) {
+	data, err := xml.Marshal(fc)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(data)
+	return xml.Marshal(&checkpointEnvelope{
+		Version:  checkpointVersion,
+		Checksum: hex.EncodeToString(checksum[:]),
+		Data:     string(data),
+	})
+}
+
+// decodeCheckpoint decodes a checkpoint into result, and reports whether it is in the format of version 1.
+func decodeCheckpoint(raw []byte, result interface{}) (legacy bool, err error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return false, nil
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return false, errors.New("checkpoint has no root element")
+		}
+		if err != nil {
+			return false, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "Checkpoint" {
+				return true, xml.Unmarshal(raw, result)
+			}
+			break
+		}
+	}
+
+	envelope := &checkpointEnvelope{}
+	if err = xml.Unmarshal(raw, envelope); err != nil {
+		return false, err
+	}
+	if envelope.Version > checkpointVersion {
+		return false, fmt.Errorf("checkpoint version [%d] is not supported", envelope.Version)
+	}
+	checksum := sha256.Sum256([]byte(envelope.Data))
+	if hex.EncodeToString(checksum[:]) != envelope.Checksum {
+		return false, errors.New("checkpoint checksum mismatch")
+	}
+	return false, xml.Unmarshal([]byte(envelope.Data), result)
+}
+
+// loadCheckpointFile loads the checkpoint of key from store, a checkpoint in the format of version 1
+// is saved again in the current format.
+func loadCheckpointFile(store CheckpointStore, key string, result interface{}) error {
+	store = getCheckpointStore(store)
+	raw, err := store.Load(key)
+	if err != nil {
+		return err
+	}
+	legacy, err := decodeCheckpoint(raw, result)
+	if err != nil {
+		return err
+	}
+	if legacy {
+		doLog(LEVEL_INFO, "Migrate checkpoint [%s] to version [%d].", key, checkpointVersion)
+		if _err := updateCheckpointFile(store, result, key); _err != nil {
+			doLog(LEVEL_WARN, "Failed to migrate checkpoint with error [%v].", _err)
+		}
+	}
+	return nil
+}
+
+func updateCheckpointFile(store CheckpointStore, fc interface{}, key string) error {
+	data, err := encodeCheckpoint(fc)
+	if err != nil {
+		return err
+	}
+	return getCheckpointStore(store).Save(key, data)
+}
+
+func removeCheckpointFile(store CheckpointStore, key string) error {
+	return getCheckpointStore(store).Delete(key)
+}
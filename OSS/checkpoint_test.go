@@ -0,0 +1,136 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointEncoding(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	ufc := &UploadCheckpoint{Bucket: "bucket", Key: "key", UploadId: "upload"}
+	if err := updateCheckpointFile(store, ufc, "checkpoint"); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &UploadCheckpoint{}
+	if err := loadCheckpointFile(store, "checkpoint", loaded); err != nil || loaded.UploadId != "upload" {
+		t.Fatalf("unexpected checkpoint %+v, err: %v", loaded, err)
+	}
+
+	raw, _ := store.Load("checkpoint")
+	store.Save("checkpoint", bytes.Replace(raw, []byte("upload"), []byte("uploaD"), 1))
+	if err := loadCheckpointFile(store, "checkpoint", &UploadCheckpoint{}); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected the checksum mismatch, got: %v", err)
+	}
+
+	store.Save("checkpoint", []byte("<Checkpoint><Version>3</Version></Checkpoint>"))
+	if err := loadCheckpointFile(store, "checkpoint", &UploadCheckpoint{}); err == nil {
+		t.Fatal("expected the unsupported version to be rejected")
+	}
+
+	if err := loadCheckpointFile(store, "missing", &UploadCheckpoint{}); err != ErrCheckpointNotFound {
+		t.Fatalf("expected ErrCheckpointNotFound, got: %v", err)
+	}
+}
+
+func TestCheckpointMigration(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	legacy, err := xml.Marshal(&DownloadCheckpoint{Bucket: "bucket", Key: "key", DownloadFile: "file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Save("checkpoint", legacy)
+
+	dfc := &DownloadCheckpoint{}
+	if err := loadCheckpointFile(store, "checkpoint", dfc); err != nil || dfc.DownloadFile != "file" {
+		t.Fatalf("unexpected checkpoint %+v, err: %v", dfc, err)
+	}
+	raw, _ := store.Load("checkpoint")
+	if legacy, err := decodeCheckpoint(raw, &DownloadCheckpoint{}); legacy || err != nil {
+		t.Fatalf("expected the checkpoint to be migrated, err: %v", err)
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "checkpoint")
+	store := FileCheckpointStore{}
+	if _, err := store.Load(key); err != ErrCheckpointNotFound {
+		t.Fatalf("expected ErrCheckpointNotFound, got: %v", err)
+	}
+	for _, data := range []string{"first", "second"} {
+		if err := store.Save(key, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if loaded, err := store.Load(key); err != nil || string(loaded) != data {
+			t.Fatalf("unexpected checkpoint %q, err: %v", loaded, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected no temp files, got %d entries", len(entries))
+	}
+	if err := store.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Fatalf("expected deleting a missing checkpoint to succeed, got: %v", err)
+	}
+	if _, err := store.Load(dir); err != errCheckpointIsFolder {
+		t.Fatalf("expected errCheckpointIsFolder, got: %v", err)
+	}
+}
+
+func TestDownloadFileCheckpointResume(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := newTestObject(fs, 5000)
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get(HEADER_RANGE) == "bytes=3000-3999" {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	store := NewMemoryCheckpointStore()
+	input := &DownloadFileInput{DownloadFile: filepath.Join(t.TempDir(), "file"), PartSize: 1000, TaskNum: 1,
+		EnableCheckpoint: true, CheckpointStore: store}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.DownloadFile(input); err == nil {
+		t.Fatal("expected DownloadFile to fail")
+	}
+	if _, err := store.Load(input.CheckpointFile); err != nil {
+		t.Fatalf("expected the checkpoint to be kept, got: %v", err)
+	}
+
+	fs.setHook(nil)
+	before := fs.countRequests(func(r *http.Request) bool { return r.Header.Get(HEADER_RANGE) != "" })
+	if _, err := client.DownloadFile(input); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if read, err := os.ReadFile(input.DownloadFile); err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("unexpected file, err: %v", err)
+	}
+	if parts := fs.countRequests(func(r *http.Request) bool { return r.Header.Get(HEADER_RANGE) != "" }) - before; parts != 2 {
+		t.Fatalf("expected the 2 unfinished parts to be downloaded, got %d", parts)
+	}
+	if _, err := store.Load(input.CheckpointFile); err != ErrCheckpointNotFound {
+		t.Fatalf("expected the checkpoint to be removed, got: %v", err)
+	}
+}
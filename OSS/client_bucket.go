@@ -74,6 +74,34 @@ func (OSSClient OSSClient) DeleteBucket(bucketName string, extensions ...extensi
 	return
 }
 
+// DeleteBucketForce empties a bucket and deletes it.
+//
+// The multipart uploads of the bucket are aborted, and all the versions and delete markers of its objects
+// are deleted by batches, before the bucket is deleted. The bucket is not deleted if any upload or object
+// fails to be deleted, and the returned output reports what has been deleted.
+func (OSSClient OSSClient) DeleteBucketForce(input *DeleteBucketForceInput, extensions ...extensionOptions) (output *DeleteBucketForceOutput, err error) {
+	if input == nil {
+		return nil, errors.New("DeleteBucketForceInput is nil")
+	}
+	if strings.TrimSpace(input.Bucket) == "" {
+		return nil, errors.New("Bucket is empty")
+	}
+	if input.ConfirmBucket != input.Bucket {
+		return nil, errors.New("ConfirmBucket is not the same as Bucket")
+	}
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("DeleteBucketForce", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.deleteBucketForce(input, extensions)
+	return
+}
+
 // SetBucketStoragePolicy sets bucket storage class.
 //
 // You can use this API to set storage class for bucket.
@@ -13,6 +13,7 @@
 package OSS
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -72,6 +73,35 @@ func (OSSClient OSSClient) ListVersions(input *ListVersionsInput, extensions ...
 	return
 }
 
+// ParallelListObjects lists the objects of a prefix by listing the partitions of its keyspace concurrently.
+//
+// The objects are streamed through the returned channel, which is closed after all the objects are listed.
+// If the listing fails, the last value received has the error. The context set by WithContext must be
+// canceled if the channel is not drained, so that the routines of the listing stop, and the channel may
+// be closed without the error of the context then.
+func (OSSClient OSSClient) ParallelListObjects(input *ParallelListObjectsInput, extensions ...extensionOptions) (<-chan ListedObject, error) {
+	if input == nil {
+		return nil, errors.New("ParallelListObjectsInput is nil")
+	}
+	if input.Delimiter == "" {
+		input.Delimiter = "/"
+	}
+	if input.MaxDepth <= 0 {
+		input.MaxDepth = defaultParallelListMaxDepth
+	}
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.MaxRequestsInFlight <= 0 {
+		input.MaxRequestsInFlight = input.TaskNum
+	}
+	if input.BufferSize <= 0 {
+		input.BufferSize = defaultParallelListBufferSize
+	}
+
+	return OSSClient.parallelListObjects(input, extensions), nil
+}
+
 // HeadObject checks whether an object exists.
 //
 // You can use this API to check whether an object exists.
@@ -137,6 +167,72 @@ func (OSSClient OSSClient) DeleteObjects(input *DeleteObjectsInput, extensions .
 	return
 }
 
+// DeletePrefix deletes the objects of a prefix, optionally with all their versions, by batches.
+//
+// The batches are deleted concurrently while the objects are listed, and the returned output aggregates
+// the results of all the batches. A batch which fails does not stop the others. An error is returned only
+// if listing the objects fails or the context is done, together with the results of the batches sent before that.
+func (OSSClient OSSClient) DeletePrefix(input *DeletePrefixInput, extensions ...extensionOptions) (output *DeletePrefixOutput, err error) {
+	if input == nil {
+		return nil, errors.New("DeletePrefixInput is nil")
+	}
+	if strings.TrimSpace(input.Bucket) == "" {
+		return nil, errors.New("Bucket is empty")
+	}
+	if input.Prefix == "" && !input.AllowEmptyPrefix {
+		return nil, errors.New("Prefix is empty, set AllowEmptyPrefix to delete all the objects of the bucket")
+	}
+	if input.BatchSize <= 0 || input.BatchSize > MAX_DELETE_OBJECTS_NUM {
+		input.BatchSize = MAX_DELETE_OBJECTS_NUM
+	}
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("DeletePrefix", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.deletePrefix(input, nil, extensions)
+	return
+}
+
+// RunBatchJob applies an operation to each key of a key list, a manifest file or a prefix concurrently.
+//
+// The result of each key is written to the results manifest as soon as the key is done, so the manifest
+// of an interrupted job can be used to retry its failed keys. A key which fails does not stop the others.
+// An error is returned only if reading the keys or writing the results fails, or the context is done,
+// together with the results of the keys processed before that.
+func (OSSClient OSSClient) RunBatchJob(input *BatchJobInput, extensions ...extensionOptions) (output *BatchJobOutput, err error) {
+	if input == nil {
+		return nil, errors.New("BatchJobInput is nil")
+	}
+	if input.Operation == nil {
+		return nil, errors.New("Operation is nil")
+	}
+	if input.Keys != nil && input.ManifestFile != "" {
+		return nil, errors.New("Keys and ManifestFile can not be both set")
+	}
+	if input.ResultFile != "" && input.ResultFile == input.ManifestFile {
+		return nil, errors.New("ResultFile can not be the same as ManifestFile")
+	}
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.MaxRetries < 0 {
+		input.MaxRetries = 0
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("RunBatchJob", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.runBatchJob(input, extensions)
+	return
+}
+
 // SetObjectAcl sets ACL for an object.
 //
 // You can use this API to set the ACL for an object in a specified bucket.
@@ -257,10 +353,12 @@ func (OSSClient OSSClient) PutObject(input *PutObjectInput, extensions ...extens
 		}
 	}
 	output = &PutObjectOutput{}
+	extensions = withProgressListener(extensions, input.ProgressListener)
 	var repeatable bool
 	if input.Body != nil {
-		if _, ok := input.Body.(*strings.Reader); !ok {
-			repeatable = false
+		switch input.Body.(type) {
+		case *strings.Reader, *bytes.Reader:
+			repeatable = true
 		}
 		if input.ContentLength > 0 {
 			input.Body = &readerWrapper{reader: input.Body, totalCount: input.ContentLength}
@@ -364,6 +462,7 @@ func (OSSClient OSSClient) PutFile(input *PutFileInput, extensions ...extensionO
 	}
 
 	output = &PutObjectOutput{}
+	extensions = withProgressListener(extensions, input.ProgressListener)
 	err = OSSClient.doActionWithBucketAndKey("PutFile", HTTP_PUT, _input.Bucket, _input.Key, _input, output, extensions)
 	if err != nil {
 		output = nil
@@ -407,6 +506,8 @@ func (OSSClient OSSClient) AppendObject(input *AppendObjectInput, extensions ...
 			input.ContentType = contentType
 		}
 	}
+	output = &AppendObjectOutput{}
+	extensions = withProgressListener(extensions, input.ProgressListener)
 	var repeatable bool
 	if input.Body != nil {
 		if _, ok := input.Body.(*strings.Reader); !ok {
@@ -416,7 +517,6 @@ func (OSSClient OSSClient) AppendObject(input *AppendObjectInput, extensions ...
 			input.Body = &readerWrapper{reader: input.Body, totalCount: input.ContentLength}
 		}
 	}
-	fmt.Printf("re:%s", repeatable)
 	if repeatable {
 		err = OSSClient.doActionWithBucketAndKey("AppendObject", HTTP_PUT, input.Bucket, input.Key, input, output, extensions)
 	} else {
@@ -44,6 +44,7 @@ func (OSSClient OSSClient) getSecurity() securityHolder {
 // Close closes OSSClient.
 func (OSSClient *OSSClient) Close() {
 	OSSClient.httpClient = nil
+	OSSClient.conf.endpoints.close()
 	OSSClient.conf.transport.CloseIdleConnections()
 	OSSClient.conf = nil
 }
@@ -13,11 +13,13 @@
 package OSS
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"os"
 	"sort"
 	"strings"
+	"time"
 )
 
 // ListMultipartUploads lists the multipart uploads.
@@ -111,12 +113,15 @@ func (OSSClient OSSClient) UploadPart(_input *UploadPartInput, extensions ...ext
 	input.PartSize = _input.PartSize
 	input.SseHeader = _input.SseHeader
 	input.Body = _input.Body
+	input.ProgressListener = _input.ProgressListener
 
 	output = &UploadPartOutput{}
+	extensions = withProgressListener(extensions, input.ProgressListener)
 	var repeatable bool
 	if input.Body != nil {
-		if _, ok := input.Body.(*strings.Reader); !ok {
-			repeatable = false
+		switch input.Body.(type) {
+		case *strings.Reader, *bytes.Reader:
+			repeatable = true
 		}
 		if _, ok := input.Body.(*readerWrapper); !ok && input.PartSize > 0 {
 			input.Body = &readerWrapper{reader: input.Body, totalCount: input.PartSize}
@@ -151,6 +156,7 @@ func (OSSClient OSSClient) UploadPart(_input *UploadPartInput, extensions ...ext
 			input.PartSize = fileSize - input.Offset
 		}
 		fileReaderWrapper.totalCount = input.PartSize
+		fileReaderWrapper.mark = input.Offset
 		if _, err = fd.Seek(input.Offset, io.SeekStart); err != nil {
 			return nil, err
 		}
@@ -250,3 +256,32 @@ func (OSSClient OSSClient) CopyPart(input *CopyPartInput, extensions ...extensio
 	}
 	return
 }
+
+// CleanupMultipartUploads aborts the multipart uploads of a prefix which were initiated before a given age.
+//
+// The uploads are aborted concurrently and the returned output reports the result of each upload, together
+// with the parts and the bytes reclaimed, which are counted by ListParts. An upload which fails to be aborted
+// does not stop the others. An error is returned only if listing the uploads fails or the context is done,
+// together with the results of the uploads dispatched before that.
+func (OSSClient OSSClient) CleanupMultipartUploads(input *CleanupMultipartUploadsInput, extensions ...extensionOptions) (output *CleanupMultipartUploadsOutput, err error) {
+	if input == nil {
+		return nil, errors.New("CleanupMultipartUploadsInput is nil")
+	}
+	if input.OlderThan < 0 {
+		return nil, errors.New("OlderThan is negative")
+	}
+	if input.OlderThan == 0 {
+		input.OlderThan = 24 * time.Hour
+	}
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("CleanupMultipartUploads", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.cleanupMultipartUploads(input, false, nil, extensions)
+	return
+}
@@ -12,6 +12,14 @@
 
 package OSS
 
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
 // UploadFile resume uploads.
 //
 // This API is an encapsulated and enhanced version of multipart upload, and aims to eliminate large file
@@ -30,10 +38,89 @@ func (OSSClient OSSClient) UploadFile(input *UploadFileInput, extensions ...exte
 		input.PartSize = MAX_PART_SIZE
 	}
 
+	extensions, span := OSSClient.startExtensionsSpan("UploadFile", input.Bucket, input.Key, extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	output, err = OSSClient.resumeUpload(input, extensions)
 	return
 }
 
+// UploadStream uploads the data read from input.Body, whose length does not need to be known in advance.
+//
+// The data is buffered in parts of PartSize and the parts are uploaded concurrently, so at most
+// (TaskNum + 1) * PartSize bytes are held in memory. A stream shorter than PartSize is uploaded
+// with a single PutObject request. If any part fails, the multipart upload is aborted.
+func (OSSClient OSSClient) UploadStream(input *UploadStreamInput, extensions ...extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	if input == nil {
+		return nil, errors.New("UploadStreamInput is nil")
+	}
+	if input.Body == nil {
+		return nil, errors.New("Body is nil")
+	}
+
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.PartSize <= 0 {
+		input.PartSize = DEFAULT_PART_SIZE
+	} else if input.PartSize < MIN_PART_SIZE {
+		input.PartSize = MIN_PART_SIZE
+	} else if input.PartSize > MAX_PART_SIZE {
+		input.PartSize = MAX_PART_SIZE
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("UploadStream", input.Bucket, input.Key, extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.resumeUploadStream(input, extensions)
+	return
+}
+
+// CopyFile resume copies.
+//
+// This API copies the source object with concurrent CopyPart requests, so that objects larger than
+// the limit of CopyObject can be copied, and an interrupted copy can be resumed from the checkpoint file.
+// Objects not larger than PartSize are copied with a single CopyObject request.
+// If the object is copied but copying the source ACL fails, both the output and the error are returned.
+func (OSSClient OSSClient) CopyFile(input *CopyFileInput, extensions ...extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	if input == nil {
+		return nil, errors.New("CopyFileInput is nil")
+	}
+	if strings.TrimSpace(input.CopySourceBucket) == "" {
+		return nil, errors.New("Source bucket is empty")
+	}
+	if strings.TrimSpace(input.CopySourceKey) == "" {
+		return nil, errors.New("Source key is empty")
+	}
+
+	if input.EnableCheckpoint && input.CheckpointFile == "" {
+		input.CheckpointFile = url.QueryEscape(input.Bucket+"/"+input.Key) + ".copyfile_record"
+	}
+
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.PartSize <= 0 {
+		input.PartSize = DEFAULT_PART_SIZE
+	} else if input.PartSize < MIN_PART_SIZE {
+		input.PartSize = MIN_PART_SIZE
+	} else if input.PartSize > MAX_PART_SIZE {
+		input.PartSize = MAX_PART_SIZE
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("CopyFile", input.Bucket, input.Key, extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.resumeCopy(input, extensions)
+	return
+}
+
 // DownloadFile resume downloads.
 //
 // This API is an encapsulated and enhanced version of partial download, and aims to eliminate large file
@@ -54,6 +141,230 @@ func (OSSClient OSSClient) DownloadFile(input *DownloadFileInput, extensions ...
 		input.PartSize = DEFAULT_PART_SIZE
 	}
 
+	extensions, span := OSSClient.startExtensionsSpan("DownloadFile", input.Bucket, input.Key, extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	output, err = OSSClient.resumeDownload(input, extensions)
 	return
 }
+
+// UploadDirectory uploads the files under a local directory concurrently.
+//
+// The returned output reports the result of each file, and a file which fails to be uploaded does not
+// stop the others. An error is returned only if the directory can not be walked or the context is done,
+// together with the results of the files dispatched before that.
+func (OSSClient OSSClient) UploadDirectory(input *UploadDirectoryInput, extensions ...extensionOptions) (output *DirectoryTransferOutput, err error) {
+	if input == nil {
+		return nil, errors.New("UploadDirectoryInput is nil")
+	}
+	if strings.TrimSpace(input.Directory) == "" {
+		return nil, errors.New("Directory is empty")
+	}
+	if err = validatePathPatterns(input.Include); err != nil {
+		return nil, err
+	}
+	if err = validatePathPatterns(input.Exclude); err != nil {
+		return nil, err
+	}
+
+	input.Prefix = directoryPrefix(input.Prefix)
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.PartTaskNum <= 0 {
+		input.PartTaskNum = 1
+	}
+	if input.PartSize <= 0 {
+		input.PartSize = DEFAULT_PART_SIZE
+	}
+	if input.MultipartThreshold <= 0 {
+		input.MultipartThreshold = input.PartSize
+	}
+	if input.EnableCheckpoint && input.CheckpointDir == "" {
+		input.CheckpointDir = filepath.Join(os.TempDir(), "oss-checkpoints")
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("UploadDirectory", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.uploadDirectory(input, extensions)
+	return
+}
+
+// DownloadPrefix downloads the objects of a prefix to a local directory concurrently.
+//
+// The returned output reports the result of each object, and an object which fails to be downloaded does
+// not stop the others. The objects whose keys would escape the directory, such as keys containing "..",
+// are reported as failed. An error is returned only if listing the objects fails or the context is done,
+// together with the results of the objects dispatched before that.
+func (OSSClient OSSClient) DownloadPrefix(input *DownloadPrefixInput, extensions ...extensionOptions) (output *DirectoryTransferOutput, err error) {
+	if input == nil {
+		return nil, errors.New("DownloadPrefixInput is nil")
+	}
+	if strings.TrimSpace(input.Directory) == "" {
+		return nil, errors.New("Directory is empty")
+	}
+	if err = validatePathPatterns(input.Include); err != nil {
+		return nil, err
+	}
+	if err = validatePathPatterns(input.Exclude); err != nil {
+		return nil, err
+	}
+
+	input.Prefix = directoryPrefix(input.Prefix)
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.PartTaskNum <= 0 {
+		input.PartTaskNum = 1
+	}
+	if input.PartSize <= 0 {
+		input.PartSize = DEFAULT_PART_SIZE
+	}
+	if input.MultipartThreshold <= 0 {
+		input.MultipartThreshold = input.PartSize
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("DownloadPrefix", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.downloadPrefix(input, extensions)
+	return
+}
+
+// SyncDirectory syncs a local directory and a bucket prefix in the direction of input.Direction.
+//
+// Only the files or objects which are missing or changed on the destination are transferred. The returned
+// output lists the planned actions and the result of each of them. An error is returned if the directory
+// can not be walked, listing the objects fails or the context is done.
+func (OSSClient OSSClient) SyncDirectory(input *SyncDirectoryInput, extensions ...extensionOptions) (output *SyncDirectoryOutput, err error) {
+	if input == nil {
+		return nil, errors.New("SyncDirectoryInput is nil")
+	}
+	if strings.TrimSpace(input.Directory) == "" {
+		return nil, errors.New("Directory is empty")
+	}
+	if input.Direction != SyncUpload && input.Direction != SyncDownload {
+		return nil, errors.New("Direction must be SyncUpload or SyncDownload")
+	}
+	if err = validatePathPatterns(input.Include); err != nil {
+		return nil, err
+	}
+	if err = validatePathPatterns(input.Exclude); err != nil {
+		return nil, err
+	}
+
+	input.Prefix = directoryPrefix(input.Prefix)
+	if input.CompareMode == "" {
+		input.CompareMode = SyncCompareSizeAndModTime
+	}
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.PartTaskNum <= 0 {
+		input.PartTaskNum = 1
+	}
+	if input.PartSize <= 0 {
+		input.PartSize = DEFAULT_PART_SIZE
+	}
+	if input.MultipartThreshold <= 0 {
+		input.MultipartThreshold = input.PartSize
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("SyncDirectory", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.syncDirectory(input, extensions)
+	return
+}
+
+// ReplicatePrefix replicates the objects of a prefix to another bucket, which may be in another region or account.
+//
+// The objects are copied on the server side with CopyFile if the source client has the same endpoint as
+// this client, otherwise they are streamed from the source client to this client. The metadata, the
+// content type and the ACL of the objects are preserved. The returned output reports the copied, skipped
+// and failed keys. An error is returned only if listing the objects fails or the context is done.
+func (OSSClient OSSClient) ReplicatePrefix(input *ReplicatePrefixInput, extensions ...extensionOptions) (output *ReplicatePrefixOutput, err error) {
+	if input == nil {
+		return nil, errors.New("ReplicatePrefixInput is nil")
+	}
+	if strings.TrimSpace(input.SourceBucket) == "" {
+		return nil, errors.New("Source bucket is empty")
+	}
+	if strings.TrimSpace(input.Bucket) == "" {
+		return nil, errors.New("Bucket is empty")
+	}
+	if err = validatePathPatterns(input.Include); err != nil {
+		return nil, err
+	}
+	if err = validatePathPatterns(input.Exclude); err != nil {
+		return nil, err
+	}
+
+	input.SourcePrefix = directoryPrefix(input.SourcePrefix)
+	input.Prefix = directoryPrefix(input.Prefix)
+	if input.SourceClient == nil {
+		input.SourceClient = &OSSClient
+	}
+	if input.EnableCheckpoint && input.CheckpointFile == "" {
+		input.CheckpointFile = url.QueryEscape(input.Bucket+"/"+input.Prefix) + ".replicate_record"
+	}
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.PartTaskNum <= 0 {
+		input.PartTaskNum = 1
+	}
+	if input.PartSize <= 0 {
+		input.PartSize = DEFAULT_PART_SIZE
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("ReplicatePrefix", input.Bucket, "", extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.replicatePrefix(input, extensions)
+	return
+}
+
+// DownloadToWriter downloads an object to input.Writer with concurrent range requests.
+//
+// Unlike DownloadFile, no local file is used, so the object can be downloaded into a pre-allocated
+// buffer or streamed to a client. The download can not be resumed from a checkpoint.
+func (OSSClient OSSClient) DownloadToWriter(input *DownloadToWriterInput, extensions ...extensionOptions) (output *GetObjectMetadataOutput, err error) {
+	if input == nil {
+		return nil, errors.New("DownloadToWriterInput is nil")
+	}
+	if input.Writer == nil {
+		return nil, errors.New("Writer is nil")
+	}
+
+	if input.TaskNum <= 0 {
+		input.TaskNum = 1
+	}
+	if input.PartSize <= 0 {
+		input.PartSize = DEFAULT_PART_SIZE
+	}
+	if input.WindowSize <= 0 {
+		input.WindowSize = 2 * input.TaskNum
+	} else if input.WindowSize < input.TaskNum {
+		return nil, errors.New("WindowSize must not be less than TaskNum")
+	}
+
+	extensions, span := OSSClient.startExtensionsSpan("DownloadToWriter", input.Bucket, input.Key, extensions)
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	output, err = OSSClient.downloadToWriter(input, extensions)
+	return
+}
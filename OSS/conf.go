@@ -34,30 +34,39 @@ type urlHolder struct {
 }
 
 type config struct {
-	securityProviders []securityProvider
-	urlHolder         *urlHolder
-	pathStyle         bool
-	cname             bool
-	sslVerify         bool
-	endpoint          string
-	signature         SignatureType
-	region            string
-	connectTimeout    int
-	socketTimeout     int
-	headerTimeout     int
-	idleConnTimeout   int
-	finalTimeout      int
-	maxRetryCount     int
-	proxyURL          string
-	maxConnsPerHost   int
-	pemCerts          []byte
-	transport         *http.Transport
-	roundTripper      http.RoundTripper
-	httpClient        *http.Client
-	ctx               context.Context
-	maxRedirectCount  int
-	userAgent         string
-	enableCompression bool
+	securityProviders     []securityProvider
+	urlHolder             *urlHolder
+	pathStyle             bool
+	cname                 bool
+	sslVerify             bool
+	endpoint              string
+	signature             SignatureType
+	region                string
+	connectTimeout        int
+	socketTimeout         int
+	headerTimeout         int
+	idleConnTimeout       int
+	finalTimeout          int
+	maxRetryCount         int
+	proxyURL              string
+	maxConnsPerHost       int
+	pemCerts              []byte
+	transport             *http.Transport
+	roundTripper          http.RoundTripper
+	httpClient            *http.Client
+	ctx                   context.Context
+	maxRedirectCount      int
+	userAgent             string
+	enableCompression     bool
+	rateLimiter           *RateLimiter
+	retryPolicy           RetryPolicy
+	middlewares           []Middleware
+	metricsRecorder       MetricsRecorder
+	tracer                Tracer
+	extraEndpoints        []string
+	endpointSelection     EndpointSelectionType
+	endpointProbeInterval time.Duration
+	endpoints             *endpointSet
 }
 
 func (conf config) String() string {
@@ -229,6 +238,72 @@ func WithEnableCompression(enableCompression bool) configurer {
 	}
 }
 
+// WithRateLimit is a configurer for OSSClient to limit the bandwidth of all the requests of the client
+// to bytesPerSecond. The limit can be changed at runtime with OSSClient.RateLimiter.
+func WithRateLimit(bytesPerSecond int64) configurer {
+	return func(conf *config) {
+		conf.rateLimiter = NewRateLimiter(bytesPerSecond)
+	}
+}
+
+// WithRetryPolicy is a configurer for OSSClient to decide whether a failed request is retried and how
+// long to wait before the retry, the default policy is NewDefaultRetryPolicy.
+func WithRetryPolicy(retryPolicy RetryPolicy) configurer {
+	return func(conf *config) {
+		conf.retryPolicy = retryPolicy
+	}
+}
+
+// WithMiddleware is a configurer for OSSClient to wrap each HTTP attempt of the requests with the middlewares,
+// it can be used more than once and the middleware added first is the outermost.
+func WithMiddleware(middlewares ...Middleware) configurer {
+	return func(conf *config) {
+		conf.middlewares = append(conf.middlewares, middlewares...)
+	}
+}
+
+// WithMetricsRecorder is a configurer for OSSClient to record the metrics of each HTTP attempt of the
+// requests and of each connection dialed.
+func WithMetricsRecorder(recorder MetricsRecorder) configurer {
+	return func(conf *config) {
+		conf.metricsRecorder = recorder
+	}
+}
+
+// WithTracer is a configurer for OSSClient to trace each API call and each HTTP attempt of it with the tracer.
+func WithTracer(tracer Tracer) configurer {
+	return func(conf *config) {
+		conf.tracer = tracer
+	}
+}
+
+// WithEndpoints is a configurer for OSSClient to send the requests to more endpoints than the one passed to New,
+// such as the gateway nodes of a deployment without load balancer. The endpoints must serve the same buckets.
+// An endpoint is marked down on a network error or a 5xx response, the failed request is retried on another
+// endpoint, and the endpoint is probed in the background until it is healthy again or the client is closed.
+// The endpoints must be all IP addresses or all domain names, unless the path style is set with WithPathStyle.
+func WithEndpoints(endpoints ...string) configurer {
+	return func(conf *config) {
+		conf.extraEndpoints = append(conf.extraEndpoints, endpoints...)
+	}
+}
+
+// WithEndpointSelection is a configurer for OSSClient to select the endpoint of each request among the healthy
+// endpoints, the default is EndpointRoundRobin.
+func WithEndpointSelection(selection EndpointSelectionType) configurer {
+	return func(conf *config) {
+		conf.endpointSelection = selection
+	}
+}
+
+// WithEndpointProbeInterval is a configurer for OSSClient to set the interval between the probes of an endpoint
+// marked down, the default is DEFAULT_ENDPOINT_PROBE_INTERVAL.
+func WithEndpointProbeInterval(interval time.Duration) configurer {
+	return func(conf *config) {
+		conf.endpointProbeInterval = interval
+	}
+}
+
 func (conf *config) prepareConfig() {
 	if conf.connectTimeout <= 0 {
 		conf.connectTimeout = DEFAULT_CONNECT_TIMEOUT
@@ -271,29 +346,77 @@ func (conf *config) initConfigWithDefault() error {
 		return errors.New("endpoint is not set")
 	}
 
-	if index := strings.Index(conf.endpoint, "?"); index > 0 {
-		conf.endpoint = conf.endpoint[:index]
+	conf.endpoint = trimEndpoint(conf.endpoint)
+
+	if conf.signature == "" {
+		conf.signature = DEFAULT_SIGNATURE
 	}
 
-	for strings.LastIndex(conf.endpoint, "/") == len(conf.endpoint)-1 {
-		conf.endpoint = conf.endpoint[:len(conf.endpoint)-1]
+	if conf.rateLimiter == nil {
+		conf.rateLimiter = NewRateLimiter(0)
+	}
+	if conf.retryPolicy == nil {
+		conf.retryPolicy = NewDefaultRetryPolicy()
 	}
 
-	if conf.signature == "" {
-		conf.signature = DEFAULT_SIGNATURE
+	conf.urlHolder = parseEndpoint(conf.endpoint)
+	isIP := IsIP(conf.urlHolder.host)
+	if len(conf.extraEndpoints) > 0 {
+		urlHolders := []*urlHolder{conf.urlHolder}
+		for _, endpoint := range conf.extraEndpoints {
+			if endpoint = trimEndpoint(endpoint); endpoint == "" || endpoint == conf.endpoint {
+				continue
+			}
+			urlHolder := parseEndpoint(endpoint)
+			// the access mode is shared by the endpoints, an IP address would turn the domain names to the path style
+			if IsIP(urlHolder.host) != isIP && !conf.pathStyle && !conf.cname {
+				return fmt.Errorf("endpoint [%s] and [%s] must be both IP addresses or both domain names, "+
+					"or the path style must be set with WithPathStyle", conf.endpoint, endpoint)
+			}
+			urlHolders = append(urlHolders, urlHolder)
+		}
+		if len(urlHolders) > 1 {
+			conf.endpoints = newEndpointSet(urlHolders, conf.endpointSelection, conf.endpointProbeInterval)
+		}
+	}
+	if isIP {
+		conf.pathStyle = true
+	}
+
+	conf.region = strings.TrimSpace(conf.region)
+	if conf.region == "" {
+		conf.region = DEFAULT_REGION
+	}
+
+	conf.prepareConfig()
+	conf.proxyURL = strings.TrimSpace(conf.proxyURL)
+	return nil
+}
+
+func trimEndpoint(endpoint string) string {
+	endpoint = strings.TrimSpace(endpoint)
+	if index := strings.Index(endpoint, "?"); index > 0 {
+		endpoint = endpoint[:index]
 	}
 
+	for strings.LastIndex(endpoint, "/") == len(endpoint)-1 && endpoint != "" {
+		endpoint = endpoint[:len(endpoint)-1]
+	}
+	return endpoint
+}
+
+func parseEndpoint(endpoint string) *urlHolder {
 	urlHolder := &urlHolder{}
 	var address string
-	if strings.HasPrefix(conf.endpoint, "https://") {
+	if strings.HasPrefix(endpoint, "https://") {
 		urlHolder.scheme = "https"
-		address = conf.endpoint[len("https://"):]
-	} else if strings.HasPrefix(conf.endpoint, "http://") {
+		address = endpoint[len("https://"):]
+	} else if strings.HasPrefix(endpoint, "http://") {
 		urlHolder.scheme = "http"
-		address = conf.endpoint[len("http://"):]
+		address = endpoint[len("http://"):]
 	} else {
 		urlHolder.scheme = "https"
-		address = conf.endpoint
+		address = endpoint
 	}
 
 	addr := strings.Split(address, ":")
@@ -311,27 +434,18 @@ func (conf *config) initConfigWithDefault() error {
 		}
 	}
 
-	if IsIP(urlHolder.host) {
-		conf.pathStyle = true
-	}
-
-	conf.urlHolder = urlHolder
-
-	conf.region = strings.TrimSpace(conf.region)
-	if conf.region == "" {
-		conf.region = DEFAULT_REGION
-	}
-
-	conf.prepareConfig()
-	conf.proxyURL = strings.TrimSpace(conf.proxyURL)
-	return nil
+	return urlHolder
 }
 
 func (conf *config) getTransport() error {
 	if conf.transport == nil {
 		conf.transport = &http.Transport{
 			Dial: func(network, addr string) (net.Conn, error) {
+				start := time.Now()
 				conn, err := net.DialTimeout(network, addr, time.Second*time.Duration(conf.connectTimeout))
+				if conf.metricsRecorder != nil {
+					conf.metricsRecorder.RecordDial(network, addr, time.Since(start), err)
+				}
 				if err != nil {
 					return nil, err
 				}
@@ -374,8 +488,7 @@ func DummyQueryEscape(s string) string {
 	return s
 }
 
-func (conf *config) prepareBaseURL(bucketName string) (requestURL string, canonicalizedURL string) {
-	urlHolder := conf.urlHolder
+func (conf *config) prepareBaseURL(urlHolder *urlHolder, bucketName string) (requestURL string, canonicalizedURL string) {
 	if conf.cname {
 		requestURL = fmt.Sprintf("%s://%s:%d", urlHolder.scheme, urlHolder.host, urlHolder.port)
 		if conf.signature == "v4" {
@@ -434,8 +547,13 @@ func (conf *config) prepareEscapeFunc(escape bool) (escapeFunc func(s string) st
 }
 
 func (conf *config) formatUrls(bucketName, objectKey string, params map[string]string, escape bool) (requestURL string, canonicalizedURL string) {
+	return conf.formatHostUrls(conf.urlHolder, bucketName, objectKey, params, escape)
+}
+
+// formatHostUrls formats the urls with the host of urlHolder, which is one of the endpoints of the client.
+func (conf *config) formatHostUrls(urlHolder *urlHolder, bucketName, objectKey string, params map[string]string, escape bool) (requestURL string, canonicalizedURL string) {
 
-	requestURL, canonicalizedURL = conf.prepareBaseURL(bucketName)
+	requestURL, canonicalizedURL = conf.prepareBaseURL(urlHolder, bucketName)
 	var escapeFunc func(s string) string
 	escapeFunc = conf.prepareEscapeFunc(escape)
 
@@ -12,6 +12,8 @@
 
 package OSS
 
+import "time"
+
 const (
 	OSS_SDK_VERSION        = "3.22.11"
 	USER_AGENT             = "OSS-sdk-go/" + OSS_SDK_VERSION
@@ -78,8 +80,10 @@ const (
 	HEADER_BUCKET_TYPE                      = "bucket-type"
 	headerOefMarker                         = "oef-marker"
 
-	HEADER_ETAG         = "etag"
-	HEADER_LASTMODIFIED = "last-modified"
+	HEADER_ETAG          = "etag"
+	HEADER_LASTMODIFIED  = "last-modified"
+	HEADER_CONTENT_RANGE = "content-range"
+	HEADER_HASH_CRC64    = "hash-crc64ecma"
 
 	HEADER_COPY_SOURCE_IF_MATCH            = "copy-source-if-match"
 	HEADER_COPY_SOURCE_IF_NONE_MATCH       = "copy-source-if-none-match"
@@ -117,6 +121,7 @@ const (
 	HEADER_AUTH_CAMEL                          = "Authorization"
 	HEADER_MD5_CAMEL                           = "Content-MD5"
 	HEADER_LOCATION_CAMEL                      = "Location"
+	HEADER_RETRY_AFTER                         = "retry-after"
 	HEADER_CONTENT_LENGTH_CAMEL                = "Content-Length"
 	HEADER_CONTENT_TYPE_CAML                   = "Content-Type"
 	HEADER_USER_AGENT_CAMEL                    = "User-Agent"
@@ -155,8 +160,11 @@ const (
 	DEFAULT_HEADER_TIMEOUT       = 60
 	DEFAULT_IDLE_CONN_TIMEOUT    = 30
 	DEFAULT_MAX_RETRY_COUNT      = 3
+	DEFAULT_RETRY_BASE_DELAY     = 1 * time.Second
+	DEFAULT_RETRY_MAX_DELAY      = 20 * time.Second
 	DEFAULT_MAX_REDIRECT_COUNT   = 3
 	DEFAULT_MAX_CONN_PER_HOST    = 1000
+	DEFAULT_ABORT_TIMEOUT        = 60 * time.Second
 	EMPTY_CONTENT_SHA256         = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
 	UNSIGNED_PAYLOAD             = "UNSIGNED-PAYLOAD"
 	LONG_DATE_FORMAT             = "20060102T150405Z"
@@ -194,6 +202,14 @@ const (
 	MIN_PART_SIZE     = 100 * 1024
 	DEFAULT_PART_SIZE = 9 * 1024 * 1024
 	MAX_PART_NUM      = 10000
+
+	MAX_DELETE_OBJECTS_NUM = 1000
+
+	DEFAULT_BLOCK_SIZE   = 1024 * 1024
+	DEFAULT_CACHE_BLOCKS = 8
+	DEFAULT_READ_AHEAD   = 2
+
+	DEFAULT_ENDPOINT_PROBE_INTERVAL = 10 * time.Second
 )
 
 // SignatureType defines type of signature
@@ -991,3 +1007,59 @@ const (
 	OBJECT BucketType = "OBJECT"
 	POSIX  BucketType = "POSIX"
 )
+
+// SyncDirectionType defines the direction of SyncDirectory
+type SyncDirectionType string
+
+const (
+	// SyncUpload syncs the bucket prefix with the local directory
+	SyncUpload SyncDirectionType = "Upload"
+	// SyncDownload syncs the local directory with the bucket prefix
+	SyncDownload SyncDirectionType = "Download"
+)
+
+// SyncCompareType defines how SyncDirectory decides whether a file is changed
+type SyncCompareType string
+
+const (
+	// SyncCompareSizeAndModTime compares the size and the last modified time
+	SyncCompareSizeAndModTime SyncCompareType = "SizeAndModTime"
+	// SyncCompareChecksum compares the size and the MD5 of the file with the ETag of the object
+	SyncCompareChecksum SyncCompareType = "Checksum"
+)
+
+// SyncActionType defines the type of an action planned by SyncDirectory
+type SyncActionType string
+
+const (
+	SyncActionUpload       SyncActionType = "Upload"
+	SyncActionDownload     SyncActionType = "Download"
+	SyncActionDeleteObject SyncActionType = "DeleteObject"
+	SyncActionDeleteFile   SyncActionType = "DeleteFile"
+)
+
+// DeleteBucketForceStageType defines the stage of DeleteBucketForce
+type DeleteBucketForceStageType string
+
+const (
+	DeleteBucketForceAbortUploads  DeleteBucketForceStageType = "AbortUploads"
+	DeleteBucketForceDeleteObjects DeleteBucketForceStageType = "DeleteObjects"
+	DeleteBucketForceDeleteBucket  DeleteBucketForceStageType = "DeleteBucket"
+	DeleteBucketForceCompleted     DeleteBucketForceStageType = "Completed"
+)
+
+// BatchResultStatusType defines the status of a key in the results manifest of RunBatchJob
+type BatchResultStatusType string
+
+const (
+	BatchResultSucceeded BatchResultStatusType = "Succeeded"
+	BatchResultFailed    BatchResultStatusType = "Failed"
+)
+
+// EndpointSelectionType defines how the endpoint of a request is selected among the endpoints of the client
+type EndpointSelectionType string
+
+const (
+	EndpointRoundRobin   EndpointSelectionType = "RoundRobin"
+	EndpointLeastLatency EndpointSelectionType = "LeastLatency"
+)
@@ -0,0 +1,240 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// the weight of the latest latency in the moving average of an endpoint
+const endpointLatencyWeight = 0.2
+
+type endpoint struct {
+	urlHolder *urlHolder
+	down      bool
+	probing   bool
+	latency   time.Duration
+	// the bucket of the last failed request, which is used to probe the endpoint
+	bucketName string
+}
+
+func (ep *endpoint) String() string {
+	return fmt.Sprintf("%s://%s:%d", ep.urlHolder.scheme, ep.urlHolder.host, ep.urlHolder.port)
+}
+
+// endpointSet selects the endpoints of the requests of a client with more than one endpoint, its methods
+// do nothing on a nil set.
+type endpointSet struct {
+	lock          sync.Mutex
+	endpoints     []*endpoint
+	selection     EndpointSelectionType
+	probeInterval time.Duration
+	next          int
+	// done is closed when the client is closed, to stop the probes
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newEndpointSet(urlHolders []*urlHolder, selection EndpointSelectionType, probeInterval time.Duration) *endpointSet {
+	if selection != EndpointLeastLatency {
+		selection = EndpointRoundRobin
+	}
+	if probeInterval <= 0 {
+		probeInterval = DEFAULT_ENDPOINT_PROBE_INTERVAL
+	}
+	set := &endpointSet{selection: selection, probeInterval: probeInterval, done: make(chan struct{})}
+	for _, urlHolder := range urlHolders {
+		set.endpoints = append(set.endpoints, &endpoint{urlHolder: urlHolder})
+	}
+	return set
+}
+
+// pick selects the endpoint of an attempt among the healthy endpoints, or among all of them if none is healthy.
+func (set *endpointSet) pick() *endpoint {
+	if set == nil {
+		return nil
+	}
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	candidates := make([]*endpoint, 0, len(set.endpoints))
+	for _, ep := range set.endpoints {
+		if !ep.down {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = set.endpoints
+	}
+
+	if set.selection == EndpointLeastLatency {
+		// an endpoint without latency measured yet is selected first
+		selected := candidates[0]
+		for _, ep := range candidates[1:] {
+			if ep.latency < selected.latency {
+				selected = ep
+			}
+		}
+		return selected
+	}
+	selected := candidates[set.next%len(candidates)]
+	set.next++
+	return selected
+}
+
+// close stops the probes of the endpoints.
+func (set *endpointSet) close() {
+	if set == nil {
+		return
+	}
+	set.closeOnce.Do(func() {
+		close(set.done)
+	})
+}
+
+// traceLatency returns a copy of req and a function which returns the latency of its attempt, which is the time
+// from the request being written to the first byte of the response, so that the upload of the body is not
+// counted. The function returns 0 if the latency is not measured.
+func (set *endpointSet) traceLatency(req *http.Request) (*http.Request, func() time.Duration) {
+	if set == nil || set.selection != EndpointLeastLatency {
+		return req, func() time.Duration { return 0 }
+	}
+	var lock sync.Mutex
+	var wrote, firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			lock.Lock()
+			defer lock.Unlock()
+			wrote = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			lock.Lock()
+			defer lock.Unlock()
+			firstByte = time.Now()
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return req, func() time.Duration {
+		lock.Lock()
+		defer lock.Unlock()
+		if wrote.IsZero() || firstByte.Before(wrote) {
+			return 0
+		}
+		return firstByte.Sub(wrote)
+	}
+}
+
+// succeeded marks the endpoint healthy, and adds the latency of the attempt to its moving average if it is measured.
+func (set *endpointSet) succeeded(ep *endpoint, latency time.Duration) {
+	if set == nil || ep == nil {
+		return
+	}
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	if ep.down {
+		doLog(LEVEL_WARN, "Endpoint [%s] is healthy again.", ep)
+		ep.down = false
+	}
+	if latency <= 0 {
+		return
+	}
+	if ep.latency == 0 {
+		ep.latency = latency
+	} else {
+		ep.latency = time.Duration(endpointLatencyWeight*float64(latency) + (1-endpointLatencyWeight)*float64(ep.latency))
+	}
+}
+
+// failed marks the endpoint down, and starts to probe it if it is not probed yet.
+func (set *endpointSet) failed(OSSClient OSSClient, ep *endpoint, bucketName string) {
+	if set == nil || ep == nil {
+		return
+	}
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	if !ep.down {
+		doLog(LEVEL_WARN, "Endpoint [%s] is marked down.", ep)
+		ep.down = true
+	}
+	if bucketName != "" {
+		ep.bucketName = bucketName
+	}
+	if !ep.probing {
+		ep.probing = true
+		go OSSClient.probeEndpoint(set, ep)
+	}
+}
+
+// probeEndpoint probes the endpoint every probe interval with HeadBucket until it is healthy or the client is closed.
+// An endpoint whose failed requests have no bucket can not be probed, it is marked healthy again after an interval.
+func (OSSClient OSSClient) probeEndpoint(set *endpointSet, ep *endpoint) {
+	timer := time.NewTimer(set.probeInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-set.done:
+			set.lock.Lock()
+			ep.probing = false
+			set.lock.Unlock()
+			return
+		case <-timer.C:
+		}
+		set.lock.Lock()
+		down, bucketName := ep.down, ep.bucketName
+		set.lock.Unlock()
+
+		if !down || bucketName == "" || OSSClient.headBucketOnEndpoint(ep, bucketName, set.probeInterval) {
+			set.lock.Lock()
+			if ep.down {
+				doLog(LEVEL_WARN, "Endpoint [%s] is healthy again.", ep)
+				ep.down = false
+			}
+			ep.probing = false
+			set.lock.Unlock()
+			return
+		}
+		timer.Reset(set.probeInterval)
+	}
+}
+
+// headBucketOnEndpoint sends a single HeadBucket request to the endpoint, any response but 5xx means it is healthy.
+func (OSSClient OSSClient) headBucketOnEndpoint(ep *endpoint, bucketName string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	headers := prepareHeaders(make(map[string][]string), false, OSSClient.conf.signature == SignatureOSS)
+	req, err := OSSClient.getRequest(ctx, "", "", false, nil, HTTP_HEAD, bucketName, "", make(map[string]string), headers, ep.urlHolder)
+	if err != nil {
+		return false
+	}
+	prepareReq(headers, req, nil, OSSClient.conf.userAgent)
+	resp, err := OSSClient.doHTTPRequest(&OperationRequest{Operation: "HeadBucket", Bucket: bucketName, Request: req})
+	if err != nil {
+		doLog(LEVEL_INFO, "Failed to probe endpoint [%s] with error [%v].", ep, err)
+		return false
+	}
+	errMsg := resp.Body.Close()
+	checkAndLogErr(errMsg, LEVEL_WARN, "Failed to close response body with reason: %v", errMsg)
+	return resp.StatusCode < 500
+}
+
+// getURLHolder returns the urlHolder of the endpoint, or the one of the endpoint passed to New if ep is nil.
+func (conf *config) getURLHolder(ep *endpoint) *urlHolder {
+	if ep == nil {
+		return conf.urlHolder
+	}
+	return ep.urlHolder
+}
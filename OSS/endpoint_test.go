@@ -0,0 +1,149 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowReader returns EOF after a delay, so that writing the request body takes at least the delay.
+type slowReader struct {
+	delay time.Duration
+}
+
+func (r slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.delay)
+	return 0, io.EOF
+}
+
+func TestEndpointFailover(t *testing.T) {
+	down := newFakeServer(t)
+	fs := newFakeServer(t)
+	down.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		writeError(w, http.StatusServiceUnavailable, "ServiceUnavailable")
+		return true
+	})
+	client := newTestClient(t, down, WithEndpoints(fs.URL), WithSignature(SignatureV4), WithEndpointProbeInterval(10*time.Millisecond))
+
+	for i := 0; i < 4; i++ {
+		input := &PutObjectInput{}
+		input.Bucket, input.Key = "bucket", "key"
+		input.Body = strings.NewReader("data")
+		if _, err := client.PutObject(input); err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+	}
+	// only the first attempt goes to the endpoint before it is marked down
+	isPut := func(r *http.Request) bool { return r.Method == http.MethodPut }
+	if failed, succeeded := down.countRequests(isPut), fs.countRequests(isPut); failed != 1 || succeeded != 4 {
+		t.Fatalf("expected the requests to fail over, got %d failed and %d succeeded", failed, succeeded)
+	}
+
+	// the attempt on the other endpoint is signed again with its host
+	down.lock.Lock()
+	failedRequest := down.requests[0]
+	down.lock.Unlock()
+	fs.lock.Lock()
+	retriedRequest := fs.requests[0]
+	fs.lock.Unlock()
+	if retriedRequest.Host != strings.TrimPrefix(fs.URL, "http://") || retriedRequest.Host == failedRequest.Host {
+		t.Fatalf("unexpected host %s of the retried attempt", retriedRequest.Host)
+	}
+	if retriedRequest.Header.Get(HEADER_AUTH_CAMEL) == failedRequest.Header.Get(HEADER_AUTH_CAMEL) {
+		t.Fatal("expected the retried attempt to be signed again")
+	}
+
+	// the endpoint is probed until the client is closed
+	isHead := func(r *http.Request) bool { return r.Method == http.MethodHead }
+	for deadline := time.Now().Add(time.Second); down.countRequests(isHead) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the endpoint to be probed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	client.Close()
+	time.Sleep(30 * time.Millisecond)
+	probes := down.countRequests(isHead)
+	time.Sleep(50 * time.Millisecond)
+	if down.countRequests(isHead) != probes {
+		t.Fatal("expected the probes to stop when the client is closed")
+	}
+}
+
+func TestEndpointLeastLatency(t *testing.T) {
+	slow := newFakeServer(t)
+	fs := newFakeServer(t)
+	for _, server := range []*fakeServer{slow, fs} {
+		server.putObject("bucket", "key", []byte("data"))
+	}
+	slow.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		time.Sleep(20 * time.Millisecond)
+		return false
+	})
+	client := newTestClient(t, slow, WithEndpoints(fs.URL), WithEndpointSelection(EndpointLeastLatency))
+
+	for i := 0; i < 10; i++ {
+		input := &GetObjectMetadataInput{}
+		input.Bucket, input.Key = "bucket", "key"
+		if _, err := client.GetObjectMetadata(input); err != nil {
+			t.Fatalf("GetObjectMetadata failed: %v", err)
+		}
+	}
+	all := func(r *http.Request) bool { return true }
+	if slowCount, fastCount := slow.countRequests(all), fs.countRequests(all); slowCount != 1 || fastCount != 9 {
+		t.Fatalf("expected the requests to go to the fastest endpoint, got %d slow and %d fast", slowCount, fastCount)
+	}
+}
+
+func TestEndpointLatencyExcludesUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+	set := newEndpointSet([]*urlHolder{parseEndpoint(server.URL)}, EndpointLeastLatency, 0)
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, slowReader{delay: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, latency := set.traceLatency(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if measured := latency(); measured <= 0 || measured >= 50*time.Millisecond {
+		t.Fatalf("expected the latency to exclude the upload of the body, got %v", measured)
+	}
+}
+
+func TestEndpointsMixingIPAndDomain(t *testing.T) {
+	if _, err := New("ak", "sk", "http://127.0.0.1:8080", WithEndpoints("http://oss.example.com")); err == nil {
+		t.Fatal("expected the endpoints mixing an IP address and a domain name to be rejected")
+	}
+	client, err := New("ak", "sk", "http://127.0.0.1:8080", WithEndpoints("http://oss.example.com"), WithPathStyle(true))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	client.Close()
+	client, err = New("ak", "sk", "http://oss-1.example.com", WithEndpoints("http://oss-2.example.com"))
+	if err != nil || client.conf.pathStyle {
+		t.Fatalf("expected the domain names to keep the virtual hosting, err: %v", err)
+	}
+	client.Close()
+}
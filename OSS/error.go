@@ -32,3 +32,39 @@ func (err OSSError) Error() string {
 	return fmt.Sprintf("OSS: service returned error: Status=%s, Code=%s, Message=%s, RequestId=%s",
 		err.Status, err.Code, err.Message, err.RequestId)
 }
+
+// IntegrityError defines the error returned when the checksum of the transferred data does not match
+// the checksum of the object. PartNumber is 0 if the checksum of the whole object does not match.
+type IntegrityError struct {
+	Bucket     string
+	Key        string
+	PartNumber int
+	Algorithm  string
+	Expected   string
+	Actual     string
+}
+
+func (err IntegrityError) Error() string {
+	if err.PartNumber > 0 {
+		return fmt.Sprintf("OSS: integrity check failed: Bucket=%s, Key=%s, PartNumber=%d, %s expected=%s, actual=%s",
+			err.Bucket, err.Key, err.PartNumber, err.Algorithm, err.Expected, err.Actual)
+	}
+	return fmt.Sprintf("OSS: integrity check failed: Bucket=%s, Key=%s, %s expected=%s, actual=%s",
+		err.Bucket, err.Key, err.Algorithm, err.Expected, err.Actual)
+}
+
+// AclCopyError defines the error returned when an object is copied, but its ACL can not be copied from
+// the source object.
+type AclCopyError struct {
+	Bucket string
+	Key    string
+	Err    error
+}
+
+func (err AclCopyError) Error() string {
+	return fmt.Sprintf("OSS: object is copied, but copying its acl failed: Bucket=%s, Key=%s, %v", err.Bucket, err.Key, err.Err)
+}
+
+func (err AclCopyError) Unwrap() error {
+	return err.Err
+}
@@ -13,13 +13,16 @@
 package OSS
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type extensionOptions interface{}
 type extensionHeaders func(headers map[string][]string, isOSS bool) error
+type extensionContext func() context.Context
 
 func setHeaderPrefix(key string, value string) extensionHeaders {
 	return func(headers map[string][]string, isOSS bool) error {
@@ -39,3 +42,49 @@ func WithReqPaymentHeader(requester PayerType) extensionHeaders {
 func WithTrafficLimitHeader(trafficLimit int64) extensionHeaders {
 	return setHeaderPrefix(TRAFFIC_LIMIT, strconv.FormatInt(trafficLimit, 10))
 }
+
+// WithContext sets the context for the request, which overrides the context set by WithRequestContext.
+//
+// When the context is canceled or its deadline is exceeded, the request and its retries are stopped,
+// and the error of the context is returned.
+func WithContext(ctx context.Context) extensionContext {
+	return func() context.Context {
+		return ctx
+	}
+}
+
+// detachedContext keeps the values of its parent, such as the span of the call, but is never canceled with it.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) { return }
+func (detachedContext) Done() <-chan struct{}                   { return nil }
+func (detachedContext) Err() error                              { return nil }
+
+// withDetachedContext returns the extensions with a context which is not canceled by the caller and times out
+// after timeout, for the requests which clean up after a call failed because its context is canceled.
+func (OSSClient OSSClient) withDetachedContext(extensions []extensionOptions, timeout time.Duration) ([]extensionOptions, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(detachedContext{OSSClient.getRequestContext(extensions)}, timeout)
+	detached := []extensionOptions{WithContext(ctx)}
+	for _, extension := range extensions {
+		if _, ok := extension.(extensionContext); !ok {
+			detached = append(detached, extension)
+		}
+	}
+	return detached, cancel
+}
+
+func (OSSClient OSSClient) getRequestContext(extensions []extensionOptions) context.Context {
+	for _, extension := range extensions {
+		if extensionCtx, ok := extension.(extensionContext); ok {
+			if ctx := extensionCtx(); ctx != nil {
+				return ctx
+			}
+		}
+	}
+	if OSSClient.conf.ctx != nil {
+		return OSSClient.conf.ctx
+	}
+	return context.Background()
+}
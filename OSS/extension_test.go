@@ -0,0 +1,133 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithContextCancelsRequest(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		<-r.Context().Done()
+		return true
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	input := &GetObjectMetadataInput{Bucket: "bucket", Key: "key"}
+	_, err := client.GetObjectMetadata(input, WithContext(ctx))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the error of the context, got: %v", err)
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return true }); count != 1 {
+		t.Fatalf("expected no retry after the context is done, got %d requests", count)
+	}
+}
+
+func TestWithContextInterruptsRetryDelay(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs, WithRetryPolicy(&DefaultRetryPolicy{BaseDelay: time.Minute, MaxDelay: time.Minute}))
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusServiceUnavailable, "ServiceUnavailable")
+		return true
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetObjectMetadata(&GetObjectMetadataInput{Bucket: "bucket", Key: "key"}, WithContext(ctx))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the error of the context, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the retry delay is not interrupted, elapsed: %v", elapsed)
+	}
+}
+
+func TestUploadFileWithCanceledContext(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, bytes.Repeat([]byte("a"), 2*MIN_PART_SIZE), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	input := &UploadFileInput{UploadFile: file, PartSize: MIN_PART_SIZE}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.UploadFile(input, WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the error of the context, got: %v", err)
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return r.URL.Query().Has("partNumber") }); count != 0 {
+		t.Fatalf("expected no part to be uploaded, got %d", count)
+	}
+}
+
+func TestUploadCanceledDuringPartsIsAborted(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 3*MIN_PART_SIZE)
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	uploads := map[string]func(client *OSSClient, ctx context.Context) error{
+		"UploadFile": func(client *OSSClient, ctx context.Context) error {
+			input := &UploadFileInput{UploadFile: file, PartSize: MIN_PART_SIZE, TaskNum: 1}
+			input.Bucket, input.Key = "bucket", "key"
+			_, err := client.UploadFile(input, WithContext(ctx))
+			return err
+		},
+		"UploadStream": func(client *OSSClient, ctx context.Context) error {
+			input := &UploadStreamInput{Body: bytes.NewReader(data), PartSize: MIN_PART_SIZE, TaskNum: 1}
+			input.Bucket, input.Key = "bucket", "key"
+			_, err := client.UploadStream(input, WithContext(ctx))
+			return err
+		},
+	}
+	for name, upload := range uploads {
+		fs := newFakeServer(t)
+		client := newTestClient(t, fs)
+		ctx, cancel := context.WithCancel(context.Background())
+		// the context is canceled while the first part is being uploaded
+		fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+			if r.URL.Query().Has("partNumber") {
+				cancel()
+				select {
+				case <-r.Context().Done():
+				case <-time.After(100 * time.Millisecond):
+				}
+				return true
+			}
+			return false
+		})
+		if err := upload(client, ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: expected the error of the context, got: %v", name, err)
+		}
+		aborts := fs.countRequests(func(r *http.Request) bool {
+			return r.Method == http.MethodDelete && r.URL.Query().Has("uploadId")
+		})
+		if aborts != 1 || fs.uploadCount() != 0 {
+			t.Fatalf("%s: expected the multipart upload to be aborted once, got %d aborts and %d uploads", name, aborts, fs.uploadCount())
+		}
+	}
+}
@@ -14,10 +14,10 @@ package OSS
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
@@ -120,75 +120,106 @@ func (OSSClient OSSClient) doAction(action, method, bucketName, objectKey string
 	}
 
 	for _, extension := range extensions {
-		if extensionHeader, ok := extension.(extensionHeaders); ok {
-			_err := extensionHeader(headers, OSSClient.conf.signature == SignatureOSS)
+		switch _extension := extension.(type) {
+		case extensionHeaders:
+			_err := _extension(headers, OSSClient.conf.signature == SignatureOSS)
 			if _err != nil {
 				doLog(LEVEL_INFO, fmt.Sprintf("set header with error: %v", _err))
 			}
-		} else {
+		case extensionProgressListener, extensionContext, extensionResumableBody, extensionPool, extensionSharedPool, extensionRateLimiter:
+		default:
 			doLog(LEVEL_INFO, "Unsupported extensionOptions")
 		}
 	}
+
+	ctx := withOperation(withRateLimiters(OSSClient.getRequestContext(extensions), extensions), action)
+	ctx, span := OSSClient.startSpan(ctx, action, bucketName, objectKey)
+	ctx, record := OSSClient.withAttemptRecord(ctx)
+	if partNumber, ok := params["partNumber"]; ok {
+		span.SetAttributes(Attribute{Key: ATTRIBUTE_PART_NUMBER, Value: partNumber})
+	}
+	tracker := newProgressTracker(getProgressListener(extensions), 0, 0)
+	data = attachProgressTracker(data, headers, tracker)
+	tracker.started()
+
 	switch method {
 	case HTTP_GET:
-		resp, respError = OSSClient.doHTTPGet(bucketName, objectKey, params, headers, data, repeatable)
+		resp, respError = OSSClient.doHTTPGet(ctx, bucketName, objectKey, params, headers, data, repeatable)
 	case HTTP_POST:
-		resp, respError = OSSClient.doHTTPPost(bucketName, objectKey, params, headers, data, repeatable)
+		resp, respError = OSSClient.doHTTPPost(ctx, bucketName, objectKey, params, headers, data, repeatable)
 	case HTTP_PUT:
-		resp, respError = OSSClient.doHTTPPut(bucketName, objectKey, params, headers, data, repeatable)
+		resp, respError = OSSClient.doHTTPPut(ctx, bucketName, objectKey, params, headers, data, repeatable)
 	case HTTP_DELETE:
-		resp, respError = OSSClient.doHTTPDelete(bucketName, objectKey, params, headers, data, repeatable)
+		resp, respError = OSSClient.doHTTPDelete(ctx, bucketName, objectKey, params, headers, data, repeatable)
 	case HTTP_HEAD:
-		resp, respError = OSSClient.doHTTPHead(bucketName, objectKey, params, headers, data, repeatable)
+		resp, respError = OSSClient.doHTTPHead(ctx, bucketName, objectKey, params, headers, data, repeatable)
 	case HTTP_OPTIONS:
-		resp, respError = OSSClient.doHTTPOptions(bucketName, objectKey, params, headers, data, repeatable)
+		resp, respError = OSSClient.doHTTPOptions(ctx, bucketName, objectKey, params, headers, data, repeatable)
 	default:
 		respError = errors.New("Unexpect http method error")
 	}
 	if respError == nil && output != nil {
+		_, isReadCloser := output.(IReadCloser)
+		if isReadCloser && method == HTTP_GET && isResumableBody(extensions) {
+			resp.Body = newResumableBody(OSSClient, ctx, bucketName, objectKey, params, headers, resp)
+		}
+		if isReadCloser && tracker != nil {
+			tracker.setTotalBytes(resp.ContentLength)
+			resp.Body = &progressReadCloser{ReadCloser: resp.Body, tracker: tracker}
+		}
 		respError = ParseResponseToBaseModel(resp, output, xmlResult, OSSClient.conf.signature == SignatureOSS)
 		if respError != nil {
 			doLog(LEVEL_WARN, "Parse response to BaseModel with error: %v", respError)
+			tracker.failed()
+		} else if !isReadCloser {
+			tracker.completed()
 		}
 	} else {
 		doLog(LEVEL_WARN, "Do http request with error: %v", respError)
+		if respError != nil {
+			tracker.failed()
+		} else {
+			tracker.completed()
+		}
 	}
 
 	if isDebugLogEnabled() {
 		doLog(LEVEL_DEBUG, "End method %s, OSSclient cost %d ms", action, (GetCurrentTimestamp() - start))
 	}
 
+	record.setAttributes(span)
+	endSpan(span, respError)
 	return respError
 }
 
-func (OSSClient OSSClient) doHTTPGet(bucketName, objectKey string, params map[string]string,
+func (OSSClient OSSClient) doHTTPGet(ctx context.Context, bucketName, objectKey string, params map[string]string,
 	headers map[string][]string, data interface{}, repeatable bool) (*http.Response, error) {
-	return OSSClient.doHTTP(HTTP_GET, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
+	return OSSClient.doHTTP(ctx, HTTP_GET, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
 }
 
-func (OSSClient OSSClient) doHTTPHead(bucketName, objectKey string, params map[string]string,
+func (OSSClient OSSClient) doHTTPHead(ctx context.Context, bucketName, objectKey string, params map[string]string,
 	headers map[string][]string, data interface{}, repeatable bool) (*http.Response, error) {
-	return OSSClient.doHTTP(HTTP_HEAD, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
+	return OSSClient.doHTTP(ctx, HTTP_HEAD, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
 }
 
-func (OSSClient OSSClient) doHTTPOptions(bucketName, objectKey string, params map[string]string,
+func (OSSClient OSSClient) doHTTPOptions(ctx context.Context, bucketName, objectKey string, params map[string]string,
 	headers map[string][]string, data interface{}, repeatable bool) (*http.Response, error) {
-	return OSSClient.doHTTP(HTTP_OPTIONS, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
+	return OSSClient.doHTTP(ctx, HTTP_OPTIONS, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
 }
 
-func (OSSClient OSSClient) doHTTPDelete(bucketName, objectKey string, params map[string]string,
+func (OSSClient OSSClient) doHTTPDelete(ctx context.Context, bucketName, objectKey string, params map[string]string,
 	headers map[string][]string, data interface{}, repeatable bool) (*http.Response, error) {
-	return OSSClient.doHTTP(HTTP_DELETE, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
+	return OSSClient.doHTTP(ctx, HTTP_DELETE, bucketName, objectKey, params, prepareHeaders(headers, false, OSSClient.conf.signature == SignatureOSS), data, repeatable)
 }
 
-func (OSSClient OSSClient) doHTTPPut(bucketName, objectKey string, params map[string]string,
+func (OSSClient OSSClient) doHTTPPut(ctx context.Context, bucketName, objectKey string, params map[string]string,
 	headers map[string][]string, data interface{}, repeatable bool) (*http.Response, error) {
-	return OSSClient.doHTTP(HTTP_PUT, bucketName, objectKey, params, prepareHeaders(headers, true, OSSClient.conf.signature == SignatureOSS), data, repeatable)
+	return OSSClient.doHTTP(ctx, HTTP_PUT, bucketName, objectKey, params, prepareHeaders(headers, true, OSSClient.conf.signature == SignatureOSS), data, repeatable)
 }
 
-func (OSSClient OSSClient) doHTTPPost(bucketName, objectKey string, params map[string]string,
+func (OSSClient OSSClient) doHTTPPost(ctx context.Context, bucketName, objectKey string, params map[string]string,
 	headers map[string][]string, data interface{}, repeatable bool) (*http.Response, error) {
-	return OSSClient.doHTTP(HTTP_POST, bucketName, objectKey, params, prepareHeaders(headers, true, OSSClient.conf.signature == SignatureOSS), data, repeatable)
+	return OSSClient.doHTTP(ctx, HTTP_POST, bucketName, objectKey, params, prepareHeaders(headers, true, OSSClient.conf.signature == SignatureOSS), data, repeatable)
 }
 
 func prepareAgentHeader(clientUserAgent string) string {
@@ -235,9 +266,17 @@ func (OSSClient OSSClient) doHTTPWithSignedURL(action, method string, signedURL
 	if err != nil {
 		return err
 	}
+	ctx := context.Background()
 	if OSSClient.conf.ctx != nil {
-		req = req.WithContext(OSSClient.conf.ctx)
-	}
+		ctx = OSSClient.conf.ctx
+	}
+	ctx, span := OSSClient.startSpan(ctx, action, "", "")
+	ctx, record := OSSClient.withAttemptRecord(ctx)
+	defer func() {
+		record.setAttributes(span)
+		endSpan(span, respError)
+	}()
+	req = req.WithContext(ctx)
 	var resp *http.Response
 
 	var isSecurityToken bool
@@ -281,12 +320,24 @@ func (OSSClient OSSClient) doHTTPWithSignedURL(action, method string, signedURL
 	userAgent := prepareAgentHeader(OSSClient.conf.userAgent)
 	req.Header[HEADER_USER_AGENT_CAMEL] = []string{userAgent}
 	start := GetCurrentTimestamp()
-	resp, err = OSSClient.httpClient.Do(req)
+	operationRequest := &OperationRequest{Operation: action, Request: req}
+	meter := OSSClient.newAttemptMeter(operationRequest)
+	resp, err = OSSClient.doHTTPRequest(operationRequest)
+	meter.responded(resp)
 	if isInfoLogEnabled() {
 		doLog(LEVEL_INFO, "Do http request cost %d ms", (GetCurrentTimestamp() - start))
 	}
 
+	// the body of a successful response of GetObject is read after return, so the attempt is recorded when it is closed
+	_, isReadCloser := output.(IReadCloser)
+	streamed := isReadCloser && err == nil && resp.StatusCode < 300
+	if streamed {
+		meter.doneOnClose(resp)
+	}
 	respError = OSSClient.getSignedURLResponse(action, output, xmlResult, resp, err, start)
+	if !streamed || respError != nil {
+		meter.done(respError)
+	}
 
 	return
 }
@@ -312,15 +363,15 @@ func prepareData(headers map[string][]string, data interface{}) (io.Reader, erro
 	return _data, nil
 }
 
-func (OSSClient OSSClient) getRequest(redirectURL, requestURL string, redirectFlag bool, _data io.Reader, method,
-	bucketName, objectKey string, params map[string]string, headers map[string][]string) (*http.Request, error) {
+func (OSSClient OSSClient) getRequest(ctx context.Context, redirectURL, requestURL string, redirectFlag bool, _data io.Reader, method,
+	bucketName, objectKey string, params map[string]string, headers map[string][]string, urlHolder *urlHolder) (*http.Request, error) {
 	if redirectURL != "" {
 		if !redirectFlag {
 			parsedRedirectURL, err := url.Parse(redirectURL)
 			if err != nil {
 				return nil, err
 			}
-			requestURL, err = OSSClient.doAuth(method, bucketName, objectKey, params, headers, parsedRedirectURL.Host)
+			requestURL, err = OSSClient.doAuth(method, bucketName, objectKey, params, headers, parsedRedirectURL.Host, urlHolder)
 			if err != nil {
 				return nil, err
 			}
@@ -333,16 +384,13 @@ func (OSSClient OSSClient) getRequest(redirectURL, requestURL string, redirectFl
 		requestURL = redirectURL
 	} else {
 		var err error
-		requestURL, err = OSSClient.doAuth(method, bucketName, objectKey, params, headers, "")
+		requestURL, err = OSSClient.doAuth(method, bucketName, objectKey, params, headers, "", urlHolder)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	req, err := http.NewRequest(method, requestURL, _data)
-	if OSSClient.conf.ctx != nil {
-		req = req.WithContext(OSSClient.conf.ctx)
-	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, _data)
 	if err != nil {
 		return nil, err
 	}
@@ -400,7 +448,7 @@ func prepareReq(headers map[string][]string, req, lastRequest *http.Request, cli
 }
 
 func canNotRetry(repeatable bool, statusCode int) bool {
-	if !repeatable || (statusCode >= 400 && statusCode < 500) || statusCode == 304 {
+	if !repeatable || statusCode == 304 {
 		return true
 	}
 	return false
@@ -443,6 +491,7 @@ func prepareRetry(resp *http.Response, headers map[string][]string, _data io.Rea
 			return nil, nil, err
 		}
 	} else if r, ok := _data.(*fileReaderWrapper); ok {
+		r.rollback()
 		fd, err := os.Open(r.filePath)
 		if err != nil {
 			return nil, nil, err
@@ -451,6 +500,7 @@ func prepareRetry(resp *http.Response, headers map[string][]string, _data io.Rea
 		fileReaderWrapper.mark = r.mark
 		fileReaderWrapper.reader = fd
 		fileReaderWrapper.totalCount = r.totalCount
+		fileReaderWrapper.tracker = r.tracker
 		_data = fileReaderWrapper
 		_, err = fd.Seek(r.mark, 0)
 		if err != nil {
@@ -463,12 +513,13 @@ func prepareRetry(resp *http.Response, headers map[string][]string, _data io.Rea
 		if err != nil {
 			return nil, nil, err
 		}
+		r.rollback()
 		r.readedCount = 0
 	}
 	return _data, resp, nil
 }
 
-func (OSSClient OSSClient) doHTTP(method, bucketName, objectKey string, params map[string]string,
+func (OSSClient OSSClient) doHTTP(ctx context.Context, method, bucketName, objectKey string, params map[string]string,
 	headers map[string][]string, data interface{}, repeatable bool) (resp *http.Response, respError error) {
 
 	bucketName = strings.TrimSpace(bucketName)
@@ -486,36 +537,66 @@ func (OSSClient OSSClient) doHTTP(method, bucketName, objectKey string, params m
 
 	var lastRequest *http.Request
 	redirectFlag := false
-	for i, redirectCount := 0, 0; i <= maxRetryCount; i++ {
-		req, err := OSSClient.getRequest(redirectURL, requestURL, redirectFlag, _data,
-			method, bucketName, objectKey, params, headers)
+	limiters := OSSClient.getRateLimiters(ctx)
+	retryPolicy := OSSClient.conf.retryPolicy
+	for i, attempt, redirectCount := 0, 0, 0; i <= maxRetryCount; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// the request is signed again for the endpoint of each attempt, a redirected attempt goes to its location
+		var ep *endpoint
+		if redirectURL == "" {
+			ep = OSSClient.conf.endpoints.pick()
+		}
+		req, err := OSSClient.getRequest(ctx, redirectURL, requestURL, redirectFlag, _data,
+			method, bucketName, objectKey, params, headers, OSSClient.conf.getURLHolder(ep))
 		if err != nil {
 			return nil, err
 		}
 		logHeaders(headers, OSSClient.conf.signature)
 
 		lastRequest = prepareReq(headers, req, lastRequest, OSSClient.conf.userAgent)
+		req.Body = newRateLimitedReadCloser(ctx, req.Body, limiters)
+		req, latency := OSSClient.conf.endpoints.traceLatency(req)
 
 		start := GetCurrentTimestamp()
-		resp, err = OSSClient.httpClient.Do(req)
-		doLog(LEVEL_INFO, "Do http request cost %d ms", (GetCurrentTimestamp() - start))
+		operationRequest := &OperationRequest{Operation: getOperation(ctx), Bucket: bucketName, Key: objectKey, Attempt: i, Request: req}
+		meter := OSSClient.newAttemptMeter(operationRequest)
+		resp, err = OSSClient.doHTTPRequest(operationRequest)
+		meter.responded(resp)
+		cost := GetCurrentTimestamp() - start
+		doLog(LEVEL_INFO, "Do http request cost %d ms", cost)
+		if err != nil {
+			if ctx.Err() == nil {
+				OSSClient.conf.endpoints.failed(OSSClient, ep, bucketName)
+			}
+		} else if resp.StatusCode >= 500 {
+			OSSClient.conf.endpoints.failed(OSSClient, ep, bucketName)
+		} else {
+			OSSClient.conf.endpoints.succeeded(ep, latency())
+		}
 		//fmt.Printf("resp:%s", resp)
 		var msg interface{}
+		redirected := false
 		if err != nil {
 			msg = err
 			respError = err
 			resp = nil
-			if !repeatable {
+			meter.done(err)
+			if !repeatable || ctx.Err() != nil || !retryPolicy.ShouldRetry(attempt, err) {
 				break
 			}
 		} else {
 			doLog(LEVEL_DEBUG, "Response headers: %v", resp.Header)
 			if resp.StatusCode < 300 {
 				respError = nil
+				resp.Body = newRateLimitedReadCloser(ctx, resp.Body, limiters)
+				meter.doneOnClose(resp)
 				break
 			} else if canNotRetry(repeatable, resp.StatusCode) {
 				respError = ParseResponseToOSSError(resp, OSSClient.conf.signature == SignatureOSS)
 				resp = nil
+				meter.done(respError)
 				break
 			} else if resp.StatusCode >= 300 && resp.StatusCode < 400 {
 				location := resp.Header.Get(HEADER_LOCATION_CAMEL)
@@ -526,13 +607,22 @@ func (OSSClient OSSClient) doHTTP(method, bucketName, objectKey string, params m
 					maxRetryCount++
 					redirectCount++
 					redirectFlag = setRedirectFlag(resp.StatusCode, method)
+					redirected = true
+					meter.done(nil)
 				} else {
 					respError = ParseResponseToOSSError(resp, OSSClient.conf.signature == SignatureOSS)
 					resp = nil
+					meter.done(respError)
 					break
 				}
 			} else {
 				msg = resp.Status
+				respError = ParseResponseToOSSError(resp, OSSClient.conf.signature == SignatureOSS)
+				resp = nil
+				meter.done(respError)
+				if !retryPolicy.ShouldRetry(attempt, respError) {
+					break
+				}
 			}
 		}
 		if i != maxRetryCount {
@@ -548,7 +638,13 @@ func (OSSClient OSSClient) doHTTP(method, bucketName, objectKey string, params m
 					}()
 				}
 			}
-			time.Sleep(time.Duration(float64(i+2) * rand.Float64() * float64(time.Second)))
+			if redirected {
+				continue
+			}
+			if !sleepWithContext(ctx, retryPolicy.RetryDelay(attempt, respError)) {
+				return nil, ctx.Err()
+			}
+			attempt++
 		} else {
 			doLog(LEVEL_ERROR, "Failed to send request with reason:%v", msg)
 			if resp != nil {
@@ -560,6 +656,18 @@ func (OSSClient OSSClient) doHTTP(method, bucketName, objectKey string, params m
 	return
 }
 
+// sleepWithContext waits for the duration, and returns false if ctx is done before that.
+func sleepWithContext(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 type connDelegate struct {
 	conn          net.Conn
 	socketTimeout time.Duration
@@ -0,0 +1,162 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	integrityAlgorithmMD5   = "MD5"
+	integrityAlgorithmCRC64 = "CRC64"
+	integrityAlgorithmSize  = "Size"
+)
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// fileSectionMd5 returns the MD5 of size bytes of the file from offset on.
+func fileSectionMd5(filePath string, offset, size int64) ([]byte, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		errMsg := fd.Close()
+		if errMsg != nil {
+			doLog(LEVEL_WARN, "Failed to close file with reason: %v", errMsg)
+		}
+	}()
+	hash := md5.New()
+	if _, err = io.Copy(hash, io.NewSectionReader(fd, offset, size)); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// fileSum writes the content of the file to hash.
+func fileSum(filePath string, hash hash.Hash) error {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		errMsg := fd.Close()
+		if errMsg != nil {
+			doLog(LEVEL_WARN, "Failed to close file with reason: %v", errMsg)
+		}
+	}()
+	_, err = io.Copy(hash, fd)
+	return err
+}
+
+// fileMd5 returns the hex MD5 of the file.
+func fileMd5(filePath string) (string, error) {
+	hash := md5.New()
+	if err := fileSum(filePath, hash); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fileCrc64 returns the decimal CRC64 of the file, in the format of the hash-crc64ecma header.
+func fileCrc64(filePath string) (string, error) {
+	hash := crc64.New(crc64Table)
+	if err := fileSum(filePath, hash); err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(hash.Sum64(), 10), nil
+}
+
+// md5ReadCloser calculates the MD5 of the data read from the body.
+type md5ReadCloser struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (rc *md5ReadCloser) Read(p []byte) (n int, err error) {
+	n, err = rc.ReadCloser.Read(p)
+	rc.hash.Write(p[:n])
+	return
+}
+
+func (rc *md5ReadCloser) base64Md5() string {
+	return base64.StdEncoding.EncodeToString(rc.hash.Sum(nil))
+}
+
+// newContentMd5Body returns the Content-MD5 of the response and a body calculating the MD5 of the data
+// read from it, or an empty string and nil if the service does not return the Content-MD5 or the response
+// is a part of the object, since the Content-MD5 of a ranged GET may be the MD5 of the whole object.
+func newContentMd5Body(output *GetObjectOutput) (string, *md5ReadCloser) {
+	values, ok := output.ResponseHeaders[strings.ToLower(HEADER_MD5_CAMEL)]
+	if !ok || len(values) == 0 || values[0] == "" || !coversObject(output.ResponseHeaders) {
+		return "", nil
+	}
+	body := &md5ReadCloser{ReadCloser: output.Body, hash: md5.New()}
+	output.Body = body
+	return values[0], body
+}
+
+// coversObject reports whether the response with responseHeaders contains the whole object, that is it has
+// no Content-Range or the range is the size of the object.
+func coversObject(responseHeaders map[string][]string) bool {
+	values, ok := responseHeaders[HEADER_CONTENT_RANGE]
+	if !ok || len(values) == 0 || values[0] == "" {
+		return true
+	}
+	var start, end, size int64
+	if _, err := fmt.Sscanf(values[0], "bytes %d-%d/%d", &start, &end, &size); err != nil {
+		return false
+	}
+	return start == 0 && end+1 == size
+}
+
+// etagIsMd5 reports whether the ETag of an object encrypted with sseHeader is the MD5 of its content,
+// which is not the case for the objects encrypted with SSE-C or SSE-KMS.
+func etagIsMd5(sseHeader ISseHeader) bool {
+	switch header := sseHeader.(type) {
+	case SseCHeader, *SseCHeader:
+		return false
+	case SseKmsHeader:
+		return header.Encryption == DEFAULT_SSE_C_ENCRYPTION
+	case *SseKmsHeader:
+		return header.Encryption == DEFAULT_SSE_C_ENCRYPTION
+	}
+	return true
+}
+
+func trimETag(etag string) string {
+	return strings.Trim(etag, "\"")
+}
+
+// multipartETag returns the ETag of an object uploaded with the parts of etags, which is the MD5
+// of the concatenated MD5 of the parts followed by the number of the parts.
+func multipartETag(etags []string) (string, bool) {
+	hash := md5.New()
+	for _, etag := range etags {
+		value, err := hex.DecodeString(trimETag(etag))
+		if err != nil || len(value) != md5.Size {
+			return "", false
+		}
+		hash.Write(value)
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(hash.Sum(nil)), len(etags)), true
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const (
+	noContentMd5 = iota
+	rangeContentMd5
+	objectContentMd5
+)
+
+// serveRanges serves the ranged GETs of the object with the Content-MD5 of each range or of the whole object
+// depending on contentMd5, and flips the first byte of the range starting at corruptOffset.
+func serveRanges(fs *fakeServer, contentMd5 int, corruptOffset int64) {
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		var start, end int64
+		if r.Method != http.MethodGet {
+			return false
+		}
+		if _, err := fmt.Sscanf(r.Header.Get(HEADER_RANGE), "bytes=%d-%d", &start, &end); err != nil {
+			return false
+		}
+		object, _ := fs.getObject("bucket", "key")
+		data := append([]byte{}, object.data[start:end+1]...)
+		switch contentMd5 {
+		case rangeContentMd5:
+			sum := md5.Sum(data)
+			w.Header().Set(HEADER_MD5_CAMEL, base64.StdEncoding.EncodeToString(sum[:]))
+		case objectContentMd5:
+			sum := md5.Sum(object.data)
+			w.Header().Set(HEADER_MD5_CAMEL, base64.StdEncoding.EncodeToString(sum[:]))
+		}
+		if start == corruptOffset {
+			data[0] ^= 0xff
+		}
+		w.Header().Set("ETag", object.etag)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(object.data)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(data)
+		return true
+	})
+}
+
+func downloadFileInput(t *testing.T) *DownloadFileInput {
+	input := &DownloadFileInput{DownloadFile: filepath.Join(t.TempDir(), "file"), PartSize: 1000, TaskNum: 2, EnableIntegrityCheck: true}
+	input.Bucket, input.Key = "bucket", "key"
+	return input
+}
+
+func TestDownloadFileIntegrity(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := newTestObject(fs, 5500)
+	object, _ := fs.getObject("bucket", "key")
+	object.metadata = map[string]string{HEADER_PREFIX + HEADER_HASH_CRC64: strconv.FormatUint(crc64.Checksum(data, crc64Table), 10)}
+
+	// the Content-MD5 of a range is ignored, whether it is the MD5 of the range or of the whole object
+	for _, contentMd5 := range []int{rangeContentMd5, objectContentMd5} {
+		serveRanges(fs, contentMd5, -1)
+		input := downloadFileInput(t)
+		if _, err := client.DownloadFile(input); err != nil {
+			t.Fatalf("DownloadFile failed: %v", err)
+		}
+		if read, err := os.ReadFile(input.DownloadFile); err != nil || !bytes.Equal(read, data) {
+			t.Fatalf("unexpected file, err: %v", err)
+		}
+	}
+}
+
+func TestDownloadFilePartMd5Mismatch(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 5500)
+	serveRanges(fs, rangeContentMd5, 0)
+
+	input := downloadFileInput(t)
+	input.PartSize = 10000
+	_, err := client.DownloadFile(input)
+	var integrityError IntegrityError
+	if !errors.As(err, &integrityError) || integrityError.PartNumber != 1 || integrityError.Algorithm != integrityAlgorithmMD5 {
+		t.Fatalf("expected the integrity error of part 1, got: %v", err)
+	}
+	if _, err := os.Stat(input.DownloadFile); !os.IsNotExist(err) {
+		t.Fatal("unexpected download file")
+	}
+}
+
+func TestDownloadFileMd5Mismatch(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 5500)
+	serveRanges(fs, rangeContentMd5, 1000)
+
+	input := downloadFileInput(t)
+	_, err := client.DownloadFile(input)
+	var integrityError IntegrityError
+	if !errors.As(err, &integrityError) || integrityError.PartNumber != 0 || integrityError.Algorithm != integrityAlgorithmMD5 {
+		t.Fatalf("expected the integrity error of the file, got: %v", err)
+	}
+}
+
+func TestDownloadFileCrc64Mismatch(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 5500)
+	object, _ := fs.getObject("bucket", "key")
+	object.metadata = map[string]string{HEADER_PREFIX + HEADER_HASH_CRC64: "1"}
+	serveRanges(fs, noContentMd5, -1)
+
+	input := downloadFileInput(t)
+	_, err := client.DownloadFile(input)
+	var integrityError IntegrityError
+	if !errors.As(err, &integrityError) || integrityError.Algorithm != integrityAlgorithmCRC64 || integrityError.Expected != "1" {
+		t.Fatalf("expected the crc64 integrity error of the file, got: %v", err)
+	}
+}
+
+func TestUploadFileIntegrity(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := bytes.Repeat([]byte("0123456789"), MIN_PART_SIZE/4)
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	input := &UploadFileInput{UploadFile: file, PartSize: MIN_PART_SIZE, TaskNum: 2, EnableIntegrityCheck: true}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.UploadFile(input); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if object, ok := fs.getObject("bucket", "key"); !ok || !bytes.Equal(object.data, data) {
+		t.Fatal("unexpected object")
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AttemptMetrics is the metrics of an HTTP attempt of an operation.
+//
+// Attempt starts from 0, so an attempt whose Attempt is greater than 0 is a retry or a redirect.
+// Latency is the time until the response headers are received. StatusCode is 0 if no response is
+// received, and ErrorCode is the code of the OSSError if the service returns an error response.
+type AttemptMetrics struct {
+	Operation     string
+	Bucket        string
+	Attempt       int
+	Latency       time.Duration
+	StatusCode    int
+	ErrorCode     string
+	Err           error
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// MetricsRecorder receives the metrics of the client, the methods are called concurrently and must not block.
+//
+// RecordAttempt is called once for each attempt. The attempt of a successful response is recorded when
+// its body is closed, so that BytesReceived includes the body, which means that the body of the output
+// of GetObject must be closed. RecordDial is called for each connection dialed by the transport of the
+// client, it is not called if the transport is set with WithHttpTransport or WithHttpClient.
+type MetricsRecorder interface {
+	RecordAttempt(metrics *AttemptMetrics)
+	RecordDial(network, address string, duration time.Duration, err error)
+}
+
+// countingReadCloser counts the bytes read, and calls onClose once when it is closed.
+type countingReadCloser struct {
+	io.ReadCloser
+	count   int64
+	onClose func()
+	once    sync.Once
+}
+
+func (rc *countingReadCloser) Read(p []byte) (n int, err error) {
+	n, err = rc.ReadCloser.Read(p)
+	atomic.AddInt64(&rc.count, int64(n))
+	return
+}
+
+func (rc *countingReadCloser) Close() error {
+	err := rc.ReadCloser.Close()
+	rc.once.Do(func() {
+		if rc.onClose != nil {
+			rc.onClose()
+		}
+	})
+	return err
+}
+
+func (rc *countingReadCloser) bytes() int64 {
+	if rc == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&rc.count)
+}
+
+// attemptMeter measures an attempt for the MetricsRecorder and the Tracer of the client, its methods do nothing on a nil meter.
+type attemptMeter struct {
+	recorder  MetricsRecorder
+	span      Span
+	record    *attemptRecord
+	metrics   AttemptMetrics
+	requestID string
+	start     time.Time
+	sent      *countingReadCloser
+	received  *countingReadCloser
+	once      sync.Once
+}
+
+// newAttemptMeter returns nil if the client has neither MetricsRecorder nor Tracer. Otherwise it starts the span
+// of the attempt, and counts the bytes of the request body.
+func (OSSClient OSSClient) newAttemptMeter(request *OperationRequest) *attemptMeter {
+	if OSSClient.conf.metricsRecorder == nil && OSSClient.conf.tracer == nil {
+		return nil
+	}
+	meter := &attemptMeter{
+		recorder: OSSClient.conf.metricsRecorder,
+		metrics:  AttemptMetrics{Operation: request.Operation, Bucket: request.Bucket, Attempt: request.Attempt},
+		start:    time.Now(),
+	}
+	if tracer := OSSClient.conf.tracer; tracer != nil {
+		ctx := request.Request.Context()
+		meter.record = getAttemptRecord(ctx)
+		meter.record.started(request.Attempt)
+		ctx, meter.span = tracer.Start(ctx, "HTTP "+request.Request.Method,
+			Attribute{Key: ATTRIBUTE_OPERATION, Value: request.Operation},
+			Attribute{Key: ATTRIBUTE_HTTP_METHOD, Value: request.Request.Method},
+			Attribute{Key: ATTRIBUTE_ATTEMPT, Value: request.Attempt})
+		request.Request = request.Request.WithContext(ctx)
+		if propagator, ok := tracer.(TracePropagator); ok {
+			propagator.Inject(ctx, request.Request.Header)
+		}
+	}
+	if request.Request.Body != nil && request.Request.Body != http.NoBody {
+		meter.sent = &countingReadCloser{ReadCloser: request.Request.Body}
+		request.Request.Body = meter.sent
+	}
+	return meter
+}
+
+// responded measures the latency and counts the bytes of the response body.
+func (meter *attemptMeter) responded(resp *http.Response) {
+	if meter == nil {
+		return
+	}
+	meter.metrics.Latency = time.Since(meter.start)
+	if resp != nil {
+		meter.metrics.StatusCode = resp.StatusCode
+		if meter.span != nil {
+			if values, ok := cleanHeaderPrefix(resp.Header)[HEADER_REQUEST_ID]; ok && len(values) > 0 {
+				meter.requestID = values[0]
+			}
+			meter.record.responded(resp.StatusCode, meter.requestID)
+		}
+		meter.received = &countingReadCloser{ReadCloser: resp.Body}
+		resp.Body = meter.received
+	}
+}
+
+// doneOnClose records the attempt when the response body is closed.
+func (meter *attemptMeter) doneOnClose(resp *http.Response) {
+	if meter == nil {
+		return
+	}
+	resp.Body = &countingReadCloser{ReadCloser: resp.Body, onClose: func() {
+		meter.done(nil)
+	}}
+}
+
+// done records the attempt, only the first call of it is recorded.
+func (meter *attemptMeter) done(err error) {
+	if meter == nil {
+		return
+	}
+	meter.once.Do(func() {
+		if meter.metrics.Latency == 0 {
+			meter.metrics.Latency = time.Since(meter.start)
+		}
+		meter.metrics.Err = err
+		if ossError, ok := err.(OSSError); ok {
+			meter.metrics.ErrorCode = ossError.Code
+		}
+		meter.metrics.BytesSent = meter.sent.bytes()
+		meter.metrics.BytesReceived = meter.received.bytes()
+		if meter.recorder != nil {
+			meter.recorder.RecordAttempt(&meter.metrics)
+		}
+		if meter.span != nil {
+			attributes := []Attribute{
+				{Key: ATTRIBUTE_BYTES_SENT, Value: meter.metrics.BytesSent},
+				{Key: ATTRIBUTE_BYTES_RECEIVED, Value: meter.metrics.BytesReceived},
+			}
+			if meter.metrics.StatusCode > 0 {
+				attributes = append(attributes, Attribute{Key: ATTRIBUTE_HTTP_STATUS, Value: meter.metrics.StatusCode})
+			}
+			if meter.requestID != "" {
+				attributes = append(attributes, Attribute{Key: ATTRIBUTE_REQUEST_ID, Value: meter.requestID})
+			}
+			meter.span.SetAttributes(attributes...)
+			endSpan(meter.span, err)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type recordingMetricsRecorder struct {
+	lock     sync.Mutex
+	attempts []AttemptMetrics
+	dials    int
+}
+
+func (recorder *recordingMetricsRecorder) RecordAttempt(metrics *AttemptMetrics) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.attempts = append(recorder.attempts, *metrics)
+}
+
+func (recorder *recordingMetricsRecorder) RecordDial(network, address string, duration time.Duration, err error) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.dials++
+}
+
+func (recorder *recordingMetricsRecorder) recorded() ([]AttemptMetrics, int) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	return append([]AttemptMetrics(nil), recorder.attempts...), recorder.dials
+}
+
+func TestMetricsRecorder(t *testing.T) {
+	fs := newFakeServer(t)
+	recorder := &recordingMetricsRecorder{}
+	client := newTestClient(t, fs, WithMetricsRecorder(recorder))
+	var failed int32
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPut && atomic.AddInt32(&failed, 1) == 1 {
+			writeError(w, http.StatusServiceUnavailable, "ServiceUnavailable")
+			return true
+		}
+		return false
+	})
+
+	putInput := &PutObjectInput{}
+	putInput.Bucket, putInput.Key = "bucket", "key"
+	putInput.Body = strings.NewReader("0123456789")
+	if _, err := client.PutObject(putInput); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	getInput := &GetObjectInput{}
+	getInput.Bucket, getInput.Key = "bucket", "key"
+	output, err := client.GetObject(getInput)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	io.Copy(io.Discard, output.Body)
+	if attempts, _ := recorder.recorded(); len(attempts) != 2 {
+		t.Fatalf("expected the GetObject attempt to be recorded when the body is closed, got %d attempts", len(attempts))
+	}
+	output.Body.Close()
+
+	attempts, dials := recorder.recorded()
+	if len(attempts) != 3 || dials == 0 {
+		t.Fatalf("unexpected metrics %+v, %d dials", attempts, dials)
+	}
+	failedPut, put, get := attempts[0], attempts[1], attempts[2]
+	if failedPut.Operation != "PutObject" || failedPut.Attempt != 0 || failedPut.StatusCode != http.StatusServiceUnavailable ||
+		failedPut.ErrorCode != "ServiceUnavailable" || failedPut.Err == nil {
+		t.Fatalf("unexpected failed attempt %+v", failedPut)
+	}
+	if put.Attempt != 1 || put.Err != nil || put.BytesSent != 10 || put.Bucket != "bucket" {
+		t.Fatalf("unexpected retry %+v", put)
+	}
+	if get.Operation != "GetObject" || get.StatusCode != http.StatusOK || get.BytesReceived != 10 || get.Latency <= 0 {
+		t.Fatalf("unexpected download %+v", get)
+	}
+}
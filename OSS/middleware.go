@@ -0,0 +1,84 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// OperationRequest is an HTTP attempt of an operation, as seen by the middlewares.
+//
+// Operation is the name of the API, such as "PutObject". Bucket and Key are empty if the operation has
+// none of them, and for the requests with signed URLs. Attempt starts from 0 and counts the retries and
+// the redirects of the operation. Request is already signed, so a change of the headers which are signed
+// fails the authentication of the service.
+type OperationRequest struct {
+	Operation string
+	Bucket    string
+	Key       string
+	Attempt   int
+	Request   *http.Request
+}
+
+// Handler sends an HTTP attempt and returns its response. As for http.Client.Do, the response must be
+// nil if the error is not nil.
+type Handler func(request *OperationRequest) (*http.Response, error)
+
+// Middleware wraps a Handler, it can change the request before calling next, change the response after
+// that, or return a response or an error without calling next at all.
+type Middleware func(next Handler) Handler
+
+type operationKey struct{}
+
+// withOperation returns a copy of ctx carrying the name of the operation.
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// getOperation returns the name of the operation carried by ctx.
+func getOperation(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// doHTTPRequest sends the request through the middlewares of the client, the first middleware is the outermost.
+func (OSSClient OSSClient) doHTTPRequest(request *OperationRequest) (*http.Response, error) {
+	handler := Handler(func(request *OperationRequest) (*http.Response, error) {
+		return OSSClient.httpClient.Do(request.Request)
+	})
+	middlewares := OSSClient.conf.middlewares
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	resp, err := handler(request)
+	if err != nil {
+		if resp != nil && resp.Body != nil {
+			errMsg := resp.Body.Close()
+			checkAndLogErr(errMsg, LEVEL_WARN, "Failed to close response body with reason: %v", errMsg)
+		}
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errors.New("Middleware returns neither a response nor an error")
+	}
+	if resp.Body == nil {
+		resp.Body = http.NoBody
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	return resp, nil
+}
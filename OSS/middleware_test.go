@@ -0,0 +1,122 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	fs := newFakeServer(t)
+	var lock sync.Mutex
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(request *OperationRequest) (*http.Response, error) {
+				lock.Lock()
+				calls = append(calls, fmt.Sprintf("%s %s %s/%s #%d", name, request.Operation, request.Bucket, request.Key, request.Attempt))
+				lock.Unlock()
+				request.Request.Header.Set("X-Audit-"+name, "yes")
+				resp, err := next(request)
+				if err == nil {
+					resp.Header.Set("X-Seen-By-"+name, "yes")
+				}
+				return resp, err
+			}
+		}
+	}
+	client := newTestClient(t, fs, WithMiddleware(record("outer")), WithMiddleware(record("inner")))
+
+	input := &PutObjectInput{}
+	input.Bucket, input.Key = "bucket", "key"
+	input.Body = strings.NewReader("data")
+	output, err := client.PutObject(input)
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	expected := []string{"outer PutObject bucket/key #0", "inner PutObject bucket/key #0"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	if audited := fs.countRequests(func(r *http.Request) bool {
+		return r.Header.Get("X-Audit-outer") == "yes" && r.Header.Get("X-Audit-inner") == "yes"
+	}); audited != 1 {
+		t.Fatal("expected the headers of the middlewares to be sent")
+	}
+	if _, ok := output.ResponseHeaders["x-seen-by-outer"]; !ok {
+		t.Fatalf("expected the response to be seen by the middlewares, got %v", output.ResponseHeaders)
+	}
+}
+
+func TestMiddlewareFaultInjection(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.putObject("bucket", "key", []byte("data"))
+	var lock sync.Mutex
+	failures := 2
+	client := newTestClient(t, fs, WithMiddleware(func(next Handler) Handler {
+		return func(request *OperationRequest) (*http.Response, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			if failures > 0 {
+				failures--
+				return nil, io.ErrUnexpectedEOF
+			}
+			return next(request)
+		}
+	}))
+
+	input := &GetObjectInput{}
+	input.Bucket, input.Key = "bucket", "key"
+	output, err := client.GetObject(input)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	defer output.Body.Close()
+	if data, err := io.ReadAll(output.Body); err != nil || string(data) != "data" {
+		t.Fatalf("unexpected body %q, err: %v", data, err)
+	}
+	if requests := fs.countRequests(func(r *http.Request) bool { return true }); requests != 1 {
+		t.Fatalf("expected the injected failures to be retried, got %d requests", requests)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	fs := newFakeServer(t)
+	errDenied := errors.New("denied by policy")
+	client := newTestClient(t, fs, WithMiddleware(func(next Handler) Handler {
+		return func(request *OperationRequest) (*http.Response, error) {
+			if request.Request.Method == http.MethodDelete {
+				return nil, errDenied
+			}
+			// a response without body nor header is completed by the client
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}
+	}))
+
+	if _, err := client.DeleteBucket("bucket"); !errors.Is(err, errDenied) {
+		t.Fatalf("expected the error of the middleware, got: %v", err)
+	}
+	if _, err := client.HeadBucket("bucket"); err != nil {
+		t.Fatalf("HeadBucket failed: %v", err)
+	}
+	if requests := fs.countRequests(func(r *http.Request) bool { return true }); requests != 0 {
+		t.Fatalf("expected no requests to the server, got %d", requests)
+	}
+}
@@ -47,6 +47,41 @@ type PageListBucketsOutput struct {
 	TotalCount string   `xml:"TotalCount"`
 }
 
+// DeleteBucketForceInput is the input parameter of DeleteBucketForce function
+//
+// ConfirmBucket must be the same as Bucket, so that a bucket is never emptied by mistake. The multipart
+// uploads are aborted and the objects, with all their versions and delete markers, are deleted by TaskNum
+// routines before the bucket is deleted. ProgressListener is notified after each upload and each batch.
+type DeleteBucketForceInput struct {
+	Bucket           string
+	ConfirmBucket    string
+	TaskNum          int
+	ProgressListener DeleteBucketForceListener
+}
+
+// DeleteBucketForceOutput is the result of DeleteBucketForce function
+type DeleteBucketForceOutput struct {
+	AbortedUploads []CleanedMultipartUpload
+	DeletedCount   int
+	Errors         []Error
+}
+
+// DeleteBucketForceEvent defines the progress of DeleteBucketForce
+type DeleteBucketForceEvent struct {
+	Stage          DeleteBucketForceStageType
+	AbortedUploads int
+	DeletedObjects int
+	FailedCount    int
+}
+
+// DeleteBucketForceListener defines the listener of the progress of DeleteBucketForce.
+//
+// ProgressChanged is called synchronously from the goroutines that delete the bucket,
+// so the implementation should return quickly.
+type DeleteBucketForceListener interface {
+	ProgressChanged(event *DeleteBucketForceEvent)
+}
+
 // CreateBucketInput is the input parameter of CreateBucket function
 type CreateBucketInput struct {
 	BucketLocation
@@ -79,6 +79,30 @@ type ListVersionsOutput struct {
 	EncodingType        string         `xml:"EncodingType,omitempty"`
 }
 
+// ParallelListObjectsInput is the input parameter of ParallelListObjects function
+//
+// The keyspace of Prefix is split into partitions by the CommonPrefixes of Delimiter, which defaults to "/",
+// recursively up to MaxDepth levels, or until there are 4 times TaskNum partitions. The partitions are
+// listed by TaskNum routines, with at most MaxRequestsInFlight ListObjects requests at the same time,
+// which defaults to TaskNum. If Ordered is set, the objects are streamed in key order. BufferSize is the
+// buffer size of the channel, and of each partition if Ordered is set.
+type ParallelListObjectsInput struct {
+	Bucket              string
+	Prefix              string
+	Delimiter           string
+	MaxDepth            int
+	TaskNum             int
+	MaxRequestsInFlight int
+	Ordered             bool
+	BufferSize          int
+}
+
+// ListedObject is an object streamed by ParallelListObjects function, or the error which stops the listing
+type ListedObject struct {
+	Content Content
+	Err     error
+}
+
 // DeleteObjectInput is the input parameter of DeleteObject function
 type DeleteObjectInput struct {
 	Bucket    string
@@ -111,6 +135,59 @@ type DeleteObjectsOutput struct {
 	EncodingType string    `xml:"EncodingType,omitempty"`
 }
 
+// DeletePrefixInput is the input parameter of DeletePrefix function
+//
+// The objects of Prefix in Bucket are deleted by DeleteObjects requests of BatchSize keys, at most 1000,
+// sent by TaskNum routines. If IncludeVersions is set, every version of the objects is deleted permanently,
+// together with the delete markers if IncludeDeleteMarkers is also set; otherwise only the current versions
+// are deleted, which adds delete markers in a versioned bucket. An empty Prefix, which deletes the whole
+// bucket, is refused unless AllowEmptyPrefix is set. If Quiet is set, only the errors are returned.
+type DeletePrefixInput struct {
+	Bucket               string
+	Prefix               string
+	IncludeVersions      bool
+	IncludeDeleteMarkers bool
+	AllowEmptyPrefix     bool
+	Quiet                bool
+	BatchSize            int
+	TaskNum              int
+}
+
+// DeletePrefixOutput is the result of DeletePrefix function, a batch which fails to be sent reports
+// an error for each of its keys. DeletedCount counts the deleted objects, also in quiet mode.
+type DeletePrefixOutput struct {
+	Deleteds     []Deleted
+	Errors       []Error
+	DeletedCount int
+}
+
+// BatchJobInput is the input parameter of RunBatchJob function
+//
+// Operation is applied to each key of Bucket, the keys are taken from Keys, or from ManifestFile, or else
+// from the listing of Prefix. ManifestFile is a CSV file whose first column is the key, or a JSONL file,
+// as its extension is ".jsonl", whose lines have the field "key". A results manifest written to ResultFile
+// is a valid ManifestFile, and only its failed keys are taken if RetryFailed is set. The keys are processed
+// by TaskNum routines, and a key which fails is retried up to MaxRetries times as the RetryPolicy of the client decides.
+// Each request of the operation is already retried by the client, so a retry of a key multiplies the attempts of
+// its requests, and MaxRetries defaults to 0.
+type BatchJobInput struct {
+	Bucket       string
+	Keys         []string
+	ManifestFile string
+	RetryFailed  bool
+	Prefix       string
+	Operation    BatchOperation
+	TaskNum      int
+	MaxRetries   int
+	ResultFile   string
+}
+
+// BatchJobOutput is the result of RunBatchJob function
+type BatchJobOutput struct {
+	SucceededCount int
+	FailedKeys     map[string]error
+}
+
 // SetObjectAclInput is the input parameter of SetObjectAcl function
 type SetObjectAclInput struct {
 	Bucket    string  `xml:"-"`
@@ -188,6 +265,9 @@ type GetAttributeOutput struct {
 }
 
 // GetObjectInput is the input parameter of GetObject function
+//
+// The range is sent only if RangeEnd is greater than RangeStart, unless ExactRange is set, with which
+// a RangeEnd equal to RangeStart requests the single byte at RangeStart.
 type GetObjectInput struct {
 	GetObjectMetadataInput
 	IfMatch                    string
@@ -196,6 +276,7 @@ type GetObjectInput struct {
 	IfModifiedSince            time.Time
 	RangeStart                 int64
 	RangeEnd                   int64
+	ExactRange                 bool
 	ImageProcess               string
 	ResponseCacheControl       string
 	ResponseContentDisposition string
@@ -262,10 +343,11 @@ type ObjectOperationInput struct {
 // PutObjectBasicInput defines the basic object operation properties
 type PutObjectBasicInput struct {
 	ObjectOperationInput
-	ContentType     string
-	ContentMD5      string
-	ContentLength   int64
-	ContentEncoding string
+	ContentType      string
+	ContentMD5       string
+	ContentLength    int64
+	ContentEncoding  string
+	ProgressListener ProgressListener
 }
 
 // PutObjectInput is the input parameter of PutObject function
@@ -332,29 +414,258 @@ type CopyObjectOutput struct {
 }
 
 // UploadFileInput is the input parameter of UploadFile function
+//
+// The checkpoint is kept in CheckpointStore with CheckpointFile as its key, CheckpointStore defaults to
+// the local file CheckpointFile.
 type UploadFileInput struct {
+	ObjectOperationInput
+	ContentType          string
+	UploadFile           string
+	PartSize             int64
+	TaskNum              int
+	EnableCheckpoint     bool
+	CheckpointFile       string
+	CheckpointStore      CheckpointStore
+	EncodingType         string
+	EnableIntegrityCheck bool
+	ProgressListener     ProgressListener
+}
+
+// UploadStreamInput is the input parameter of UploadStream function
+type UploadStreamInput struct {
 	ObjectOperationInput
 	ContentType      string
-	UploadFile       string
+	Body             io.Reader
 	PartSize         int64
 	TaskNum          int
-	EnableCheckpoint bool
-	CheckpointFile   string
 	EncodingType     string
+	ProgressListener ProgressListener
+}
+
+// CopyFileInput is the input parameter of CopyFile function
+//
+// With the default MetadataDirective CopyMetadata, the metadata and content type of the source
+// object are kept; with ReplaceMetadata, ContentType and Metadata of the input are used instead.
+// The ACL of the destination object is set from ACL and the Grant* fields, or copied from the
+// source object when CopySourceACL is true. If the destination object has another owner, it keeps its owner,
+// the grants to the owner of the source object are given to it and the grants to other accounts are dropped;
+// if the ACL fails to be copied, the output is returned with an AclCopyError. The checkpoint is kept in
+// CheckpointStore with CheckpointFile as its key, CheckpointStore defaults to the local file CheckpointFile.
+type CopyFileInput struct {
+	ObjectOperationInput
+	CopySourceBucket    string
+	CopySourceKey       string
+	CopySourceVersionId string
+	SourceSseHeader     ISseHeader
+	ContentType         string
+	MetadataDirective   MetadataDirectiveType
+	CopySourceACL       bool
+	PartSize            int64
+	TaskNum             int
+	EnableCheckpoint    bool
+	CheckpointFile      string
+	CheckpointStore     CheckpointStore
+	EncodingType        string
+	ProgressListener    ProgressListener
 }
 
 // DownloadFileInput is the input parameter of DownloadFile function
+//
+// The checkpoint is kept in CheckpointStore with CheckpointFile as its key, CheckpointStore defaults to
+// the local file CheckpointFile. If EnableIntegrityCheck is set, the size of each part is checked, and so
+// is its MD5 if the part is the whole object and the service returns its Content-MD5; the CRC64 of the file
+// is compared to the hash-crc64ecma of the object if the service provides it, and the MD5 of the file to
+// the ETag of the object if the ETag is the MD5 of the object.
 type DownloadFileInput struct {
+	GetObjectMetadataInput
+	IfMatch              string
+	IfNoneMatch          string
+	IfModifiedSince      time.Time
+	IfUnmodifiedSince    time.Time
+	DownloadFile         string
+	PartSize             int64
+	TaskNum              int
+	EnableCheckpoint     bool
+	CheckpointFile       string
+	CheckpointStore      CheckpointStore
+	EnableIntegrityCheck bool
+	ProgressListener     ProgressListener
+}
+
+// DownloadToWriterInput is the input parameter of DownloadToWriter function
+//
+// If Writer implements io.WriterAt, the parts are written to it concurrently at their offsets.
+// Otherwise the parts are written sequentially, and at most WindowSize parts are buffered in
+// memory while waiting for the preceding parts. WindowSize defaults to twice TaskNum and must not
+// be less than TaskNum.
+type DownloadToWriterInput struct {
 	GetObjectMetadataInput
 	IfMatch           string
 	IfNoneMatch       string
 	IfModifiedSince   time.Time
 	IfUnmodifiedSince time.Time
-	DownloadFile      string
+	Writer            io.Writer
 	PartSize          int64
 	TaskNum           int
-	EnableCheckpoint  bool
-	CheckpointFile    string
+	WindowSize        int
+	ProgressListener  ProgressListener
+}
+
+// UploadDirectoryInput is the input parameter of UploadDirectory function
+//
+// The files under Directory are uploaded to the keys of Prefix followed by their slash-separated paths
+// relative to Directory, a slash is appended to a non-empty Prefix without one. Include and Exclude are glob patterns of the relative paths, see MatchPathPattern.
+// Files larger than MultipartThreshold, which defaults to PartSize, are uploaded with UploadFile,
+// the others with PutFile. TaskNum requests are sent concurrently for the files and their parts in total,
+// at most PartTaskNum of them for the parts of a file. The checkpoint files are written to CheckpointDir,
+// which defaults to a directory under os.TempDir, so that they are not uploaded with the directory.
+type UploadDirectoryInput struct {
+	Bucket             string
+	Prefix             string
+	Directory          string
+	Include            []string
+	Exclude            []string
+	FollowSymlinks     bool
+	ACL                AclType
+	StorageClass       StorageClassType
+	SseHeader          ISseHeader
+	PartSize           int64
+	MultipartThreshold int64
+	TaskNum            int
+	PartTaskNum        int
+	EnableCheckpoint   bool
+	CheckpointDir      string
+}
+
+// DownloadPrefixInput is the input parameter of DownloadPrefix function
+//
+// The objects of Prefix are downloaded to the paths under Directory of their keys without Prefix,
+// a slash is appended to a non-empty Prefix without one. Include and Exclude are glob patterns of the keys without Prefix, see MatchPathPattern.
+// Objects larger than MultipartThreshold, which defaults to PartSize, are downloaded with DownloadFile,
+// the others with GetObject. TaskNum requests are sent concurrently for the objects and their parts in total,
+// at most PartTaskNum of them for the parts of an object.
+type DownloadPrefixInput struct {
+	Bucket             string
+	Prefix             string
+	Directory          string
+	Include            []string
+	Exclude            []string
+	PartSize           int64
+	MultipartThreshold int64
+	TaskNum            int
+	PartTaskNum        int
+	EnableCheckpoint   bool
+}
+
+// FileTransferResult is the result of a file transferred by UploadDirectory or DownloadPrefix
+type FileTransferResult struct {
+	Key       string
+	LocalPath string
+	Size      int64
+	Err       error
+}
+
+// DirectoryTransferOutput is the result of UploadDirectory and DownloadPrefix functions
+type DirectoryTransferOutput struct {
+	Results          []FileTransferResult
+	SucceededCount   int
+	FailedCount      int
+	TransferredBytes int64
+}
+
+// SyncDirectoryInput is the input parameter of SyncDirectory function
+//
+// The files under Directory correspond to the objects of Prefix as in UploadDirectory and DownloadPrefix.
+// A file is transferred if it is missing on the destination or changed according to CompareMode, which
+// defaults to SyncCompareSizeAndModTime. If Delete is set, the files or objects missing on the source
+// are deleted from the destination, but the ones excluded by Include and Exclude are never deleted.
+// If DryRun is set, the planned actions are returned without being executed.
+type SyncDirectoryInput struct {
+	Bucket             string
+	Prefix             string
+	Directory          string
+	Direction          SyncDirectionType
+	CompareMode        SyncCompareType
+	Include            []string
+	Exclude            []string
+	FollowSymlinks     bool
+	Delete             bool
+	DryRun             bool
+	ACL                AclType
+	StorageClass       StorageClassType
+	SseHeader          ISseHeader
+	PartSize           int64
+	MultipartThreshold int64
+	TaskNum            int
+	PartTaskNum        int
+}
+
+// SyncAction is an action planned by SyncDirectory, Err is the error of executing it
+type SyncAction struct {
+	Type      SyncActionType
+	Key       string
+	LocalPath string
+	Size      int64
+	Reason    string
+	Err       error
+
+	lastModified time.Time
+}
+
+// SyncDirectoryOutput is the result of SyncDirectory function
+type SyncDirectoryOutput struct {
+	Actions          []SyncAction
+	UnchangedCount   int
+	SucceededCount   int
+	FailedCount      int
+	TransferredBytes int64
+}
+
+// ReplicatePrefixInput is the input parameter of ReplicatePrefix function
+//
+// The objects of SourcePrefix in SourceBucket of SourceClient are replicated to the keys of Prefix followed
+// by their keys without SourcePrefix in Bucket, a slash is appended to a non-empty SourcePrefix or Prefix
+// without one. SourceClient defaults to the client replicating the objects.
+// The objects whose destination already has the same size and was modified no earlier than them are skipped,
+// the ETags are not compared since they differ between the objects uploaded in parts. If EnableCheckpoint is set,
+// the replicated keys are recorded in CheckpointFile, so that an interrupted replication can be resumed.
+// CheckpointStore defaults to the local file CheckpointFile.
+type ReplicatePrefixInput struct {
+	SourceClient     *OSSClient
+	SourceBucket     string
+	SourcePrefix     string
+	Bucket           string
+	Prefix           string
+	Include          []string
+	Exclude          []string
+	PartSize         int64
+	TaskNum          int
+	PartTaskNum      int
+	EnableCheckpoint bool
+	CheckpointFile   string
+	CheckpointStore  CheckpointStore
+}
+
+// ReplicatePrefixOutput is the result of ReplicatePrefix function, the keys are the keys of the source objects.
+// The objects which are replicated but whose ACLs fail to be replicated are in both CopiedKeys and AclFailedKeys,
+// with an AclCopyError.
+type ReplicatePrefixOutput struct {
+	CopiedKeys    []string
+	SkippedKeys   []string
+	FailedKeys    map[string]error
+	AclFailedKeys map[string]error
+	CopiedBytes   int64
+}
+
+// OpenObjectInput is the input parameter of OpenObject function
+//
+// BlockSize, CacheBlocks and ReadAhead default to DEFAULT_BLOCK_SIZE, DEFAULT_CACHE_BLOCKS and
+// DEFAULT_READ_AHEAD, a negative ReadAhead disables read-ahead.
+type OpenObjectInput struct {
+	GetObjectMetadataInput
+	BlockSize   int64
+	CacheBlocks int
+	ReadAhead   int
 }
 
 type AppendObjectInput struct {
@@ -73,16 +73,17 @@ type InitiateMultipartUploadOutput struct {
 
 // UploadPartInput is the input parameter of UploadPart function
 type UploadPartInput struct {
-	Bucket     string
-	Key        string
-	PartNumber int
-	UploadId   string
-	ContentMD5 string
-	SseHeader  ISseHeader
-	Body       io.Reader
-	SourceFile string
-	Offset     int64
-	PartSize   int64
+	Bucket           string
+	Key              string
+	PartNumber       int
+	UploadId         string
+	ContentMD5       string
+	SseHeader        ISseHeader
+	Body             io.Reader
+	SourceFile       string
+	Offset           int64
+	PartSize         int64
+	ProgressListener ProgressListener
 }
 
 // UploadPartOutput is the result of UploadPart function
@@ -168,3 +169,37 @@ type CopyPartOutput struct {
 	LastModified time.Time  `xml:"LastModified"`
 	SseHeader    ISseHeader `xml:"-"`
 }
+
+// CleanupMultipartUploadsInput is the input parameter of CleanupMultipartUploads function
+//
+// The multipart uploads of Prefix in Bucket which were initiated more than OlderThan ago are aborted
+// by TaskNum routines. OlderThan defaults to 24 hours, so that the uploads in progress are not aborted.
+// If DryRun is set, the uploads are only listed and none of them is aborted.
+type CleanupMultipartUploadsInput struct {
+	Bucket    string
+	Prefix    string
+	OlderThan time.Duration
+	TaskNum   int
+	DryRun    bool
+}
+
+// CleanedMultipartUpload is the result of a multipart upload of CleanupMultipartUploads function
+type CleanedMultipartUpload struct {
+	Key       string
+	UploadId  string
+	Initiated time.Time
+	PartCount int
+	Size      int64
+	Err       error
+}
+
+// CleanupMultipartUploadsOutput is the result of CleanupMultipartUploads function
+//
+// ReclaimedParts and ReclaimedBytes count the parts of the aborted uploads, or of all the listed uploads in DryRun.
+type CleanupMultipartUploadsOutput struct {
+	Uploads        []CleanedMultipartUpload
+	AbortedCount   int
+	FailedCount    int
+	ReclaimedParts int
+	ReclaimedBytes int64
+}
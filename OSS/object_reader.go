@@ -0,0 +1,258 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"container/list"
+	"errors"
+	"io"
+	"sync"
+)
+
+var errObjectReaderClosed = errors.New("ObjectReader is closed")
+
+type objectBlock struct {
+	index   int64
+	data    []byte
+	err     error
+	done    chan struct{}
+	element *list.Element
+}
+
+// ObjectReader reads an object with range requests. It implements io.ReadSeekCloser and io.ReaderAt.
+//
+// The object is read in blocks of BlockSize, the most recently used CacheBlocks blocks are cached,
+// and sequential reads fetch the following ReadAhead blocks in the background.
+// All the range requests are made with If-Match of the ETag the object had when it was opened,
+// so a reader never mixes data of different versions of the object.
+// ReadAt can be called concurrently, while Read and Seek share the offset of the reader.
+type ObjectReader struct {
+	client      OSSClient
+	input       GetObjectMetadataInput
+	extensions  []extensionOptions
+	span        Span
+	size        int64
+	etag        string
+	blockSize   int64
+	cacheBlocks int
+	readAhead   int
+
+	lock   sync.Mutex
+	offset int64
+	blocks map[int64]*objectBlock
+	lru    *list.List
+	closed bool
+}
+
+// OpenObject opens an object for random access.
+func (OSSClient OSSClient) OpenObject(input *OpenObjectInput, extensions ...extensionOptions) (reader *ObjectReader, err error) {
+	if input == nil {
+		return nil, errors.New("OpenObjectInput is nil")
+	}
+
+	// the span ends when the reader is closed, the range requests are its children
+	extensions, span := OSSClient.startExtensionsSpan("OpenObject", input.Bucket, input.Key, extensions)
+	output, err := OSSClient.GetObjectMetadata(&input.GetObjectMetadataInput, extensions...)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+
+	reader = &ObjectReader{
+		client:      OSSClient,
+		input:       input.GetObjectMetadataInput,
+		extensions:  extensions,
+		span:        span,
+		size:        output.ContentLength,
+		etag:        output.ETag,
+		blockSize:   input.BlockSize,
+		cacheBlocks: input.CacheBlocks,
+		readAhead:   input.ReadAhead,
+		blocks:      make(map[int64]*objectBlock),
+		lru:         list.New(),
+	}
+	if reader.blockSize <= 0 {
+		reader.blockSize = DEFAULT_BLOCK_SIZE
+	}
+	if reader.readAhead < 0 {
+		reader.readAhead = 0
+	} else if input.ReadAhead == 0 {
+		reader.readAhead = DEFAULT_READ_AHEAD
+	}
+	if reader.cacheBlocks <= 0 {
+		reader.cacheBlocks = DEFAULT_CACHE_BLOCKS
+	}
+	if reader.cacheBlocks <= reader.readAhead {
+		reader.cacheBlocks = reader.readAhead + 1
+	}
+	return reader, nil
+}
+
+// Size returns the size of the object.
+func (reader *ObjectReader) Size() int64 {
+	return reader.size
+}
+
+// ETag returns the ETag of the object when it was opened.
+func (reader *ObjectReader) ETag() string {
+	return reader.etag
+}
+
+// ReadAt implements io.ReaderAt.
+func (reader *ObjectReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	for n < len(p) {
+		if off >= reader.size {
+			return n, io.EOF
+		}
+		index := off / reader.blockSize
+		block, err := reader.getBlock(index)
+		if err != nil {
+			return n, err
+		}
+		<-block.done
+		if block.err != nil {
+			return n, block.err
+		}
+		cnt := copy(p[n:], block.data[off-index*reader.blockSize:])
+		n += cnt
+		off += int64(cnt)
+	}
+	return n, nil
+}
+
+// Read implements io.Reader.
+func (reader *ObjectReader) Read(p []byte) (n int, err error) {
+	reader.lock.Lock()
+	offset := reader.offset
+	reader.lock.Unlock()
+
+	n, err = reader.ReadAt(p, offset)
+	if n > 0 || err == nil {
+		reader.prefetch(offset + int64(n))
+	}
+
+	reader.lock.Lock()
+	reader.offset = offset + int64(n)
+	reader.lock.Unlock()
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return
+}
+
+// Seek implements io.Seeker.
+func (reader *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	reader.lock.Lock()
+	defer reader.lock.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += reader.offset
+	case io.SeekEnd:
+		offset += reader.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	reader.offset = offset
+	return offset, nil
+}
+
+// Close releases the cached blocks. The reader can not be used after it is closed.
+func (reader *ObjectReader) Close() error {
+	reader.lock.Lock()
+	defer reader.lock.Unlock()
+	if !reader.closed {
+		endSpan(reader.span, nil)
+	}
+	reader.closed = true
+	reader.blocks = nil
+	reader.lru.Init()
+	return nil
+}
+
+// prefetch fetches the blocks following offset in the background.
+func (reader *ObjectReader) prefetch(offset int64) {
+	index := offset / reader.blockSize
+	for i := int64(1); i <= int64(reader.readAhead); i++ {
+		if (index+i)*reader.blockSize >= reader.size {
+			break
+		}
+		if _, err := reader.getBlock(index + i); err != nil {
+			break
+		}
+	}
+}
+
+// getBlock returns the cached block of index, or starts to fetch it if it is not cached.
+func (reader *ObjectReader) getBlock(index int64) (*objectBlock, error) {
+	reader.lock.Lock()
+	defer reader.lock.Unlock()
+	if reader.closed {
+		return nil, errObjectReaderClosed
+	}
+	if block, ok := reader.blocks[index]; ok {
+		reader.lru.MoveToFront(block.element)
+		return block, nil
+	}
+
+	block := &objectBlock{index: index, done: make(chan struct{})}
+	block.element = reader.lru.PushFront(block)
+	reader.blocks[index] = block
+	for reader.lru.Len() > reader.cacheBlocks {
+		evicted := reader.lru.Remove(reader.lru.Back()).(*objectBlock)
+		delete(reader.blocks, evicted.index)
+	}
+	go reader.fetch(block)
+	return block, nil
+}
+
+func (reader *ObjectReader) fetch(block *objectBlock) {
+	defer close(block.done)
+
+	input := &GetObjectInput{}
+	input.GetObjectMetadataInput = reader.input
+	input.IfMatch = reader.etag
+	input.RangeStart = block.index * reader.blockSize
+	input.RangeEnd = input.RangeStart + reader.blockSize - 1
+	if input.RangeEnd >= reader.size {
+		input.RangeEnd = reader.size - 1
+	}
+	input.ExactRange = true
+
+	output, err := reader.client.GetObject(input, reader.extensions...)
+	if err == nil {
+		data := make([]byte, input.RangeEnd-input.RangeStart+1)
+		_, err = io.ReadFull(output.Body, data)
+		if errMsg := output.Body.Close(); errMsg != nil {
+			doLog(LEVEL_WARN, "Failed to close response body.")
+		}
+		block.data = data
+	}
+	if err != nil {
+		doLog(LEVEL_WARN, "Failed to read block [%d] of object [%s] with error [%v].", block.index, reader.input.Key, err)
+		block.err = err
+		// do not cache a failed block, so that the next read fetches it again
+		reader.lock.Lock()
+		if cached, ok := reader.blocks[block.index]; ok && cached == block {
+			reader.lru.Remove(block.element)
+			delete(reader.blocks, block.index)
+		}
+		reader.lock.Unlock()
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestGetObjectSingleByteRange(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := newTestObject(fs, 100)
+
+	for _, exactRange := range []bool{false, true} {
+		input := &GetObjectInput{RangeStart: 10, RangeEnd: 10, ExactRange: exactRange}
+		input.Bucket, input.Key = "bucket", "key"
+		output, err := client.GetObject(input)
+		if err != nil {
+			t.Fatalf("GetObject failed: %v", err)
+		}
+		read, err := io.ReadAll(output.Body)
+		output.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := data
+		if exactRange {
+			expected = data[10:11]
+		}
+		if !bytes.Equal(read, expected) {
+			t.Fatalf("ExactRange %v: unexpected body of %d bytes", exactRange, len(read))
+		}
+	}
+}
+
+func TestObjectReader(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	// the last block is a single byte
+	data := newTestObject(fs, 4*1000+1)
+
+	input := &OpenObjectInput{BlockSize: 1000, CacheBlocks: 2}
+	input.Bucket, input.Key = "bucket", "key"
+	reader, err := client.OpenObject(input)
+	if err != nil {
+		t.Fatalf("OpenObject failed: %v", err)
+	}
+	defer reader.Close()
+	if reader.Size() != int64(len(data)) {
+		t.Fatalf("unexpected size %d", reader.Size())
+	}
+
+	read, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("unexpected sequential read, err: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, offset := range []int64{3999, 4000, 1500, 0, 2222} {
+		wg.Add(1)
+		go func(offset int64) {
+			defer wg.Done()
+			p := make([]byte, 700)
+			n, err := reader.ReadAt(p, offset)
+			end := offset + int64(n)
+			if !bytes.Equal(p[:n], data[offset:end]) || end < int64(len(data)) && n != len(p) || err != nil && err != io.EOF {
+				t.Errorf("unexpected ReadAt at %d: %d bytes, err: %v", offset, n, err)
+			}
+		}(offset)
+	}
+	wg.Wait()
+
+	if _, err := reader.Seek(-1, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	read, err = io.ReadAll(reader)
+	if err != nil || !bytes.Equal(read, data[len(data)-1:]) {
+		t.Fatalf("unexpected read after Seek, err: %v", err)
+	}
+}
+
+func TestObjectReaderDetectsChangedObject(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 3000)
+
+	input := &OpenObjectInput{BlockSize: 1000, ReadAhead: -1}
+	input.Bucket, input.Key = "bucket", "key"
+	reader, err := client.OpenObject(input)
+	if err != nil {
+		t.Fatalf("OpenObject failed: %v", err)
+	}
+	defer reader.Close()
+	fs.putObject("bucket", "key", bytes.Repeat([]byte("x"), 3000))
+
+	if _, err := reader.ReadAt(make([]byte, 10), 0); err == nil {
+		t.Fatal("expected an error after the object changed")
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return r.Header.Get("If-Match") != "" }); count == 0 {
+		t.Fatal("expected the reads to be pinned to the ETag")
+	}
+}
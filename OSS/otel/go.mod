@@ -0,0 +1,17 @@
+module github.com/dangcingzzw/inspur-go-sdk/OSS/otel
+
+go 1.19
+
+require (
+	github.com/dangcingzzw/inspur-go-sdk v0.0.0-20261018065538-c9b7407643e0
+	go.opentelemetry.io/otel v1.17.0
+	go.opentelemetry.io/otel/sdk v1.17.0
+	go.opentelemetry.io/otel/trace v1.17.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.17.0 // indirect
+	golang.org/x/sys v0.11.0 // indirect
+)
@@ -0,0 +1,100 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package otel provides an OSS.Tracer which starts the spans of the client with OpenTelemetry, and
+// injects the trace context into the headers of each HTTP attempt. It is a module of its own, so that
+// the SDK does not depend on OpenTelemetry.
+//
+//	tracer := otel.NewTracer(otelapi.Tracer("oss"), nil)
+//	client, err := OSS.New(ak, sk, endpoint, OSS.WithTracer(tracer))
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dangcingzzw/inspur-go-sdk/OSS"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer is an OSS.Tracer and an OSS.TracePropagator backed by OpenTelemetry.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer returns a Tracer which starts the spans with tracer and injects the trace context with propagator.
+// If propagator is nil, the global TextMapPropagator of OpenTelemetry is used when the headers are injected.
+func NewTracer(tracer trace.Tracer, propagator propagation.TextMapPropagator) *Tracer {
+	return &Tracer{tracer: tracer, propagator: propagator}
+}
+
+// Start starts a client span as a child of the span of ctx.
+func (t *Tracer) Start(ctx context.Context, name string, attributes ...OSS.Attribute) (context.Context, OSS.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(convert(attributes)...))
+	return ctx, Span{span: span}
+}
+
+// Inject injects the trace context of ctx into header.
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	propagator := t.propagator
+	if propagator == nil {
+		propagator = otelapi.GetTextMapPropagator()
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Span is an OSS.Span backed by a span of OpenTelemetry.
+type Span struct {
+	span trace.Span
+}
+
+// SetAttributes sets the attributes on the span.
+func (s Span) SetAttributes(attributes ...OSS.Attribute) {
+	s.span.SetAttributes(convert(attributes)...)
+}
+
+// RecordError records err on the span and sets its status to Error.
+func (s Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends the span.
+func (s Span) End() {
+	s.span.End()
+}
+
+// convert converts the attributes of the client, the values of other types than those of OSS.Attribute are formatted.
+func convert(attributes []OSS.Attribute) []attribute.KeyValue {
+	keyValues := make([]attribute.KeyValue, 0, len(attributes))
+	for _, _attribute := range attributes {
+		switch value := _attribute.Value.(type) {
+		case string:
+			keyValues = append(keyValues, attribute.String(_attribute.Key, value))
+		case int:
+			keyValues = append(keyValues, attribute.Int(_attribute.Key, value))
+		case int64:
+			keyValues = append(keyValues, attribute.Int64(_attribute.Key, value))
+		case bool:
+			keyValues = append(keyValues, attribute.Bool(_attribute.Key, value))
+		default:
+			keyValues = append(keyValues, attribute.String(_attribute.Key, fmt.Sprint(value)))
+		}
+	}
+	return keyValues
+}
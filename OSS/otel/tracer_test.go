@@ -0,0 +1,97 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dangcingzzw/inspur-go-sdk/OSS"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	var lock sync.Mutex
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		lock.Unlock()
+		w.Header().Set("x-amz-request-id", "test")
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewTracer(provider.Tracer("oss"), propagation.TraceContext{})
+	client, err := OSS.New("ak", "sk", server.URL, OSS.WithTracer(tracer), OSS.WithPathStyle(true))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	putInput := &OSS.PutObjectInput{}
+	putInput.Bucket, putInput.Key = "bucket", "key"
+	putInput.Body = strings.NewReader("data")
+	if _, err := client.PutObject(putInput, OSS.WithContext(ctx)); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	headInput := &OSS.GetObjectMetadataInput{}
+	headInput.Bucket, headInput.Key = "bucket", "missing"
+	if _, err := client.GetObjectMetadata(headInput, OSS.WithContext(ctx)); err == nil {
+		t.Fatal("expected GetObjectMetadata to fail")
+	}
+	parent.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	put, attempt := spans["PutObject"], spans["HTTP PUT"]
+	if put == nil || attempt == nil {
+		t.Fatalf("expected the spans of PutObject, got %v", spans)
+	}
+	if put.Parent().SpanID() != parent.SpanContext().SpanID() || attempt.Parent().SpanID() != put.SpanContext().SpanID() {
+		t.Fatal("expected the attempt to be a child of the call, and the call a child of the parent")
+	}
+	attributes := attribute.NewSet(put.Attributes()...)
+	if value, _ := attributes.Value(OSS.ATTRIBUTE_HTTP_STATUS); value.AsInt64() != http.StatusOK {
+		t.Fatalf("unexpected attributes %v", put.Attributes())
+	}
+	if value, _ := attributes.Value(OSS.ATTRIBUTE_REQUEST_ID); value.AsString() != "test" {
+		t.Fatalf("unexpected attributes %v", put.Attributes())
+	}
+	if traceparents[0] == "" || !strings.Contains(traceparents[0], attempt.SpanContext().SpanID().String()) {
+		t.Fatalf("expected the trace context of the attempt to be injected, got %q", traceparents[0])
+	}
+
+	head := spans["GetObjectMetadata"]
+	if head == nil || head.Status().Code != codes.Error {
+		t.Fatalf("expected the error status on the span of GetObjectMetadata, got %v", head)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrNoMorePages will be returned by the Next method of a paginator if HasNext is false
+var ErrNoMorePages = errors.New("No more pages")
+
+// ListObjectsPaginator walks the pages of ListObjects.
+//
+// The paginator keeps its own copy of the input, and the markers of the input are advanced after
+// each page. The keys and the markers are decoded if EncodingType is "url", so the pages can be used
+// as if no encoding is set. Next returns the error of the context set by WithContext once it is done.
+// A paginator must not be used by multiple goroutines concurrently.
+type ListObjectsPaginator struct {
+	client     *OSSClient
+	input      ListObjectsInput
+	extensions []extensionOptions
+	done       bool
+}
+
+// NewListObjectsPaginator creates a ListObjectsPaginator instance
+func NewListObjectsPaginator(client *OSSClient, input *ListObjectsInput, extensions ...extensionOptions) *ListObjectsPaginator {
+	paginator := &ListObjectsPaginator{client: client, extensions: extensions}
+	if input != nil {
+		paginator.input = *input
+	}
+	return paginator
+}
+
+// HasNext reports whether there are more pages.
+func (paginator *ListObjectsPaginator) HasNext() bool {
+	return !paginator.done
+}
+
+// Next lists the next page. If it fails, the same page is listed by the next call.
+func (paginator *ListObjectsPaginator) Next() (*ListObjectsOutput, error) {
+	if paginator.done {
+		return nil, ErrNoMorePages
+	}
+	if err := paginator.client.getRequestContext(paginator.extensions).Err(); err != nil {
+		return nil, err
+	}
+	extensions, span := paginator.client.startExtensionsSpan("ListObjectsPaginator", paginator.input.Bucket, "", paginator.extensions)
+	output, err := paginator.client.ListObjects(&paginator.input, extensions...)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	marker := output.NextMarker
+	if marker == "" {
+		// NextMarker is returned only if Delimiter is set, otherwise the last key is the marker
+		if count := len(output.Contents); count > 0 {
+			marker = output.Contents[count-1].Key
+		}
+		if count := len(output.CommonPrefixes); count > 0 && output.CommonPrefixes[count-1] > marker {
+			marker = output.CommonPrefixes[count-1]
+		}
+	}
+	if !output.IsTruncated || marker == "" || marker == paginator.input.Marker {
+		paginator.done = true
+	}
+	paginator.input.Marker = marker
+	return output, nil
+}
+
+// ListVersionsPaginator walks the pages of ListVersions, see ListObjectsPaginator.
+type ListVersionsPaginator struct {
+	client     *OSSClient
+	input      ListVersionsInput
+	extensions []extensionOptions
+	done       bool
+}
+
+// NewListVersionsPaginator creates a ListVersionsPaginator instance
+func NewListVersionsPaginator(client *OSSClient, input *ListVersionsInput, extensions ...extensionOptions) *ListVersionsPaginator {
+	paginator := &ListVersionsPaginator{client: client, extensions: extensions}
+	if input != nil {
+		paginator.input = *input
+	}
+	return paginator
+}
+
+// HasNext reports whether there are more pages.
+func (paginator *ListVersionsPaginator) HasNext() bool {
+	return !paginator.done
+}
+
+// Next lists the next page. If it fails, the same page is listed by the next call.
+func (paginator *ListVersionsPaginator) Next() (*ListVersionsOutput, error) {
+	if paginator.done {
+		return nil, ErrNoMorePages
+	}
+	if err := paginator.client.getRequestContext(paginator.extensions).Err(); err != nil {
+		return nil, err
+	}
+	extensions, span := paginator.client.startExtensionsSpan("ListVersionsPaginator", paginator.input.Bucket, "", paginator.extensions)
+	output, err := paginator.client.ListVersions(&paginator.input, extensions...)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	keyMarker, versionIdMarker := output.NextKeyMarker, output.NextVersionIdMarker
+	if keyMarker == "" {
+		keyMarker, versionIdMarker = lastVersionMarker(output)
+	}
+	if !output.IsTruncated || keyMarker == "" ||
+		(keyMarker == paginator.input.KeyMarker && versionIdMarker == paginator.input.VersionIdMarker) {
+		paginator.done = true
+	}
+	paginator.input.KeyMarker = keyMarker
+	paginator.input.VersionIdMarker = versionIdMarker
+	return output, nil
+}
+
+// lastVersionMarker returns the key and the version id of the last version or delete marker of the page.
+// The versions of a key are listed from the newest to the oldest, so the last one of a key is the oldest.
+func lastVersionMarker(output *ListVersionsOutput) (key, versionId string) {
+	var last *DeleteMarker
+	isLast := func(entry *DeleteMarker) bool {
+		return last == nil || entry.Key > last.Key || entry.Key == last.Key && !entry.LastModified.After(last.LastModified)
+	}
+	for i := range output.Versions {
+		if entry := &output.Versions[i].DeleteMarker; isLast(entry) {
+			last = entry
+		}
+	}
+	for i := range output.DeleteMarkers {
+		if entry := &output.DeleteMarkers[i]; isLast(entry) {
+			last = entry
+		}
+	}
+	if last == nil {
+		return "", ""
+	}
+	return last.Key, last.VersionId
+}
+
+// ListMultipartUploadsPaginator walks the pages of ListMultipartUploads, see ListObjectsPaginator.
+type ListMultipartUploadsPaginator struct {
+	client     *OSSClient
+	input      ListMultipartUploadsInput
+	extensions []extensionOptions
+	done       bool
+}
+
+// NewListMultipartUploadsPaginator creates a ListMultipartUploadsPaginator instance
+func NewListMultipartUploadsPaginator(client *OSSClient, input *ListMultipartUploadsInput,
+	extensions ...extensionOptions) *ListMultipartUploadsPaginator {
+	paginator := &ListMultipartUploadsPaginator{client: client, extensions: extensions}
+	if input != nil {
+		paginator.input = *input
+	}
+	return paginator
+}
+
+// HasNext reports whether there are more pages.
+func (paginator *ListMultipartUploadsPaginator) HasNext() bool {
+	return !paginator.done
+}
+
+// Next lists the next page. If it fails, the same page is listed by the next call.
+func (paginator *ListMultipartUploadsPaginator) Next() (*ListMultipartUploadsOutput, error) {
+	if paginator.done {
+		return nil, ErrNoMorePages
+	}
+	if err := paginator.client.getRequestContext(paginator.extensions).Err(); err != nil {
+		return nil, err
+	}
+	extensions, span := paginator.client.startExtensionsSpan("ListMultipartUploadsPaginator", paginator.input.Bucket, "", paginator.extensions)
+	output, err := paginator.client.ListMultipartUploads(&paginator.input, extensions...)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	keyMarker, uploadIdMarker := output.NextKeyMarker, output.NextUploadIdMarker
+	if keyMarker == "" && len(output.Uploads) > 0 {
+		last := output.Uploads[len(output.Uploads)-1]
+		keyMarker, uploadIdMarker = last.Key, last.UploadId
+	}
+	if !output.IsTruncated || keyMarker == "" ||
+		(keyMarker == paginator.input.KeyMarker && uploadIdMarker == paginator.input.UploadIdMarker) {
+		paginator.done = true
+	}
+	paginator.input.KeyMarker = keyMarker
+	paginator.input.UploadIdMarker = uploadIdMarker
+	return output, nil
+}
+
+// ListPartsPaginator walks the pages of ListParts, see ListObjectsPaginator.
+type ListPartsPaginator struct {
+	client     *OSSClient
+	input      ListPartsInput
+	extensions []extensionOptions
+	done       bool
+}
+
+// NewListPartsPaginator creates a ListPartsPaginator instance
+func NewListPartsPaginator(client *OSSClient, input *ListPartsInput, extensions ...extensionOptions) *ListPartsPaginator {
+	paginator := &ListPartsPaginator{client: client, extensions: extensions}
+	if input != nil {
+		paginator.input = *input
+	}
+	return paginator
+}
+
+// HasNext reports whether there are more pages.
+func (paginator *ListPartsPaginator) HasNext() bool {
+	return !paginator.done
+}
+
+// Next lists the next page. If it fails, the same page is listed by the next call.
+func (paginator *ListPartsPaginator) Next() (*ListPartsOutput, error) {
+	if paginator.done {
+		return nil, ErrNoMorePages
+	}
+	if err := paginator.client.getRequestContext(paginator.extensions).Err(); err != nil {
+		return nil, err
+	}
+	extensions, span := paginator.client.startExtensionsSpan("ListPartsPaginator", paginator.input.Bucket, "", paginator.extensions)
+	output, err := paginator.client.ListParts(&paginator.input, extensions...)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	marker := output.NextPartNumberMarker
+	if marker == 0 && len(output.Parts) > 0 {
+		marker = output.Parts[len(output.Parts)-1].PartNumber
+	}
+	if !output.IsTruncated || marker <= paginator.input.PartNumberMarker {
+		paginator.done = true
+	}
+	paginator.input.PartNumberMarker = marker
+	return output, nil
+}
+
+// PageListBucketsPaginator walks the pages of PageListBuckets, see ListObjectsPaginator.
+// The pages start from PageNo of the input, or the first page if it is not set.
+type PageListBucketsPaginator struct {
+	client     *OSSClient
+	input      PageListBucketsInput
+	extensions []extensionOptions
+	done       bool
+}
+
+// NewPageListBucketsPaginator creates a PageListBucketsPaginator instance
+func NewPageListBucketsPaginator(client *OSSClient, input *PageListBucketsInput, extensions ...extensionOptions) *PageListBucketsPaginator {
+	paginator := &PageListBucketsPaginator{client: client, extensions: extensions}
+	if input != nil {
+		paginator.input = *input
+	}
+	if paginator.input.PageNo == "" {
+		paginator.input.PageNo = "1"
+	}
+	return paginator
+}
+
+// HasNext reports whether there are more pages.
+func (paginator *PageListBucketsPaginator) HasNext() bool {
+	return !paginator.done
+}
+
+// Next lists the next page. If it fails, the same page is listed by the next call.
+func (paginator *PageListBucketsPaginator) Next() (*PageListBucketsOutput, error) {
+	if paginator.done {
+		return nil, ErrNoMorePages
+	}
+	if err := paginator.client.getRequestContext(paginator.extensions).Err(); err != nil {
+		return nil, err
+	}
+	extensions, span := paginator.client.startExtensionsSpan("PageListBucketsPaginator", "", "", paginator.extensions)
+	output, err := paginator.client.PageListBuckets(&paginator.input, extensions...)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	pageNo, err := strconv.Atoi(paginator.input.PageNo)
+	if err != nil {
+		paginator.done = true
+		return output, nil
+	}
+	pageSize, err := strconv.Atoi(output.PageSize)
+	if err != nil || pageSize <= 0 {
+		pageSize, err = strconv.Atoi(paginator.input.PageSize)
+	}
+	totalCount, _err := strconv.Atoi(output.TotalCount)
+	if err != nil || _err != nil || pageSize <= 0 || len(output.Buckets) == 0 || pageNo*pageSize >= totalCount {
+		paginator.done = true
+	}
+	paginator.input.PageNo = strconv.Itoa(pageNo + 1)
+	return output, nil
+}
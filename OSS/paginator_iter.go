@@ -0,0 +1,110 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+//go:build go1.23
+
+package OSS
+
+import (
+	"iter"
+)
+
+// paginate returns an iterator over the pages of a paginator, which stops after the first error.
+func paginate[T any](hasNext func() bool, next func() (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for hasNext() {
+			page, err := next()
+			if !yield(page, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// paginateItems returns an iterator over the items of the pages of a paginator, which stops after the first error.
+func paginateItems[T any, Item any](hasNext func() bool, next func() (T, error), items func(T) []Item) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		for page, err := range paginate(hasNext, next) {
+			if err != nil {
+				var zero Item
+				yield(zero, err)
+				return
+			}
+			for _, item := range items(page) {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Pages returns an iterator over the remaining pages, the pages are listed lazily.
+func (paginator *ListObjectsPaginator) Pages() iter.Seq2[*ListObjectsOutput, error] {
+	return paginate(paginator.HasNext, paginator.Next)
+}
+
+// All returns an iterator over the objects of the remaining pages, the pages are listed lazily.
+func (paginator *ListObjectsPaginator) All() iter.Seq2[Content, error] {
+	return paginateItems(paginator.HasNext, paginator.Next, func(output *ListObjectsOutput) []Content {
+		return output.Contents
+	})
+}
+
+// Pages returns an iterator over the remaining pages, the pages are listed lazily.
+func (paginator *ListVersionsPaginator) Pages() iter.Seq2[*ListVersionsOutput, error] {
+	return paginate(paginator.HasNext, paginator.Next)
+}
+
+// All returns an iterator over the versions of the remaining pages, the pages are listed lazily.
+// The delete markers are not included, use Pages to get them.
+func (paginator *ListVersionsPaginator) All() iter.Seq2[Version, error] {
+	return paginateItems(paginator.HasNext, paginator.Next, func(output *ListVersionsOutput) []Version {
+		return output.Versions
+	})
+}
+
+// Pages returns an iterator over the remaining pages, the pages are listed lazily.
+func (paginator *ListMultipartUploadsPaginator) Pages() iter.Seq2[*ListMultipartUploadsOutput, error] {
+	return paginate(paginator.HasNext, paginator.Next)
+}
+
+// All returns an iterator over the multipart uploads of the remaining pages, the pages are listed lazily.
+func (paginator *ListMultipartUploadsPaginator) All() iter.Seq2[Upload, error] {
+	return paginateItems(paginator.HasNext, paginator.Next, func(output *ListMultipartUploadsOutput) []Upload {
+		return output.Uploads
+	})
+}
+
+// Pages returns an iterator over the remaining pages, the pages are listed lazily.
+func (paginator *ListPartsPaginator) Pages() iter.Seq2[*ListPartsOutput, error] {
+	return paginate(paginator.HasNext, paginator.Next)
+}
+
+// All returns an iterator over the parts of the remaining pages, the pages are listed lazily.
+func (paginator *ListPartsPaginator) All() iter.Seq2[Part, error] {
+	return paginateItems(paginator.HasNext, paginator.Next, func(output *ListPartsOutput) []Part {
+		return output.Parts
+	})
+}
+
+// Pages returns an iterator over the remaining pages, the pages are listed lazily.
+func (paginator *PageListBucketsPaginator) Pages() iter.Seq2[*PageListBucketsOutput, error] {
+	return paginate(paginator.HasNext, paginator.Next)
+}
+
+// All returns an iterator over the buckets of the remaining pages, the pages are listed lazily.
+func (paginator *PageListBucketsPaginator) All() iter.Seq2[Bucket, error] {
+	return paginateItems(paginator.HasNext, paginator.Next, func(output *PageListBucketsOutput) []Bucket {
+		return output.Buckets
+	})
+}
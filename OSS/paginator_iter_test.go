@@ -0,0 +1,51 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+//go:build go1.23
+
+package OSS
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPaginatorIterators(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		fs.putObject("bucket", key, []byte(key))
+	}
+
+	input := &ListObjectsInput{}
+	input.Bucket, input.MaxKeys = "bucket", 2
+	var keys []string
+	for content, err := range NewListObjectsPaginator(client, input).All() {
+		if err != nil {
+			t.Fatalf("All failed: %v", err)
+		}
+		keys = append(keys, content.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c", "d", "e"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	// the pages after the break are not listed
+	before := fs.countRequests(func(r *http.Request) bool { return true })
+	for range NewListObjectsPaginator(client, input).Pages() {
+		break
+	}
+	if requests := fs.countRequests(func(r *http.Request) bool { return true }) - before; requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+}
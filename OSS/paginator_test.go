@@ -0,0 +1,182 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListObjectsPaginator(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	for _, key := range []string{"a/1", "a/2", "b", "c/1", "d", "e"} {
+		fs.putObject("bucket", key, []byte(key))
+	}
+
+	for _, delimiter := range []string{"", "/"} {
+		input := &ListObjectsInput{}
+		input.Bucket, input.MaxKeys, input.Delimiter = "bucket", 2, delimiter
+		paginator := NewListObjectsPaginator(client, input)
+		var entries []string
+		pages := 0
+		for paginator.HasNext() {
+			output, err := paginator.Next()
+			if err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			pages++
+			for _, content := range output.Contents {
+				entries = append(entries, content.Key)
+			}
+			entries = append(entries, output.CommonPrefixes...)
+		}
+		expected, expectedPages := []string{"a/1", "a/2", "b", "c/1", "d", "e"}, 3
+		if delimiter != "" {
+			expected, expectedPages = []string{"b", "a/", "d", "c/", "e"}, 3
+		}
+		if !reflect.DeepEqual(entries, expected) || pages != expectedPages {
+			t.Fatalf("delimiter %q: unexpected entries %v in %d pages", delimiter, entries, pages)
+		}
+		if _, err := paginator.Next(); err != ErrNoMorePages {
+			t.Fatalf("expected ErrNoMorePages, got: %v", err)
+		}
+	}
+}
+
+func TestListObjectsPaginatorStuckMarker(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	// the page is always truncated and never advances
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		writeXML(w, ListObjectsOutput{Name: "bucket", IsTruncated: true, Contents: []Content{{Key: "a"}}})
+		return true
+	})
+
+	input := &ListObjectsInput{}
+	input.Bucket = "bucket"
+	paginator := NewListObjectsPaginator(client, input)
+	pages := 0
+	for paginator.HasNext() && pages < 10 {
+		if _, err := paginator.Next(); err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		pages++
+	}
+	if pages != 2 {
+		t.Fatalf("expected the paginator to stop when the marker does not advance, got %d pages", pages)
+	}
+}
+
+func TestListVersionsPaginator(t *testing.T) {
+	for _, omitMarkers := range []bool{false, true} {
+		fs := newFakeServer(t)
+		client := newTestClient(t, fs)
+		fs.omitVersionMarkers = omitMarkers
+		fs.addVersion("bucket", "a", "v1", false)
+		fs.addVersion("bucket", "a", "v2", true)
+		fs.addVersion("bucket", "a", "v3", false)
+		fs.addVersion("bucket", "b", "v1", true)
+		fs.addVersion("bucket", "c", "v1", false)
+
+		input := &ListVersionsInput{}
+		input.Bucket, input.MaxKeys = "bucket", 2
+		paginator := NewListVersionsPaginator(client, input)
+		var entries []string
+		for pages := 0; paginator.HasNext(); pages++ {
+			if pages == 10 {
+				t.Fatalf("omitMarkers %v: the paginator does not stop", omitMarkers)
+			}
+			output, err := paginator.Next()
+			if err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			for _, version := range output.Versions {
+				entries = append(entries, version.Key+"@"+version.VersionId)
+			}
+			for _, marker := range output.DeleteMarkers {
+				entries = append(entries, marker.Key+"@"+marker.VersionId+"(deleted)")
+			}
+		}
+		expected := []string{"a@v1", "a@v2(deleted)", "a@v3", "b@v1(deleted)", "c@v1"}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Fatalf("omitMarkers %v: unexpected entries %v", omitMarkers, entries)
+		}
+	}
+}
+
+func TestListMultipartUploadsAndPartsPaginators(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	for _, key := range []string{"a", "a", "b"} {
+		addUploadParts(fs, "bucket", key, time.Now())
+	}
+	uploadID := addUploadParts(fs, "bucket", "c", time.Now(), 1, 2, 3, 4, 5)
+
+	uploadsInput := &ListMultipartUploadsInput{}
+	uploadsInput.Bucket, uploadsInput.MaxUploads = "bucket", 2
+	uploadsPaginator := NewListMultipartUploadsPaginator(client, uploadsInput)
+	var uploads []string
+	for uploadsPaginator.HasNext() {
+		output, err := uploadsPaginator.Next()
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		for _, upload := range output.Uploads {
+			uploads = append(uploads, upload.Key)
+		}
+	}
+	if !reflect.DeepEqual(uploads, []string{"a", "a", "b", "c"}) {
+		t.Fatalf("unexpected uploads %v", uploads)
+	}
+
+	partsInput := &ListPartsInput{}
+	partsInput.Bucket, partsInput.Key, partsInput.UploadId, partsInput.MaxParts = "bucket", "c", uploadID, 2
+	partsPaginator := NewListPartsPaginator(client, partsInput)
+	var sizes []int64
+	for partsPaginator.HasNext() {
+		output, err := partsPaginator.Next()
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		for _, part := range output.Parts {
+			sizes = append(sizes, part.Size)
+		}
+	}
+	if !reflect.DeepEqual(sizes, []int64{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected parts %v", sizes)
+	}
+}
+
+func TestPaginatorRetriesFailedPage(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs, WithMaxRetryCount(0))
+	fs.putObject("bucket", "a", []byte("a"))
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return true
+	})
+
+	input := &ListObjectsInput{}
+	input.Bucket = "bucket"
+	paginator := NewListObjectsPaginator(client, input)
+	if _, err := paginator.Next(); err == nil || !paginator.HasNext() {
+		t.Fatalf("expected the failed page to be listed again, err: %v", err)
+	}
+	fs.setHook(nil)
+	if output, err := paginator.Next(); err != nil || len(output.Contents) != 1 || paginator.HasNext() {
+		t.Fatalf("unexpected page after the failure, err: %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+const (
+	defaultParallelListMaxDepth   = 3
+	defaultParallelListBufferSize = 1000
+	// the keyspace is not split further once there are this number of partitions per task
+	parallelListPartitionsPerTask = 4
+)
+
+// listSegment is a part of the keyspace, either an object found while splitting the keyspace,
+// or a partition whose objects are listed without delimiter.
+type listSegment struct {
+	key       string
+	content   Content
+	partition bool
+}
+
+type parallelLister struct {
+	client     OSSClient
+	input      *ParallelListObjectsInput
+	extensions []extensionOptions
+	ctx        context.Context
+	cancel     context.CancelFunc
+	requests   chan struct{}
+	lock       sync.Mutex
+	err        error
+}
+
+func (lister *parallelLister) fail(err error) {
+	lister.lock.Lock()
+	defer lister.lock.Unlock()
+	if lister.err == nil {
+		lister.err = err
+		lister.cancel()
+	}
+}
+
+func (lister *parallelLister) getErr() error {
+	lister.lock.Lock()
+	defer lister.lock.Unlock()
+	return lister.err
+}
+
+func (lister *parallelLister) send(out chan<- ListedObject, content Content) bool {
+	select {
+	case out <- ListedObject{Content: content}:
+		return true
+	case <-lister.ctx.Done():
+		return false
+	}
+}
+
+// list calls fn for each page of prefix, with at most MaxRequestsInFlight requests at the same time.
+func (lister *parallelLister) list(prefix, delimiter string, fn func(output *ListObjectsOutput) bool) error {
+	listInput := &ListObjectsInput{}
+	listInput.Bucket = lister.input.Bucket
+	listInput.Prefix = prefix
+	listInput.Delimiter = delimiter
+	paginator := NewListObjectsPaginator(&lister.client, listInput, lister.extensions...)
+	for paginator.HasNext() {
+		select {
+		case lister.requests <- struct{}{}:
+		case <-lister.ctx.Done():
+			return lister.ctx.Err()
+		}
+		output, err := paginator.Next()
+		<-lister.requests
+		if err != nil {
+			return err
+		}
+		if !fn(output) {
+			return lister.ctx.Err()
+		}
+	}
+	return nil
+}
+
+// split splits the keyspace level by level, and returns the segments in key order.
+func (lister *parallelLister) split(pool Pool) []listSegment {
+	var segments []listSegment
+	var lock sync.Mutex
+	level := []string{lister.input.Prefix}
+	for depth := 0; len(level) > 0 && lister.ctx.Err() == nil; depth++ {
+		if depth == lister.input.MaxDepth || len(level) >= parallelListPartitionsPerTask*lister.input.TaskNum {
+			for _, prefix := range level {
+				segments = append(segments, listSegment{key: prefix, partition: true})
+			}
+			break
+		}
+
+		var next []string
+		var wg sync.WaitGroup
+		for _, prefix := range level {
+			prefix := prefix
+			wg.Add(1)
+			pool.ExecuteFunc(func() interface{} {
+				defer wg.Done()
+				err := lister.list(prefix, lister.input.Delimiter, func(output *ListObjectsOutput) bool {
+					lock.Lock()
+					defer lock.Unlock()
+					for _, content := range output.Contents {
+						segments = append(segments, listSegment{key: content.Key, content: content})
+					}
+					next = append(next, output.CommonPrefixes...)
+					return true
+				})
+				if err != nil {
+					lister.fail(err)
+				}
+				return nil
+			})
+		}
+		wg.Wait()
+		level = next
+	}
+
+	// an object found while splitting never starts with the prefix of a partition,
+	// so the segments in key order keep the objects in key order
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].key < segments[j].key
+	})
+	return segments
+}
+
+func (lister *parallelLister) listPartition(prefix string, out chan<- ListedObject) {
+	err := lister.list(prefix, "", func(output *ListObjectsOutput) bool {
+		for _, content := range output.Contents {
+			if !lister.send(out, content) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		lister.fail(err)
+	}
+}
+
+func (lister *parallelLister) run(pool Pool, out chan<- ListedObject) {
+	segments := lister.split(pool)
+	if lister.ctx.Err() != nil {
+		return
+	}
+
+	if !lister.input.Ordered {
+		for _, segment := range segments {
+			if segment.partition {
+				prefix := segment.key
+				pool.ExecuteFunc(func() interface{} {
+					lister.listPartition(prefix, out)
+					return nil
+				})
+			} else if !lister.send(out, segment.content) {
+				return
+			}
+		}
+		return
+	}
+
+	// the partitions are dispatched in key order, so the partition being emitted always has a routine
+	partitions := make([]chan ListedObject, len(segments))
+	for i, segment := range segments {
+		if segment.partition {
+			partitions[i] = make(chan ListedObject, lister.input.BufferSize)
+		}
+	}
+	emitted := make(chan struct{})
+	go func() {
+		defer close(emitted)
+		for i, segment := range segments {
+			if !segment.partition {
+				if !lister.send(out, segment.content) {
+					return
+				}
+				continue
+			}
+			for {
+				var result ListedObject
+				var ok bool
+				select {
+				case result, ok = <-partitions[i]:
+				case <-lister.ctx.Done():
+					return
+				}
+				if !ok {
+					break
+				}
+				if !lister.send(out, result.Content) {
+					return
+				}
+			}
+		}
+	}()
+	for i, segment := range segments {
+		if !segment.partition {
+			continue
+		}
+		if lister.ctx.Err() != nil {
+			break
+		}
+		prefix, partition := segment.key, partitions[i]
+		pool.ExecuteFunc(func() interface{} {
+			defer close(partition)
+			lister.listPartition(prefix, partition)
+			return nil
+		})
+	}
+	<-emitted
+}
+
+func (OSSClient OSSClient) parallelListObjects(input *ParallelListObjectsInput, extensions []extensionOptions) <-chan ListedObject {
+	// the span ends when the listing is done, the pages are its children
+	extensions, span := OSSClient.startExtensionsSpan("ParallelListObjects", input.Bucket, "", extensions)
+	parent := OSSClient.getRequestContext(extensions)
+	ctx, cancel := context.WithCancel(parent)
+	lister := &parallelLister{
+		client:     OSSClient,
+		input:      input,
+		extensions: append([]extensionOptions{WithContext(ctx)}, extensions...),
+		ctx:        ctx,
+		cancel:     cancel,
+		requests:   make(chan struct{}, input.MaxRequestsInFlight),
+	}
+
+	out := make(chan ListedObject, input.BufferSize)
+	go func() {
+		defer close(out)
+		defer cancel()
+		pool := NewRoutinePool(input.TaskNum, 0)
+		lister.run(pool, out)
+		pool.ShutDown()
+
+		err := lister.getErr()
+		if err == nil {
+			err = parent.Err()
+		}
+		endSpan(span, err)
+		if err != nil {
+			select {
+			case out <- ListedObject{Err: err}:
+			case <-parent.Done():
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// putPartitionedObjects puts the objects of several directories and returns their sorted keys.
+func putPartitionedObjects(fs *fakeServer) []string {
+	var keys []string
+	for _, dir := range []string{"data/a/", "data/b/", "data/b/x/", "data/c/", ""} {
+		for i := 0; i < 3; i++ {
+			key := fmt.Sprintf("%sfile%d", dir, i)
+			if dir == "" {
+				key = "data/" + key
+			}
+			fs.putObject("bucket", key, []byte(key))
+			keys = append(keys, key)
+		}
+	}
+	fs.putObject("bucket", "other", []byte("other"))
+	sort.Strings(keys)
+	return keys
+}
+
+func collectListedObjects(t *testing.T, objects <-chan ListedObject) ([]string, error) {
+	var keys []string
+	for object := range objects {
+		if object.Err != nil {
+			return keys, object.Err
+		}
+		keys = append(keys, object.Content.Key)
+	}
+	return keys, nil
+}
+
+func TestParallelListObjects(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	expected := putPartitionedObjects(fs)
+
+	for _, ordered := range []bool{false, true} {
+		input := &ParallelListObjectsInput{Bucket: "bucket", Prefix: "data/", MaxDepth: 2, TaskNum: 3, Ordered: ordered}
+		objects, err := client.ParallelListObjects(input)
+		if err != nil {
+			t.Fatalf("ParallelListObjects failed: %v", err)
+		}
+		keys, err := collectListedObjects(t, objects)
+		if err != nil {
+			t.Fatalf("the listing failed: %v", err)
+		}
+		if !ordered {
+			sort.Strings(keys)
+		}
+		if !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("Ordered %v: unexpected keys %v", ordered, keys)
+		}
+	}
+}
+
+func TestParallelListObjectsRequestsInFlight(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	putPartitionedObjects(fs)
+	var running, maxRunning int32
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		n := atomic.AddInt32(&running, 1)
+		for max := atomic.LoadInt32(&maxRunning); n > max && !atomic.CompareAndSwapInt32(&maxRunning, max, n); max = atomic.LoadInt32(&maxRunning) {
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return false
+	})
+
+	input := &ParallelListObjectsInput{Bucket: "bucket", Prefix: "data/", MaxDepth: 2, TaskNum: 4, MaxRequestsInFlight: 2}
+	objects, err := client.ParallelListObjects(input)
+	if err != nil {
+		t.Fatalf("ParallelListObjects failed: %v", err)
+	}
+	if _, err := collectListedObjects(t, objects); err != nil {
+		t.Fatalf("the listing failed: %v", err)
+	}
+	if maxRunning > 2 {
+		t.Fatalf("expected at most 2 requests in flight, got %d", maxRunning)
+	}
+}
+
+func TestParallelListObjectsError(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	putPartitionedObjects(fs)
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("prefix") == "data/b/" {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	input := &ParallelListObjectsInput{Bucket: "bucket", Prefix: "data/", MaxDepth: 2, TaskNum: 2}
+	objects, err := client.ParallelListObjects(input)
+	if err != nil {
+		t.Fatalf("ParallelListObjects failed: %v", err)
+	}
+	if _, err := collectListedObjects(t, objects); err == nil {
+		t.Fatal("expected the error of the listing")
+	}
+}
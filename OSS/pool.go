@@ -281,15 +281,17 @@ func (pool *RoutinePool) checkStatus(t Task) error {
 
 func (pool *RoutinePool) dispatcher() {
 	pool.shutDownWg.Add(1)
+	// ShutDown resets the queues of the pool, so the goroutine keeps its own references to them
+	dispatchQueue, taskQueue := pool.dispatchQueue, pool.taskQueue
 	go func() {
 		for {
-			task, ok := <-pool.dispatchQueue
+			task, ok := <-dispatchQueue
 			if !ok {
 				break
 			}
 
 			if task == closeQueue {
-				close(pool.taskQueue)
+				close(taskQueue)
 				pool.shutDownWg.Done()
 				continue
 			}
@@ -298,7 +300,7 @@ func (pool *RoutinePool) dispatcher() {
 				pool.addWorker()
 			}
 
-			pool.taskQueue <- task
+			taskQueue <- task
 		}
 	}()
 }
@@ -0,0 +1,251 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ProgressEventType defines the type of a progress event
+type ProgressEventType int
+
+const (
+	// TransferStartedEvent is published once before any data is transferred
+	TransferStartedEvent ProgressEventType = 1 + iota
+	// TransferDataEvent is published every time data is sent or received.
+	// RwBytes is negative when bytes are rolled back because a request is retried.
+	TransferDataEvent
+	// TransferCompletedEvent is published once after the transfer succeeds
+	TransferCompletedEvent
+	// TransferFailedEvent is published once after the transfer fails
+	TransferFailedEvent
+)
+
+// ProgressEvent defines the progress of a transfer
+type ProgressEvent struct {
+	ConsumedBytes int64
+	TotalBytes    int64
+	RwBytes       int64
+	EventType     ProgressEventType
+}
+
+// ProgressListener defines interface with function: ProgressChanged
+//
+// ProgressChanged is called synchronously from the goroutine that transfers the data,
+// so the implementation should return quickly.
+type ProgressListener interface {
+	ProgressChanged(event *ProgressEvent)
+}
+
+type extensionProgressListener func() ProgressListener
+
+// WithProgress sets the listener which receives the progress events of the request
+func WithProgress(listener ProgressListener) extensionProgressListener {
+	return func() ProgressListener {
+		return listener
+	}
+}
+
+func getProgressListener(extensions []extensionOptions) ProgressListener {
+	for _, extension := range extensions {
+		if progressListener, ok := extension.(extensionProgressListener); ok {
+			if listener := progressListener(); listener != nil {
+				return listener
+			}
+		}
+	}
+	return nil
+}
+
+// withProgressListener returns a copy of extensions in which listener takes precedence over
+// any listener set by WithProgress.
+func withProgressListener(extensions []extensionOptions, listener ProgressListener) []extensionOptions {
+	if listener == nil {
+		return extensions
+	}
+	_extensions := make([]extensionOptions, 0, len(extensions)+1)
+	_extensions = append(_extensions, WithProgress(listener))
+	return append(_extensions, extensions...)
+}
+
+// splitProgressListener returns the listener of a multi-request transfer and the extensions
+// without any progress listener, so that each request of the transfer does not report to the
+// listener directly.
+func splitProgressListener(extensions []extensionOptions, listener ProgressListener) (ProgressListener, []extensionOptions) {
+	if listener == nil {
+		listener = getProgressListener(extensions)
+	}
+	_extensions := make([]extensionOptions, 0, len(extensions))
+	for _, extension := range extensions {
+		if _, ok := extension.(extensionProgressListener); !ok {
+			_extensions = append(_extensions, extension)
+		}
+	}
+	return listener, _extensions
+}
+
+type progressTracker struct {
+	listener      ProgressListener
+	lock          sync.Mutex
+	consumedBytes int64
+	totalBytes    int64
+	finished      int32
+}
+
+func newProgressTracker(listener ProgressListener, consumedBytes, totalBytes int64) *progressTracker {
+	if listener == nil {
+		return nil
+	}
+	return &progressTracker{listener: listener, consumedBytes: consumedBytes, totalBytes: totalBytes}
+}
+
+func (tracker *progressTracker) publish(eventType ProgressEventType, rwBytes int64) {
+	tracker.listener.ProgressChanged(&ProgressEvent{
+		ConsumedBytes: tracker.consumedBytes,
+		TotalBytes:    tracker.totalBytes,
+		RwBytes:       rwBytes,
+		EventType:     eventType,
+	})
+}
+
+func (tracker *progressTracker) setTotalBytes(totalBytes int64) {
+	if tracker == nil {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.totalBytes = totalBytes
+}
+
+func (tracker *progressTracker) started() {
+	if tracker == nil {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.publish(TransferStartedEvent, 0)
+}
+
+func (tracker *progressTracker) add(rwBytes int64) {
+	if tracker == nil || rwBytes == 0 {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.consumedBytes += rwBytes
+	tracker.publish(TransferDataEvent, rwBytes)
+}
+
+func (tracker *progressTracker) completed() {
+	if tracker == nil || !atomic.CompareAndSwapInt32(&tracker.finished, 0, 1) {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.publish(TransferCompletedEvent, 0)
+}
+
+func (tracker *progressTracker) failed() {
+	if tracker == nil || !atomic.CompareAndSwapInt32(&tracker.finished, 0, 1) {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.publish(TransferFailedEvent, 0)
+}
+
+// partProgressListener forwards the data events of one part to the tracker of the whole
+// transfer, and rolls the bytes of the part back if the part fails.
+type partProgressListener struct {
+	tracker       *progressTracker
+	consumedBytes int64
+}
+
+func newPartProgressListener(tracker *progressTracker) ProgressListener {
+	if tracker == nil {
+		return nil
+	}
+	return &partProgressListener{tracker: tracker}
+}
+
+func (listener *partProgressListener) ProgressChanged(event *ProgressEvent) {
+	switch event.EventType {
+	case TransferDataEvent:
+		atomic.AddInt64(&listener.consumedBytes, event.RwBytes)
+		listener.tracker.add(event.RwBytes)
+	case TransferFailedEvent:
+		listener.rollback()
+	}
+}
+
+func (listener *partProgressListener) rollback() {
+	listener.tracker.add(-atomic.SwapInt64(&listener.consumedBytes, 0))
+}
+
+func rollbackPartProgress(listener ProgressListener) {
+	if partListener, ok := listener.(*partProgressListener); ok {
+		partListener.rollback()
+	}
+}
+
+// attachProgressTracker makes the request body report the bytes it reads to the tracker.
+func attachProgressTracker(data interface{}, headers map[string][]string, tracker *progressTracker) interface{} {
+	if tracker == nil {
+		return data
+	}
+	switch _data := data.(type) {
+	case *fileReaderWrapper:
+		_data.tracker = tracker
+		tracker.setTotalBytes(_data.totalCount)
+	case *readerWrapper:
+		_data.tracker = tracker
+		tracker.setTotalBytes(_data.totalCount)
+	case string:
+		return attachProgressTracker(strings.NewReader(_data), headers, tracker)
+	case []byte:
+		return attachProgressTracker(bytes.NewReader(_data), headers, tracker)
+	case io.Reader:
+		totalCount := int64(-1)
+		if value, ok := headers[HEADER_CONTENT_LENGTH_CAMEL]; ok {
+			totalCount = StringToInt64(value[0], -1)
+		} else if lenReader, ok := _data.(interface{ Len() int }); ok {
+			// keep the content length which http.NewRequest would detect from the unwrapped reader
+			totalCount = int64(lenReader.Len())
+			headers[HEADER_CONTENT_LENGTH_CAMEL] = []string{Int64ToString(totalCount)}
+		}
+		tracker.setTotalBytes(totalCount)
+		return &readerWrapper{reader: _data, totalCount: totalCount, tracker: tracker}
+	}
+	return data
+}
+
+// progressReadCloser reports the bytes read from a response body to the tracker.
+type progressReadCloser struct {
+	io.ReadCloser
+	tracker *progressTracker
+}
+
+func (prc *progressReadCloser) Read(p []byte) (n int, err error) {
+	n, err = prc.ReadCloser.Read(p)
+	prc.tracker.add(int64(n))
+	if err == io.EOF {
+		prc.tracker.completed()
+	} else if err != nil {
+		prc.tracker.failed()
+	}
+	return
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type recordingListener struct {
+	lock   sync.Mutex
+	events []ProgressEvent
+}
+
+func (listener *recordingListener) ProgressChanged(event *ProgressEvent) {
+	listener.lock.Lock()
+	defer listener.lock.Unlock()
+	listener.events = append(listener.events, *event)
+}
+
+func (listener *recordingListener) count(eventType ProgressEventType) int {
+	listener.lock.Lock()
+	defer listener.lock.Unlock()
+	count := 0
+	for _, event := range listener.events {
+		if event.EventType == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+func (listener *recordingListener) last() ProgressEvent {
+	listener.lock.Lock()
+	defer listener.lock.Unlock()
+	return listener.events[len(listener.events)-1]
+}
+
+func TestPutObjectProgress(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	listener := &recordingListener{}
+	data := strings.Repeat("a", 100000)
+
+	input := &PutObjectInput{}
+	input.Bucket, input.Key = "bucket", "key"
+	input.Body = strings.NewReader(data)
+	if _, err := client.PutObject(input, WithProgress(listener)); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if listener.count(TransferStartedEvent) != 1 || listener.count(TransferCompletedEvent) != 1 || listener.count(TransferDataEvent) == 0 {
+		t.Fatalf("unexpected events: %+v", listener.events)
+	}
+	if last := listener.last(); last.ConsumedBytes != int64(len(data)) || last.TotalBytes != int64(len(data)) {
+		t.Fatalf("unexpected last event: %+v", last)
+	}
+}
+
+func TestStringBodyProgress(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	listener := &recordingListener{}
+	policy := `{"Statement":[]}`
+
+	if _, err := client.SetBucketPolicy(&SetBucketPolicyInput{Bucket: "bucket", Policy: policy}, WithProgress(listener)); err != nil {
+		t.Fatalf("SetBucketPolicy failed: %v", err)
+	}
+	if listener.count(TransferDataEvent) == 0 {
+		t.Fatalf("no data event for a string body: %+v", listener.events)
+	}
+	if last := listener.last(); last.EventType != TransferCompletedEvent || last.ConsumedBytes != int64(len(policy)) {
+		t.Fatalf("unexpected last event: %+v", last)
+	}
+}
+
+func TestGetObjectProgress(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := bytes.Repeat([]byte("b"), 50000)
+	fs.putObject("bucket", "key", data)
+	listener := &recordingListener{}
+
+	input := &GetObjectInput{}
+	input.Bucket, input.Key = "bucket", "key"
+	output, err := client.GetObject(input, WithProgress(listener))
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	read, err := io.ReadAll(output.Body)
+	output.Body.Close()
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("unexpected body, err: %v", err)
+	}
+	if last := listener.last(); last.EventType != TransferCompletedEvent || last.ConsumedBytes != int64(len(data)) ||
+		last.TotalBytes != int64(len(data)) {
+		t.Fatalf("unexpected last event: %+v", last)
+	}
+}
+
+func TestUploadFileProgressRollsBackFailedParts(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := bytes.Repeat([]byte("c"), 3*MIN_PART_SIZE+10)
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	var failed int32
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("partNumber") == "2" && atomic.CompareAndSwapInt32(&failed, 0, 1) {
+			io.Copy(io.Discard, r.Body)
+			writeError(w, http.StatusInternalServerError, "InternalError")
+			return true
+		}
+		return false
+	})
+	listener := &recordingListener{}
+
+	input := &UploadFileInput{UploadFile: file, PartSize: MIN_PART_SIZE, TaskNum: 2, ProgressListener: listener}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.UploadFile(input); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	last := listener.last()
+	if last.EventType != TransferCompletedEvent || last.ConsumedBytes != int64(len(data)) || last.TotalBytes != int64(len(data)) {
+		t.Fatalf("unexpected last event: %+v", last)
+	}
+	if object, ok := fs.getObject("bucket", "key"); !ok || !bytes.Equal(object.data, data) {
+		t.Fatal("unexpected object")
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package prometheus provides an OSS.MetricsRecorder which exposes the metrics of the client in the
+// Prometheus text exposition format, without depending on the Prometheus client library.
+//
+//	collector := prometheus.NewCollector("oss")
+//	client, err := OSS.New(ak, sk, endpoint, OSS.WithMetricsRecorder(collector))
+//	http.Handle("/metrics", collector)
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dangcingzzw/inspur-go-sdk/OSS"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds in seconds of the buckets of the latency histograms.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, bound := range buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+type operationMetrics struct {
+	requests      uint64
+	attempts      uint64
+	retries       uint64
+	bytesSent     int64
+	bytesReceived int64
+	latency       histogram
+	errors        map[string]uint64
+}
+
+// Collector implements OSS.MetricsRecorder, and serves the metrics recorded as an http.Handler.
+//
+// The metrics are, with the namespace as prefix:
+//
+//	requests_total{operation}            operations started, the first attempts
+//	attempts_total{operation}            HTTP attempts
+//	retries_total{operation}             attempts which are retries or redirects
+//	request_duration_seconds{operation}  histogram of the latency of the attempts
+//	sent_bytes_total{operation}          bytes of the request bodies
+//	received_bytes_total{operation}      bytes of the response bodies
+//	errors_total{operation,code}         failed attempts by the code of OSSError, the status code if
+//	                                     the response has no code, or NetworkError
+//	dial_duration_seconds                histogram of the time to dial the connections
+//	dial_errors_total                    connections failed to dial
+type Collector struct {
+	namespace  string
+	buckets    []float64
+	lock       sync.Mutex
+	operations map[string]*operationMetrics
+	dial       histogram
+	dialErrors uint64
+}
+
+// NewCollector creates a Collector with the namespace, which defaults to "oss", and DefaultBuckets.
+func NewCollector(namespace string) *Collector {
+	return NewCollectorWithBuckets(namespace, DefaultBuckets)
+}
+
+// NewCollectorWithBuckets creates a Collector with the namespace and the upper bounds of the histogram buckets.
+func NewCollectorWithBuckets(namespace string, buckets []float64) *Collector {
+	if namespace == "" {
+		namespace = "oss"
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Collector{
+		namespace:  namespace,
+		buckets:    sorted,
+		operations: make(map[string]*operationMetrics),
+	}
+}
+
+// RecordAttempt implements OSS.MetricsRecorder.
+func (collector *Collector) RecordAttempt(metrics *OSS.AttemptMetrics) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	operation := collector.operations[metrics.Operation]
+	if operation == nil {
+		operation = &operationMetrics{errors: make(map[string]uint64)}
+		collector.operations[metrics.Operation] = operation
+	}
+	operation.attempts++
+	if metrics.Attempt == 0 {
+		operation.requests++
+	} else {
+		operation.retries++
+	}
+	operation.bytesSent += metrics.BytesSent
+	operation.bytesReceived += metrics.BytesReceived
+	operation.latency.observe(collector.buckets, metrics.Latency.Seconds())
+	if metrics.Err != nil {
+		code := metrics.ErrorCode
+		if code == "" {
+			if metrics.StatusCode > 0 {
+				code = strconv.Itoa(metrics.StatusCode)
+			} else {
+				code = "NetworkError"
+			}
+		}
+		operation.errors[code]++
+	}
+}
+
+// RecordDial implements OSS.MetricsRecorder.
+func (collector *Collector) RecordDial(network, address string, duration time.Duration, err error) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	collector.dial.observe(collector.buckets, duration.Seconds())
+	if err != nil {
+		collector.dialErrors++
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (collector *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	if _, err := collector.WriteTo(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type countingWriter struct {
+	*bufio.Writer
+	count int64
+}
+
+func (w *countingWriter) printf(format string, args ...interface{}) {
+	n, _ := fmt.Fprintf(w.Writer, format, args...)
+	w.count += int64(n)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format, the operations are sorted by name.
+func (collector *Collector) WriteTo(writer io.Writer) (int64, error) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	w := &countingWriter{Writer: bufio.NewWriter(writer)}
+
+	names := make([]string, 0, len(collector.operations))
+	for name := range collector.operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counter := func(name, help string, value func(operation *operationMetrics) float64) {
+		collector.writeHeader(w, name, help, "counter")
+		for _, operation := range names {
+			w.printf("%s_%s{operation=\"%s\"} %s\n", collector.namespace, name, escape(operation),
+				formatFloat(value(collector.operations[operation])))
+		}
+	}
+	counter("requests_total", "Number of the operations started.", func(operation *operationMetrics) float64 {
+		return float64(operation.requests)
+	})
+	counter("attempts_total", "Number of the HTTP attempts.", func(operation *operationMetrics) float64 {
+		return float64(operation.attempts)
+	})
+	counter("retries_total", "Number of the HTTP attempts which are retries or redirects.", func(operation *operationMetrics) float64 {
+		return float64(operation.retries)
+	})
+	counter("sent_bytes_total", "Bytes of the request bodies sent.", func(operation *operationMetrics) float64 {
+		return float64(operation.bytesSent)
+	})
+	counter("received_bytes_total", "Bytes of the response bodies received.", func(operation *operationMetrics) float64 {
+		return float64(operation.bytesReceived)
+	})
+
+	collector.writeHeader(w, "errors_total", "Number of the failed HTTP attempts by error code.", "counter")
+	for _, operation := range names {
+		errors := collector.operations[operation].errors
+		codes := make([]string, 0, len(errors))
+		for code := range errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			w.printf("%s_errors_total{operation=\"%s\",code=\"%s\"} %d\n", collector.namespace, escape(operation), escape(code), errors[code])
+		}
+	}
+
+	collector.writeHeader(w, "request_duration_seconds", "Latency of the HTTP attempts until the response headers.", "histogram")
+	for _, operation := range names {
+		collector.writeHistogram(w, "request_duration_seconds", fmt.Sprintf("operation=\"%s\",", escape(operation)),
+			&collector.operations[operation].latency)
+	}
+
+	collector.writeHeader(w, "dial_duration_seconds", "Time to dial the connections.", "histogram")
+	collector.writeHistogram(w, "dial_duration_seconds", "", &collector.dial)
+	collector.writeHeader(w, "dial_errors_total", "Number of the connections failed to dial.", "counter")
+	w.printf("%s_dial_errors_total %d\n", collector.namespace, collector.dialErrors)
+
+	return w.count, w.Flush()
+}
+
+func (collector *Collector) writeHeader(w *countingWriter, name, help, metricType string) {
+	w.printf("# HELP %s_%s %s\n", collector.namespace, name, help)
+	w.printf("# TYPE %s_%s %s\n", collector.namespace, name, metricType)
+}
+
+// writeHistogram writes the cumulative buckets of h, labels is empty or ends with a comma.
+func (collector *Collector) writeHistogram(w *countingWriter, name, labels string, h *histogram) {
+	for i, bound := range collector.buckets {
+		var count uint64
+		if h.counts != nil {
+			count = h.counts[i]
+		}
+		w.printf("%s_%s_bucket{%sle=\"%s\"} %d\n", collector.namespace, name, labels, formatFloat(bound), count)
+	}
+	w.printf("%s_%s_bucket{%sle=\"+Inf\"} %d\n", collector.namespace, name, labels, h.count)
+	suffix := ""
+	if labels != "" {
+		suffix = "{" + strings.TrimSuffix(labels, ",") + "}"
+	}
+	w.printf("%s_%s_sum%s %s\n", collector.namespace, name, suffix, formatFloat(h.sum))
+	w.printf("%s_%s_count%s %d\n", collector.namespace, name, suffix, h.count)
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package prometheus
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dangcingzzw/inspur-go-sdk/OSS"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollectorWithBuckets("", []float64{1, 0.1})
+	collector.RecordAttempt(&OSS.AttemptMetrics{Operation: "PutObject", Latency: 50 * time.Millisecond, StatusCode: 503,
+		ErrorCode: "ServiceUnavailable", Err: errors.New("unavailable")})
+	collector.RecordAttempt(&OSS.AttemptMetrics{Operation: "PutObject", Attempt: 1, Latency: 500 * time.Millisecond,
+		StatusCode: 200, BytesSent: 100})
+	collector.RecordAttempt(&OSS.AttemptMetrics{Operation: "Get\"Object", Latency: 2 * time.Second, Err: errors.New("reset")})
+	collector.RecordDial("tcp", "host:80", 10*time.Millisecond, nil)
+	collector.RecordDial("tcp", "host:80", time.Second, errors.New("refused"))
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %s", contentType)
+	}
+	body, _ := io.ReadAll(recorder.Body)
+	for _, line := range []string{
+		"# TYPE oss_requests_total counter",
+		`oss_requests_total{operation="PutObject"} 1`,
+		`oss_attempts_total{operation="PutObject"} 2`,
+		`oss_retries_total{operation="PutObject"} 1`,
+		`oss_sent_bytes_total{operation="PutObject"} 100`,
+		`oss_errors_total{operation="PutObject",code="ServiceUnavailable"} 1`,
+		`oss_errors_total{operation="Get\"Object",code="NetworkError"} 1`,
+		"# TYPE oss_request_duration_seconds histogram",
+		`oss_request_duration_seconds_bucket{operation="PutObject",le="0.1"} 1`,
+		`oss_request_duration_seconds_bucket{operation="PutObject",le="1"} 2`,
+		`oss_request_duration_seconds_bucket{operation="PutObject",le="+Inf"} 2`,
+		`oss_request_duration_seconds_count{operation="PutObject"} 2`,
+		`oss_dial_duration_seconds_count 2`,
+		`oss_dial_errors_total 1`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing line %s in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorWithClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	collector := NewCollector("sdk")
+	client, err := OSS.New("ak", "sk", server.URL, OSS.WithMetricsRecorder(collector))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := client.HeadBucket("bucket"); err != nil {
+		t.Fatalf("HeadBucket failed: %v", err)
+	}
+
+	var builder strings.Builder
+	if _, err := collector.WriteTo(&builder); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{`sdk_requests_total{operation="HeadBucket"} 1`, "sdk_dial_duration_seconds_count 1"} {
+		if !strings.Contains(builder.String(), line+"\n") {
+			t.Errorf("missing line %s in:\n%s", line, builder.String())
+		}
+	}
+}
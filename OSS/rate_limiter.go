@@ -0,0 +1,198 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitChunk is the max number of bytes read at a time by a rate limited body
+const rateLimitChunk = 32 * 1024
+
+// RateLimiter limits the bandwidth of the request and response bodies with a token bucket.
+//
+// A RateLimiter is safe for concurrent use, so one limiter can be shared by all the part requests of
+// a transfer, or by several transfers. The bucket holds at most one second of tokens.
+type RateLimiter struct {
+	lock   sync.Mutex
+	limit  int64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter of bytesPerSecond, the bandwidth is not limited if bytesPerSecond <= 0.
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	limiter := &RateLimiter{last: time.Now()}
+	limiter.SetLimit(bytesPerSecond)
+	return limiter
+}
+
+// SetLimit changes the bandwidth limit, it takes effect immediately for all the bodies using the limiter,
+// including those of the requests started before a limit is set.
+func (limiter *RateLimiter) SetLimit(bytesPerSecond int64) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	limiter.refill(time.Now())
+	if bytesPerSecond <= 0 {
+		bytesPerSecond = 0
+	}
+	limiter.limit = bytesPerSecond
+	if limiter.tokens > float64(bytesPerSecond) {
+		limiter.tokens = float64(bytesPerSecond)
+	}
+}
+
+// Limit returns the bandwidth limit in bytes per second, 0 means unlimited.
+func (limiter *RateLimiter) Limit() int64 {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	return limiter.limit
+}
+
+// refill adds the tokens accumulated until now, the lock must be held by the caller.
+func (limiter *RateLimiter) refill(now time.Time) {
+	if limiter.limit > 0 {
+		limiter.tokens += now.Sub(limiter.last).Seconds() * float64(limiter.limit)
+		if limiter.tokens > float64(limiter.limit) {
+			limiter.tokens = float64(limiter.limit)
+		}
+	}
+	limiter.last = now
+}
+
+// chunkSize returns the max number of bytes which should be read at a time.
+func (limiter *RateLimiter) chunkSize() int {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	if limiter.limit > 0 && limiter.limit < rateLimitChunk {
+		return int(limiter.limit)
+	}
+	return rateLimitChunk
+}
+
+// wait takes n tokens from the bucket, and waits until the bucket is no longer in debt.
+// It returns the error of ctx if ctx is done before that.
+func (limiter *RateLimiter) wait(ctx context.Context, n int) error {
+	limiter.lock.Lock()
+	if limiter.limit <= 0 {
+		limiter.lock.Unlock()
+		return nil
+	}
+	limiter.refill(time.Now())
+	limiter.tokens -= float64(n)
+	var delay time.Duration
+	if limiter.tokens < 0 {
+		delay = time.Duration(-limiter.tokens / float64(limiter.limit) * float64(time.Second))
+	}
+	limiter.lock.Unlock()
+
+	if delay > 0 && !sleepWithContext(ctx, delay) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// RateLimiter returns the limiter of the client, which limits the bandwidth of all the requests of the client.
+// The bandwidth is not limited unless WithRateLimit is set or RateLimiter().SetLimit is called.
+func (OSSClient OSSClient) RateLimiter() *RateLimiter {
+	return OSSClient.conf.rateLimiter
+}
+
+type extensionRateLimiter func() *RateLimiter
+
+// WithRateLimiter limits the bandwidth of the request with limiter, in addition to the limiter of the client.
+//
+// When it is passed to UploadFile, DownloadFile or the other transfers, the limiter is shared by all the
+// part requests of the transfer, so the bandwidth of the whole transfer is limited.
+func WithRateLimiter(limiter *RateLimiter) extensionRateLimiter {
+	return func() *RateLimiter {
+		return limiter
+	}
+}
+
+type rateLimitersKey struct{}
+
+// withRateLimiters returns a copy of ctx carrying the rate limiters set by extensions.
+func withRateLimiters(ctx context.Context, extensions []extensionOptions) context.Context {
+	var limiters []*RateLimiter
+	for _, extension := range extensions {
+		if rateLimiter, ok := extension.(extensionRateLimiter); ok {
+			if limiter := rateLimiter(); limiter != nil {
+				limiters = append(limiters, limiter)
+			}
+		}
+	}
+	if len(limiters) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, rateLimitersKey{}, limiters)
+}
+
+// getRateLimiters returns the limiter of the client and the limiters carried by ctx.
+func (OSSClient OSSClient) getRateLimiters(ctx context.Context) []*RateLimiter {
+	limiters, _ := ctx.Value(rateLimitersKey{}).([]*RateLimiter)
+	if OSSClient.conf.rateLimiter != nil {
+		limiters = append([]*RateLimiter{OSSClient.conf.rateLimiter}, limiters...)
+	}
+	return limiters
+}
+
+// rateLimitedReadCloser limits the bandwidth of reading from a body with all of its limiters.
+type rateLimitedReadCloser struct {
+	io.ReadCloser
+	ctx      context.Context
+	limiters []*RateLimiter
+}
+
+// isLimited reports whether any of limiters limits the bandwidth.
+func isLimited(limiters []*RateLimiter) bool {
+	for _, limiter := range limiters {
+		if limiter.Limit() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// newRateLimitedReadCloser wraps body even if none of limiters has a limit yet, so that a limit set later
+// applies to the rest of the body.
+func newRateLimitedReadCloser(ctx context.Context, body io.ReadCloser, limiters []*RateLimiter) io.ReadCloser {
+	if body == nil || body == http.NoBody || len(limiters) == 0 {
+		return body
+	}
+	return &rateLimitedReadCloser{ReadCloser: body, ctx: ctx, limiters: limiters}
+}
+
+func (rrc *rateLimitedReadCloser) Read(p []byte) (n int, err error) {
+	if !isLimited(rrc.limiters) {
+		return rrc.ReadCloser.Read(p)
+	}
+	for _, limiter := range rrc.limiters {
+		if size := limiter.chunkSize(); len(p) > size {
+			p = p[:size]
+		}
+	}
+	n, err = rrc.ReadCloser.Read(p)
+	if n > 0 {
+		for _, limiter := range rrc.limiters {
+			if _err := limiter.wait(rrc.ctx, n); _err != nil {
+				return n, _err
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedBodyWithoutLimit(t *testing.T) {
+	body := io.NopCloser(bytes.NewReader([]byte("data")))
+	if limited := newRateLimitedReadCloser(context.Background(), body, nil); limited != body {
+		t.Fatal("expected the body not to be wrapped without a limiter")
+	}
+	limited := newRateLimitedReadCloser(context.Background(), body, []*RateLimiter{NewRateLimiter(0), NewRateLimiter(-1)})
+	if limited == body {
+		t.Fatal("expected the body to be wrapped with the limiters")
+	}
+	if data, err := io.ReadAll(limited); err != nil || string(data) != "data" {
+		t.Fatalf("unexpected body %q, err: %v", data, err)
+	}
+}
+
+func TestRateLimiterSharedByRequests(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	limiter := NewRateLimiter(400 * 1024)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			input := &PutObjectInput{}
+			input.Bucket, input.Key = "bucket", key
+			input.Body = bytes.NewReader(make([]byte, 50*1024))
+			if _, err := client.PutObject(input, WithRateLimiter(limiter)); err != nil {
+				t.Errorf("PutObject failed: %v", err)
+			}
+		}(key)
+	}
+	wg.Wait()
+	// 100KB at 400KB/s from an empty bucket
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("the bandwidth is not limited, elapsed: %v", elapsed)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 100*1024)
+
+	download := func() time.Duration {
+		start := time.Now()
+		input := &GetObjectInput{}
+		input.Bucket, input.Key = "bucket", "key"
+		output, err := client.GetObject(input)
+		if err != nil {
+			t.Fatalf("GetObject failed: %v", err)
+		}
+		defer output.Body.Close()
+		if _, err := io.Copy(io.Discard, output.Body); err != nil {
+			t.Fatal(err)
+		}
+		return time.Since(start)
+	}
+
+	if elapsed := download(); elapsed > 200*time.Millisecond {
+		t.Fatalf("unexpected limit, elapsed: %v", elapsed)
+	}
+	client.RateLimiter().SetLimit(400 * 1024)
+	if elapsed := download(); elapsed < 200*time.Millisecond {
+		t.Fatalf("the bandwidth is not limited, elapsed: %v", elapsed)
+	}
+}
+
+func TestRateLimitSetDuringDownload(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 200*1024)
+
+	input := &GetObjectInput{}
+	input.Bucket, input.Key = "bucket", "key"
+	output, err := client.GetObject(input)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	defer output.Body.Close()
+	if _, err := io.CopyN(io.Discard, output.Body, 100*1024); err != nil {
+		t.Fatal(err)
+	}
+
+	client.RateLimiter().SetLimit(400 * 1024)
+	defer client.RateLimiter().SetLimit(0)
+	start := time.Now()
+	if _, err := io.Copy(io.Discard, output.Body); err != nil {
+		t.Fatal(err)
+	}
+	// the rest 100KB at 400KB/s from an empty bucket
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("the limit set during the download is not applied, elapsed: %v", elapsed)
+	}
+}
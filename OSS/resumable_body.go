@@ -0,0 +1,141 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type extensionResumableBody func() bool
+
+// WithResumableBody makes the body of a GetObject response resume after a network error while it is read.
+//
+// The body reissues a ranged GET from the last byte read, up to the max retry count of the client.
+// The ranged GET is sent with If-Match of the ETag of the first response, so the data of two
+// different versions of the object is never stitched together.
+func WithResumableBody() extensionResumableBody {
+	return func() bool {
+		return true
+	}
+}
+
+func isResumableBody(extensions []extensionOptions) bool {
+	for _, extension := range extensions {
+		if resumableBody, ok := extension.(extensionResumableBody); ok && resumableBody() {
+			return true
+		}
+	}
+	return false
+}
+
+type resumableBody struct {
+	io.ReadCloser
+	OSSClient  OSSClient
+	ctx        context.Context
+	bucketName string
+	objectKey  string
+	params     map[string]string
+	headers    map[string][]string
+	etag       string
+	rangeStart int64
+	rangeEnd   string
+	readBytes  int64
+	retryCount int
+	err        error
+}
+
+// newResumableBody returns the body of resp, which resumes with the request described by params and headers.
+// The body is not resumable if the response has no ETag.
+func newResumableBody(OSSClient OSSClient, ctx context.Context, bucketName, objectKey string, params map[string]string,
+	headers map[string][]string, resp *http.Response) io.ReadCloser {
+	etag := resp.Header.Get(HEADER_ETAG)
+	if etag == "" {
+		doLog(LEVEL_WARN, "The response has no ETag, the body can not be resumed.")
+		return resp.Body
+	}
+
+	rb := &resumableBody{
+		ReadCloser: resp.Body,
+		OSSClient:  OSSClient,
+		ctx:        ctx,
+		bucketName: bucketName,
+		objectKey:  objectKey,
+		params:     params,
+		headers:    make(map[string][]string, len(headers)+2),
+		etag:       etag,
+	}
+	for key, value := range headers {
+		rb.headers[key] = value
+	}
+	if value, ok := headers[HEADER_RANGE]; ok && len(value) > 0 {
+		var rangeSpec string
+		if _, err := fmt.Sscanf(value[0], "bytes=%s", &rangeSpec); err == nil {
+			if index := strings.Index(rangeSpec, "-"); index > 0 {
+				rb.rangeStart = StringToInt64(rangeSpec[:index], 0)
+				rb.rangeEnd = rangeSpec[index+1:]
+			}
+		}
+	}
+	return rb
+}
+
+func (rb *resumableBody) Read(p []byte) (n int, err error) {
+	if rb.err != nil {
+		return 0, rb.err
+	}
+	for {
+		n, err = rb.ReadCloser.Read(p)
+		rb.readBytes += int64(n)
+		if err == nil || err == io.EOF {
+			return
+		}
+		if rb.ctx.Err() != nil || rb.retryCount >= rb.OSSClient.conf.maxRetryCount {
+			rb.err = err
+			return
+		}
+		rb.retryCount++
+		doLog(LEVEL_WARN, "Failed to read response body with reason:%v, will resume from byte [%d]", err, rb.rangeStart+rb.readBytes)
+		if _err := rb.resume(); _err != nil {
+			doLog(LEVEL_WARN, "Failed to resume response body with error [%v].", _err)
+			rb.err = _err
+			return n, _err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (rb *resumableBody) resume() error {
+	if errMsg := rb.ReadCloser.Close(); errMsg != nil {
+		doLog(LEVEL_WARN, "Failed to close response body.")
+	}
+	rb.headers[HEADER_RANGE] = []string{fmt.Sprintf("bytes=%d-%s", rb.rangeStart+rb.readBytes, rb.rangeEnd)}
+	rb.headers[HEADER_IF_MATCH] = []string{rb.etag}
+	resp, err := rb.OSSClient.doHTTPGet(rb.ctx, rb.bucketName, rb.objectKey, rb.params, rb.headers, nil, true)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		if errMsg := resp.Body.Close(); errMsg != nil {
+			doLog(LEVEL_WARN, "Failed to close response body.")
+		}
+		return fmt.Errorf("unexpected status code [%d] when resuming response body", resp.StatusCode)
+	}
+	rb.ReadCloser = resp.Body
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// cutBodies makes the server drop the connection after half of the body of the first count GET requests.
+func cutBodies(fs *fakeServer, count int32) {
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodGet || atomic.AddInt32(&count, -1) < 0 {
+			return false
+		}
+		object, _ := fs.getObject("bucket", "key")
+		start := int64(0)
+		status := "200 OK"
+		if _, err := fmt.Sscanf(r.Header.Get(HEADER_RANGE), "bytes=%d-", &start); err == nil {
+			status = "206 Partial Content"
+		}
+		data := object.data[start:]
+		conn, buffer, _ := w.(http.Hijacker).Hijack()
+		fmt.Fprintf(buffer, "HTTP/1.1 %s\r\nContent-Length: %d\r\nETag: %s\r\n\r\n", status, len(data), object.etag)
+		buffer.Write(data[:len(data)/2])
+		buffer.Flush()
+		conn.Close()
+		return true
+	})
+}
+
+func TestResumableBody(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := newTestObject(fs, 100000)
+	cutBodies(fs, 2)
+
+	input := &GetObjectInput{}
+	input.Bucket, input.Key = "bucket", "key"
+	output, err := client.GetObject(input, WithResumableBody())
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	read, err := io.ReadAll(output.Body)
+	output.Body.Close()
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("unexpected body of %d bytes, err: %v", len(read), err)
+	}
+	resumed := fs.countRequests(func(r *http.Request) bool {
+		return r.Header.Get(HEADER_RANGE) != "" && r.Header.Get("If-Match") != ""
+	})
+	if resumed != 2 {
+		t.Fatalf("expected 2 ranged requests pinned to the ETag, got %d", resumed)
+	}
+}
+
+func TestBodyWithoutResume(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 100000)
+	cutBodies(fs, 1)
+
+	input := &GetObjectInput{}
+	input.Bucket, input.Key = "bucket", "key"
+	output, err := client.GetObject(input)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	_, err = io.ReadAll(output.Body)
+	output.Body.Close()
+	if err == nil {
+		t.Fatal("expected the read error of the body")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether a failed attempt of a request is retried, and how long to wait before the retry.
+//
+// attempt starts from 0, and err is an OSSError if the service returns an error response, or the error
+// of the network otherwise. A request is never retried more than the max retry count of the client,
+// or if its body can not be sent again. The methods may be called concurrently.
+type RetryPolicy interface {
+	ShouldRetry(attempt int, err error) bool
+	RetryDelay(attempt int, err error) time.Duration
+}
+
+// DefaultRetryPolicy retries the transient network errors, the server errors, the timeouts and the throttling
+// of the service, with capped exponential backoff and full jitter: the delay before the retry of attempt n
+// is random between 0 and the minimum of MaxDelay and BaseDelay * 2^n. The Retry-After header of an error
+// response is used as the delay if it is present, but no longer than MaxDelay. The errors which would fail
+// again, such as certificate errors, unknown hosts and invalid URLs, are not retried.
+type DefaultRetryPolicy struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// NewDefaultRetryPolicy creates a DefaultRetryPolicy instance
+func NewDefaultRetryPolicy() *DefaultRetryPolicy {
+	return &DefaultRetryPolicy{BaseDelay: DEFAULT_RETRY_BASE_DELAY, MaxDelay: DEFAULT_RETRY_MAX_DELAY}
+}
+
+// retryableErrorCodes are the error codes retried whatever their status codes are
+var retryableErrorCodes = map[string]bool{
+	"RequestTimeout":          true,
+	"RequestTimeoutException": true,
+	"InternalError":           true,
+	"ServiceUnavailable":      true,
+	"SlowDown":                true,
+	"Throttling":              true,
+	"ThrottlingException":     true,
+	"TooManyRequests":         true,
+	"RequestLimitExceeded":    true,
+}
+
+// ShouldRetry implements RetryPolicy.
+func (policy *DefaultRetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	ossError, ok := err.(OSSError)
+	if !ok {
+		return isRetryableNetworkError(err)
+	}
+	if retryableErrorCodes[ossError.Code] {
+		return true
+	}
+	switch ossError.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return ossError.StatusCode >= 500
+}
+
+// isRetryableNetworkError reports whether the error of sending a request is transient.
+func isRetryableNetworkError(err error) bool {
+	var urlError *url.Error
+	if errors.As(err, &urlError) && urlError.Op == "parse" {
+		return false
+	}
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) && dnsError.IsNotFound {
+		return false
+	}
+	var unknownAuthorityError x509.UnknownAuthorityError
+	var certificateInvalidError x509.CertificateInvalidError
+	var hostnameError x509.HostnameError
+	var recordHeaderError tls.RecordHeaderError
+	if errors.As(err, &unknownAuthorityError) || errors.As(err, &certificateInvalidError) ||
+		errors.As(err, &hostnameError) || errors.As(err, &recordHeaderError) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netError net.Error
+	return errors.As(err, &netError) || errors.As(err, &urlError)
+}
+
+// RetryDelay implements RetryPolicy.
+func (policy *DefaultRetryPolicy) RetryDelay(attempt int, err error) time.Duration {
+	maxDelay := policy.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DEFAULT_RETRY_MAX_DELAY
+	}
+	if delay, ok := getRetryAfter(err); ok {
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+		return delay
+	}
+	delay := policy.BaseDelay
+	if delay <= 0 {
+		delay = DEFAULT_RETRY_BASE_DELAY
+	}
+	for i := 0; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// getRetryAfter returns the delay of the Retry-After header of an error response, in seconds or an HTTP date.
+func getRetryAfter(err error) (time.Duration, bool) {
+	ossError, ok := err.(OSSError)
+	if !ok {
+		return 0, false
+	}
+	values, ok := ossError.ResponseHeaders[HEADER_RETRY_AFTER]
+	if !ok || len(values) == 0 {
+		return 0, false
+	}
+	value := strings.TrimSpace(values[0])
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDefaultRetryPolicyShouldRetry(t *testing.T) {
+	policy := NewDefaultRetryPolicy()
+	ossError := func(statusCode int, code string) error {
+		return OSSError{BaseModel: BaseModel{StatusCode: statusCode}, Code: code}
+	}
+	cases := []struct {
+		err   error
+		retry bool
+	}{
+		{ossError(http.StatusServiceUnavailable, ""), true},
+		{ossError(http.StatusTooManyRequests, ""), true},
+		{ossError(http.StatusBadRequest, "SlowDown"), true},
+		{ossError(http.StatusForbidden, "AccessDenied"), false},
+		{ossError(http.StatusNotImplemented, ""), false},
+		{&url.Error{Op: "Get", URL: "http://host", Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}, true},
+		{&url.Error{Op: "Put", URL: "http://host", Err: io.ErrUnexpectedEOF}, true},
+		{fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true},
+		{&url.Error{Op: "parse", URL: "::", Err: errors.New("missing protocol scheme")}, false},
+		{&url.Error{Op: "Get", URL: "http://host", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "host", IsNotFound: true}}}, false},
+		{&url.Error{Op: "Get", URL: "https://host", Err: x509.UnknownAuthorityError{}}, false},
+		{&url.Error{Op: "Get", URL: "https://host", Err: x509.HostnameError{Host: "host"}}, false},
+		{&url.Error{Op: "Get", URL: "http://host", Err: context.Canceled}, false},
+		{fmt.Errorf("read body: %w", context.DeadlineExceeded), false},
+		{errors.New("unknown"), false},
+	}
+	for _, c := range cases {
+		if retry := policy.ShouldRetry(0, c.err); retry != c.retry {
+			t.Errorf("ShouldRetry(%v) = %v", c.err, retry)
+		}
+	}
+}
+
+func TestDefaultRetryPolicyRetryDelay(t *testing.T) {
+	policy := &DefaultRetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		bound := 10 * time.Millisecond << uint(attempt)
+		if bound > policy.MaxDelay {
+			bound = policy.MaxDelay
+		}
+		if delay := policy.RetryDelay(attempt, errors.New("error")); delay < 0 || delay > bound {
+			t.Fatalf("unexpected delay %v of attempt %d", delay, attempt)
+		}
+	}
+
+	retryAfter := func(value string) error {
+		return OSSError{BaseModel: BaseModel{StatusCode: http.StatusTooManyRequests,
+			ResponseHeaders: map[string][]string{HEADER_RETRY_AFTER: {value}}}}
+	}
+	if delay := policy.RetryDelay(0, retryAfter("0")); delay != 0 {
+		t.Fatalf("unexpected delay %v", delay)
+	}
+	if delay := policy.RetryDelay(0, retryAfter("3600")); delay != policy.MaxDelay {
+		t.Fatalf("expected Retry-After to be capped at MaxDelay, got %v", delay)
+	}
+	if delay := policy.RetryDelay(0, retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))); delay != policy.MaxDelay {
+		t.Fatalf("expected the Retry-After date to be capped at MaxDelay, got %v", delay)
+	}
+}
+
+func TestRetryTooManyRequests(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.putObject("bucket", "key", []byte("data"))
+	var throttled int32
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if atomic.AddInt32(&throttled, 1) > 2 {
+			return false
+		}
+		// the delay is capped at the MaxDelay of the test client
+		w.Header().Set("Retry-After", "3600")
+		writeError(w, http.StatusTooManyRequests, "TooManyRequests")
+		return true
+	})
+
+	start := time.Now()
+	input := &GetObjectMetadataInput{Bucket: "bucket", Key: "key"}
+	if _, err := client.GetObjectMetadata(input); err != nil {
+		t.Fatalf("GetObjectMetadata failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("the Retry-After delay is not capped, elapsed: %v", elapsed)
+	}
+	if requests := fs.countRequests(func(r *http.Request) bool { return true }); requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests)
+	}
+}
+
+func TestRetryNonRetryableResponse(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return true
+	})
+
+	input := &GetObjectMetadataInput{Bucket: "bucket", Key: "key"}
+	if _, err := client.GetObjectMetadata(input); err == nil {
+		t.Fatal("expected GetObjectMetadata to fail")
+	}
+	if requests := fs.countRequests(func(r *http.Request) bool { return true }); requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+}
+
+// recordingRetryPolicy records the decisions of DefaultRetryPolicy.
+type recordingRetryPolicy struct {
+	DefaultRetryPolicy
+	lock      sync.Mutex
+	decisions []bool
+}
+
+func (policy *recordingRetryPolicy) ShouldRetry(attempt int, err error) bool {
+	retry := policy.DefaultRetryPolicy.ShouldRetry(attempt, err)
+	policy.lock.Lock()
+	defer policy.lock.Unlock()
+	policy.decisions = append(policy.decisions, retry)
+	return retry
+}
+
+func TestRetryCertificateError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	policy := &recordingRetryPolicy{DefaultRetryPolicy: DefaultRetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}}
+	client, err := New("ak", "sk", server.URL, WithSslVerify(true), WithMaxRetryCount(3), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	input := &GetObjectMetadataInput{Bucket: "bucket", Key: "key"}
+	if _, err := client.GetObjectMetadata(input); err == nil {
+		t.Fatal("expected the certificate error")
+	}
+	if len(policy.decisions) != 1 || policy.decisions[0] {
+		t.Fatalf("expected the certificate error not to be retried, got %v", policy.decisions)
+	}
+}
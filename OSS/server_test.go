@@ -0,0 +1,555 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeObject struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+	metadata     map[string]string
+}
+
+type fakeVersion struct {
+	key          string
+	versionID    string
+	deleteMarker bool
+}
+
+type fakeUpload struct {
+	bucket    string
+	key       string
+	initiated time.Time
+	parts     map[int][]byte
+}
+
+// fakeServer is an in-memory service speaking the path-style S3 protocol used by the client, it
+// implements the operations used by the transfer APIs.
+type fakeServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	objects  map[string]*fakeObject
+	versions map[string][]fakeVersion
+	uploads  map[string]*fakeUpload
+	deleted  map[string]bool
+	nextID   int
+	requests []*http.Request
+	// hook is called before a request is served, the request is not served if it returns true.
+	hook func(w http.ResponseWriter, r *http.Request) bool
+	// omitVersionMarkers drops NextKeyMarker and NextVersionIdMarker from truncated version listings.
+	omitVersionMarkers bool
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	fs := &fakeServer{
+		objects:  make(map[string]*fakeObject),
+		versions: make(map[string][]fakeVersion),
+		uploads:  make(map[string]*fakeUpload),
+		deleted:  make(map[string]bool),
+	}
+	fs.Server = httptest.NewServer(http.HandlerFunc(fs.serveHTTP))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+// newTestClient returns a client of the server which retries without sleeping long.
+func newTestClient(t *testing.T, fs *fakeServer, configurers ...configurer) *OSSClient {
+	configurers = append([]configurer{
+		WithMaxRetryCount(3),
+		WithRetryPolicy(&DefaultRetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}),
+	}, configurers...)
+	client, err := New("ak", "sk", fs.URL, configurers...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return client
+}
+
+func (fs *fakeServer) setHook(hook func(w http.ResponseWriter, r *http.Request) bool) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.hook = hook
+}
+
+func (fs *fakeServer) putObject(bucket, key string, data []byte) *fakeObject {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.storeObject(bucket, key, data, nil)
+}
+
+func (fs *fakeServer) getObject(bucket, key string) (*fakeObject, bool) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	object, ok := fs.objects[bucket+"/"+key]
+	return object, ok
+}
+
+func (fs *fakeServer) objectKeys(bucket string) []string {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	var keys []string
+	for name := range fs.objects {
+		if strings.HasPrefix(name, bucket+"/") {
+			keys = append(keys, strings.TrimPrefix(name, bucket+"/"))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (fs *fakeServer) addVersion(bucket, key, versionID string, deleteMarker bool) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.versions[bucket] = append(fs.versions[bucket], fakeVersion{key: key, versionID: versionID, deleteMarker: deleteMarker})
+	sort.Slice(fs.versions[bucket], func(i, j int) bool {
+		a, b := fs.versions[bucket][i], fs.versions[bucket][j]
+		return a.key < b.key || a.key == b.key && a.versionID < b.versionID
+	})
+}
+
+func (fs *fakeServer) addUpload(bucket, key string, initiated time.Time) string {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.newUpload(bucket, key, initiated)
+}
+
+func (fs *fakeServer) uploadCount() int {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return len(fs.uploads)
+}
+
+// countRequests returns the number of the requests served for which match returns true.
+func (fs *fakeServer) countRequests(match func(r *http.Request) bool) int {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	count := 0
+	for _, r := range fs.requests {
+		if match(r) {
+			count++
+		}
+	}
+	return count
+}
+
+func (fs *fakeServer) storeObject(bucket, key string, data []byte, metadata map[string]string) *fakeObject {
+	sum := md5.Sum(data)
+	object := &fakeObject{
+		data:         data,
+		etag:         "\"" + hex.EncodeToString(sum[:]) + "\"",
+		lastModified: time.Now().UTC().Truncate(time.Second),
+		metadata:     metadata,
+	}
+	fs.objects[bucket+"/"+key] = object
+	return object
+}
+
+func (fs *fakeServer) newUpload(bucket, key string, initiated time.Time) string {
+	fs.nextID++
+	uploadID := fmt.Sprintf("upload%04d", fs.nextID)
+	fs.uploads[uploadID] = &fakeUpload{bucket: bucket, key: key, initiated: initiated, parts: make(map[int][]byte)}
+	return uploadID
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message><RequestId>test</RequestId></Error>", code, code)
+}
+
+func writeXML(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	data, err := xml.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	w.Write(data)
+}
+
+func metadataOf(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for name, values := range header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, HEADER_PREFIX_META) {
+			metadata[name] = values[0]
+		}
+	}
+	return metadata
+}
+
+func (fs *fakeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	fs.lock.Lock()
+	fs.requests = append(fs.requests, r)
+	hook := fs.hook
+	fs.lock.Unlock()
+	if hook != nil && hook(w, r) {
+		return
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	w.Header().Set("x-amz-request-id", "test")
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key := path, ""
+	if index := strings.Index(path, "/"); index >= 0 {
+		bucket, key = path[:index], path[index+1:]
+	}
+	query := r.URL.Query()
+	if key == "" {
+		fs.serveBucket(w, r, bucket, query)
+		return
+	}
+	name := bucket + "/" + key
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		writeXML(w, InitiateMultipartUploadOutput{Bucket: bucket, Key: key, UploadId: fs.newUpload(bucket, key, time.Now())})
+	case query.Has("uploadId"):
+		fs.serveUpload(w, r, bucket, key, query)
+	case query.Has("acl") && r.Method == http.MethodGet:
+		writeXML(w, AccessControlPolicy{Owner: Owner{ID: "owner"}})
+	case query.Has("acl") && r.Method == http.MethodPut:
+		io.Copy(io.Discard, r.Body)
+	case r.Method == http.MethodPut && r.Header.Get(HEADER_PREFIX+HEADER_COPY_SOURCE) != "":
+		source, ok := fs.copySource(r)
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		metadata := source.metadata
+		if r.Header.Get(HEADER_PREFIX+HEADER_METADATA_DIRECTIVE) == string(ReplaceMetadata) {
+			metadata = metadataOf(r.Header)
+		}
+		object := fs.storeObject(bucket, key, source.data, metadata)
+		writeXML(w, CopyObjectOutput{ETag: object.etag, LastModified: object.lastModified})
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		object := fs.storeObject(bucket, key, data, metadataOf(r.Header))
+		w.Header().Set("ETag", object.etag)
+	case r.Method == http.MethodDelete:
+		if versionID := query.Get("versionId"); versionID != "" {
+			fs.deleteVersion(bucket, key, versionID)
+		} else {
+			delete(fs.objects, name)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := fs.objects[name]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", object.etag)
+		for name, value := range object.metadata {
+			w.Header().Set(name, value)
+		}
+		http.ServeContent(w, r, "", object.lastModified, bytes.NewReader(object.data))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (fs *fakeServer) copySource(r *http.Request) (*fakeObject, bool) {
+	source, err := url.PathUnescape(strings.TrimPrefix(r.Header.Get(HEADER_PREFIX+HEADER_COPY_SOURCE), "/"))
+	if err != nil {
+		return nil, false
+	}
+	if index := strings.Index(source, "?versionId="); index >= 0 {
+		source = source[:index]
+	}
+	object, ok := fs.objects[source]
+	return object, ok
+}
+
+func (fs *fakeServer) deleteVersion(bucket, key, versionID string) {
+	versions := fs.versions[bucket][:0]
+	for _, version := range fs.versions[bucket] {
+		if version.key != key || version.versionID != versionID {
+			versions = append(versions, version)
+		}
+	}
+	fs.versions[bucket] = versions
+}
+
+func (fs *fakeServer) serveUpload(w http.ResponseWriter, r *http.Request, bucket, key string, query url.Values) {
+	upload, ok := fs.uploads[query.Get("uploadId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		if r.Header.Get(HEADER_PREFIX+HEADER_COPY_SOURCE) != "" {
+			source, ok := fs.copySource(r)
+			if !ok {
+				writeError(w, http.StatusNotFound, "NoSuchKey")
+				return
+			}
+			data := source.data
+			var start, end int
+			if _, err := fmt.Sscanf(r.Header.Get(HEADER_PREFIX+HEADER_COPY_SOURCE_RANGE), "bytes=%d-%d", &start, &end); err == nil {
+				data = data[start : end+1]
+			}
+			upload.parts[partNumber] = data
+			sum := md5.Sum(data)
+			writeXML(w, CopyPartOutput{ETag: "\"" + hex.EncodeToString(sum[:]) + "\""})
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		upload.parts[partNumber] = data
+		sum := md5.Sum(data)
+		w.Header().Set("ETag", "\""+hex.EncodeToString(sum[:])+"\"")
+	case http.MethodPost:
+		var input CompleteMultipartUploadInput
+		body, _ := io.ReadAll(r.Body)
+		if err := xml.Unmarshal(body, &input); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var data, sums []byte
+		for _, part := range input.Parts {
+			partData, ok := upload.parts[part.PartNumber]
+			if !ok {
+				writeError(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			data = append(data, partData...)
+			sum := md5.Sum(partData)
+			sums = append(sums, sum[:]...)
+		}
+		object := fs.storeObject(bucket, key, data, nil)
+		sum := md5.Sum(sums)
+		object.etag = fmt.Sprintf("\"%s-%d\"", hex.EncodeToString(sum[:]), len(input.Parts))
+		delete(fs.uploads, query.Get("uploadId"))
+		writeXML(w, CompleteMultipartUploadOutput{Bucket: bucket, Key: key, ETag: object.etag})
+	case http.MethodDelete:
+		delete(fs.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		output := ListPartsOutput{Bucket: bucket, Key: key, UploadId: query.Get("uploadId")}
+		var partNumbers []int
+		for partNumber := range upload.parts {
+			partNumbers = append(partNumbers, partNumber)
+		}
+		sort.Ints(partNumbers)
+		marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+		maxParts := intParam(query, "max-parts", 1000)
+		for _, partNumber := range partNumbers {
+			if partNumber <= marker {
+				continue
+			}
+			if len(output.Parts) == maxParts {
+				output.IsTruncated = true
+				break
+			}
+			sum := md5.Sum(upload.parts[partNumber])
+			output.Parts = append(output.Parts, Part{PartNumber: partNumber, ETag: "\"" + hex.EncodeToString(sum[:]) + "\"",
+				Size: int64(len(upload.parts[partNumber]))})
+			output.NextPartNumberMarker = partNumber
+		}
+		writeXML(w, output)
+	}
+}
+
+func intParam(query url.Values, name string, defaultValue int) int {
+	if value, err := strconv.Atoi(query.Get(name)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+func (fs *fakeServer) serveBucket(w http.ResponseWriter, r *http.Request, bucket string, query url.Values) {
+	switch {
+	case r.Method == http.MethodHead:
+		if fs.deleted[bucket] {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodDelete:
+		for name := range fs.objects {
+			if strings.HasPrefix(name, bucket+"/") {
+				writeError(w, http.StatusConflict, "BucketNotEmpty")
+				return
+			}
+		}
+		if len(fs.versions[bucket]) > 0 {
+			writeError(w, http.StatusConflict, "BucketNotEmpty")
+			return
+		}
+		fs.deleted[bucket] = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		io.Copy(io.Discard, r.Body)
+	case r.Method == http.MethodPost && query.Has("delete"):
+		fs.serveDeleteObjects(w, r, bucket)
+	case r.Method == http.MethodGet && query.Has("versions"):
+		fs.serveListVersions(w, bucket, query)
+	case r.Method == http.MethodGet && query.Has("uploads"):
+		fs.serveListUploads(w, bucket, query)
+	case r.Method == http.MethodGet:
+		fs.serveListObjects(w, bucket, query)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (fs *fakeServer) serveDeleteObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	var input DeleteObjectsInput
+	body, _ := io.ReadAll(r.Body)
+	if err := xml.Unmarshal(body, &input); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	output := DeleteObjectsOutput{}
+	for _, object := range input.Objects {
+		if object.VersionId != "" {
+			fs.deleteVersion(bucket, object.Key, object.VersionId)
+		} else {
+			delete(fs.objects, bucket+"/"+object.Key)
+		}
+		if !input.Quiet {
+			output.Deleteds = append(output.Deleteds, Deleted{Key: object.Key, VersionId: object.VersionId})
+		}
+	}
+	writeXML(w, output)
+}
+
+func (fs *fakeServer) serveListObjects(w http.ResponseWriter, bucket string, query url.Values) {
+	prefix, delimiter, marker := query.Get("prefix"), query.Get("delimiter"), query.Get("marker")
+	maxKeys := intParam(query, "max-keys", 1000)
+	var names []string
+	for name := range fs.objects {
+		if strings.HasPrefix(name, bucket+"/"+prefix) {
+			names = append(names, strings.TrimPrefix(name, bucket+"/"))
+		}
+	}
+	sort.Strings(names)
+	output := ListObjectsOutput{Name: bucket, Prefix: prefix, Marker: marker, Delimiter: delimiter, MaxKeys: maxKeys}
+	seen := make(map[string]bool)
+	last := ""
+	for _, name := range names {
+		entry := name
+		if delimiter != "" {
+			if index := strings.Index(name[len(prefix):], delimiter); index >= 0 {
+				entry = name[:len(prefix)+index+len(delimiter)]
+			}
+		}
+		if entry <= marker || seen[entry] {
+			continue
+		}
+		if len(output.Contents)+len(output.CommonPrefixes) == maxKeys {
+			output.IsTruncated = true
+			break
+		}
+		seen[entry] = true
+		last = entry
+		if entry != name {
+			output.CommonPrefixes = append(output.CommonPrefixes, entry)
+			continue
+		}
+		object := fs.objects[bucket+"/"+name]
+		output.Contents = append(output.Contents, Content{Key: name, ETag: object.etag, Size: int64(len(object.data)),
+			LastModified: object.lastModified})
+	}
+	if output.IsTruncated && delimiter != "" {
+		output.NextMarker = last
+	}
+	writeXML(w, output)
+}
+
+func (fs *fakeServer) serveListVersions(w http.ResponseWriter, bucket string, query url.Values) {
+	prefix, keyMarker, versionIDMarker := query.Get("prefix"), query.Get("key-marker"), query.Get("version-id-marker")
+	maxKeys := intParam(query, "max-keys", 1000)
+	output := ListVersionsOutput{Name: bucket, Prefix: prefix, KeyMarker: keyMarker, VersionIdMarker: versionIDMarker}
+	count := 0
+	// the versions of a key are listed from the newest to the oldest
+	lastModified := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, version := range fs.versions[bucket] {
+		lastModified = lastModified.Add(-time.Second)
+		if !strings.HasPrefix(version.key, prefix) {
+			continue
+		}
+		if version.key < keyMarker || version.key == keyMarker && (versionIDMarker == "" || version.versionID <= versionIDMarker) {
+			continue
+		}
+		if count == maxKeys {
+			output.IsTruncated = true
+			break
+		}
+		count++
+		if version.deleteMarker {
+			output.DeleteMarkers = append(output.DeleteMarkers, DeleteMarker{Key: version.key, VersionId: version.versionID, LastModified: lastModified})
+		} else {
+			output.Versions = append(output.Versions, Version{DeleteMarker: DeleteMarker{Key: version.key, VersionId: version.versionID,
+				LastModified: lastModified}})
+		}
+		output.NextKeyMarker, output.NextVersionIdMarker = version.key, version.versionID
+	}
+	if !output.IsTruncated || fs.omitVersionMarkers {
+		output.NextKeyMarker, output.NextVersionIdMarker = "", ""
+	}
+	writeXML(w, output)
+}
+
+func (fs *fakeServer) serveListUploads(w http.ResponseWriter, bucket string, query url.Values) {
+	keyMarker, uploadIDMarker := query.Get("key-marker"), query.Get("upload-id-marker")
+	maxUploads := intParam(query, "max-uploads", 1000)
+	var uploadIDs []string
+	for uploadID, upload := range fs.uploads {
+		if upload.bucket == bucket && strings.HasPrefix(upload.key, query.Get("prefix")) {
+			uploadIDs = append(uploadIDs, uploadID)
+		}
+	}
+	sort.Slice(uploadIDs, func(i, j int) bool {
+		a, b := fs.uploads[uploadIDs[i]], fs.uploads[uploadIDs[j]]
+		return a.key < b.key || a.key == b.key && uploadIDs[i] < uploadIDs[j]
+	})
+	output := ListMultipartUploadsOutput{Bucket: bucket, KeyMarker: keyMarker, UploadIdMarker: uploadIDMarker}
+	for _, uploadID := range uploadIDs {
+		upload := fs.uploads[uploadID]
+		if upload.key < keyMarker || upload.key == keyMarker && (uploadIDMarker == "" || uploadID <= uploadIDMarker) {
+			continue
+		}
+		if len(output.Uploads) == maxUploads {
+			output.IsTruncated = true
+			break
+		}
+		output.Uploads = append(output.Uploads, Upload{Key: upload.key, UploadId: uploadID, Initiated: upload.initiated})
+		output.NextKeyMarker, output.NextUploadIdMarker = upload.key, uploadID
+	}
+	if !output.IsTruncated {
+		output.NextKeyMarker, output.NextUploadIdMarker = "", ""
+	}
+	writeXML(w, output)
+}
@@ -0,0 +1,183 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// The keys of the attributes of the spans
+const (
+	ATTRIBUTE_OPERATION      = "oss.operation"
+	ATTRIBUTE_BUCKET         = "oss.bucket"
+	ATTRIBUTE_KEY            = "oss.key"
+	ATTRIBUTE_PART_NUMBER    = "oss.part_number"
+	ATTRIBUTE_ATTEMPT        = "oss.attempt"
+	ATTRIBUTE_RETRY_COUNT    = "oss.retry_count"
+	ATTRIBUTE_REQUEST_ID     = "oss.request_id"
+	ATTRIBUTE_ERROR_CODE     = "oss.error_code"
+	ATTRIBUTE_BYTES_SENT     = "oss.bytes_sent"
+	ATTRIBUTE_BYTES_RECEIVED = "oss.bytes_received"
+	ATTRIBUTE_HTTP_METHOD    = "http.request.method"
+	ATTRIBUTE_HTTP_STATUS    = "http.response.status_code"
+)
+
+// Attribute is an attribute of a span, Value is a string, an int, an int64 or a bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts the spans of the client, it has the shape of the Tracer of OpenTelemetry, and the
+// module github.com/dangcingzzw/inspur-go-sdk/OSS/otel adapts OpenTelemetry to it, so that this SDK
+// does not depend on OpenTelemetry.
+//
+// The client starts a span for each API call, named after the API, with a child span for each HTTP
+// attempt. The status code, the request id and the retry count of the last attempt are set on the span
+// of the API call. The calls made by UploadFile, DownloadFile, CopyFile and the other APIs built upon the
+// basic ones are children of their spans, so each part has its own span. The span of StartUpload and
+// StartDownload ends when the transfer is completed, failed or canceled, the span of OpenObject ends
+// when the ObjectReader is closed, the span of ParallelListObjects ends when the listing is done, and
+// each Next of a paginator has a span. The parent of a span is taken from the context of the call,
+// which is set with WithContext or WithRequestContext.
+type Tracer interface {
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+// TracePropagator may be implemented by a Tracer to inject the trace context into the headers of each
+// HTTP attempt. The headers are injected after the request is signed, so they must not be signed headers.
+type TracePropagator interface {
+	Inject(ctx context.Context, header http.Header)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attributes ...Attribute) {}
+func (noopSpan) RecordError(err error)                 {}
+func (noopSpan) End()                                  {}
+
+type spanKey struct{}
+
+// getSpan returns the span of the API call carried by ctx.
+func getSpan(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// startSpan starts the span of an API call as a child of ctx, and returns a copy of ctx carrying the span.
+func (OSSClient OSSClient) startSpan(ctx context.Context, operation, bucketName, objectKey string) (context.Context, Span) {
+	if OSSClient.conf.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	attributes := []Attribute{{Key: ATTRIBUTE_OPERATION, Value: operation}}
+	if bucketName != "" {
+		attributes = append(attributes, Attribute{Key: ATTRIBUTE_BUCKET, Value: bucketName})
+	}
+	if objectKey != "" {
+		attributes = append(attributes, Attribute{Key: ATTRIBUTE_KEY, Value: objectKey})
+	}
+	ctx, span := OSSClient.conf.tracer.Start(ctx, operation, attributes...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// startExtensionsSpan starts the span of an API built upon the basic ones, and returns the extensions
+// with the context of the span, so that the calls made with them are children of the span.
+func (OSSClient OSSClient) startExtensionsSpan(operation, bucketName, objectKey string, extensions []extensionOptions) ([]extensionOptions, Span) {
+	if OSSClient.conf.tracer == nil {
+		return extensions, noopSpan{}
+	}
+	ctx, span := OSSClient.startSpan(OSSClient.getRequestContext(extensions), operation, bucketName, objectKey)
+	return append([]extensionOptions{WithContext(ctx)}, extensions...), span
+}
+
+// attemptRecord keeps the status and the request id of the last attempt of an API call, so that they are
+// set on the span of the call before it ends, the body of GetObject may be closed after that.
+type attemptRecord struct {
+	lock       sync.Mutex
+	attempt    int
+	statusCode int
+	requestID  string
+}
+
+type attemptRecordKey struct{}
+
+// withAttemptRecord returns a copy of ctx carrying a new attemptRecord, the record is nil if the client has no Tracer.
+func (OSSClient OSSClient) withAttemptRecord(ctx context.Context) (context.Context, *attemptRecord) {
+	if OSSClient.conf.tracer == nil {
+		return ctx, nil
+	}
+	record := &attemptRecord{}
+	return context.WithValue(ctx, attemptRecordKey{}, record), record
+}
+
+func getAttemptRecord(ctx context.Context) *attemptRecord {
+	record, _ := ctx.Value(attemptRecordKey{}).(*attemptRecord)
+	return record
+}
+
+func (record *attemptRecord) started(attempt int) {
+	if record == nil {
+		return
+	}
+	record.lock.Lock()
+	defer record.lock.Unlock()
+	record.attempt, record.statusCode, record.requestID = attempt, 0, ""
+}
+
+func (record *attemptRecord) responded(statusCode int, requestID string) {
+	if record == nil {
+		return
+	}
+	record.lock.Lock()
+	defer record.lock.Unlock()
+	record.statusCode, record.requestID = statusCode, requestID
+}
+
+// setAttributes sets the attributes of the last attempt on the span of the API call.
+func (record *attemptRecord) setAttributes(span Span) {
+	if record == nil {
+		return
+	}
+	record.lock.Lock()
+	defer record.lock.Unlock()
+	attributes := []Attribute{{Key: ATTRIBUTE_RETRY_COUNT, Value: record.attempt}}
+	if record.statusCode > 0 {
+		attributes = append(attributes, Attribute{Key: ATTRIBUTE_HTTP_STATUS, Value: record.statusCode})
+	}
+	if record.requestID != "" {
+		attributes = append(attributes, Attribute{Key: ATTRIBUTE_REQUEST_ID, Value: record.requestID})
+	}
+	span.SetAttributes(attributes...)
+}
+
+// endSpan records err if it is not nil and ends the span.
+func endSpan(span Span, err error) {
+	if err != nil {
+		if ossError, ok := err.(OSSError); ok && ossError.Code != "" {
+			span.SetAttributes(Attribute{Key: ATTRIBUTE_ERROR_CODE, Value: ossError.Code})
+		}
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,258 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type recordedSpan struct {
+	tracer     *recordingTracer
+	name       string
+	parent     *recordedSpan
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+	// lateAttributes counts the attributes set after the span is ended
+	lateAttributes int
+}
+
+func (span *recordedSpan) SetAttributes(attributes ...Attribute) {
+	span.tracer.lock.Lock()
+	defer span.tracer.lock.Unlock()
+	for _, attribute := range attributes {
+		if span.ended {
+			span.lateAttributes++
+		}
+		span.attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (span *recordedSpan) RecordError(err error) {
+	span.tracer.lock.Lock()
+	defer span.tracer.lock.Unlock()
+	span.err = err
+}
+
+func (span *recordedSpan) End() {
+	span.tracer.lock.Lock()
+	defer span.tracer.lock.Unlock()
+	span.ended = true
+}
+
+type recordedSpanKey struct{}
+
+type recordingTracer struct {
+	lock  sync.Mutex
+	spans []*recordedSpan
+}
+
+func (tracer *recordingTracer) Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span) {
+	parent, _ := ctx.Value(recordedSpanKey{}).(*recordedSpan)
+	span := &recordedSpan{tracer: tracer, name: name, parent: parent, attributes: make(map[string]interface{})}
+	for _, attribute := range attributes {
+		span.attributes[attribute.Key] = attribute.Value
+	}
+	tracer.lock.Lock()
+	tracer.spans = append(tracer.spans, span)
+	tracer.lock.Unlock()
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+func (tracer *recordingTracer) Inject(ctx context.Context, header http.Header) {
+	if span, ok := ctx.Value(recordedSpanKey{}).(*recordedSpan); ok {
+		header.Set("traceparent", span.name+"/"+span.parent.name)
+	}
+}
+
+// find returns the spans named name, the lock must not be held by the caller.
+func (tracer *recordingTracer) find(name string) []*recordedSpan {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	var spans []*recordedSpan
+	for _, span := range tracer.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// descends reports whether the span is a descendant of ancestor.
+func (span *recordedSpan) descends(ancestor *recordedSpan) bool {
+	for parent := span.parent; parent != nil; parent = parent.parent {
+		if parent == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// state returns whether the span is ended, and a copy of its attributes.
+func (span *recordedSpan) state() (bool, map[string]interface{}) {
+	span.tracer.lock.Lock()
+	defer span.tracer.lock.Unlock()
+	attributes := make(map[string]interface{}, len(span.attributes))
+	for key, value := range span.attributes {
+		attributes[key] = value
+	}
+	return span.ended, attributes
+}
+
+func TestTracerSpans(t *testing.T) {
+	fs := newFakeServer(t)
+	tracer := &recordingTracer{}
+	client := newTestClient(t, fs, WithTracer(tracer))
+	var failed int32
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPut && atomic.AddInt32(&failed, 1) == 1 {
+			writeError(w, http.StatusServiceUnavailable, "ServiceUnavailable")
+			return true
+		}
+		return false
+	})
+
+	putInput := &PutObjectInput{}
+	putInput.Bucket, putInput.Key = "bucket", "key"
+	putInput.Body = strings.NewReader("0123456789")
+	if _, err := client.PutObject(putInput); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	puts := tracer.find("PutObject")
+	if len(puts) != 1 {
+		t.Fatalf("expected a span of PutObject, got %d", len(puts))
+	}
+	ended, attributes := puts[0].state()
+	if !ended || attributes[ATTRIBUTE_RETRY_COUNT] != 1 || attributes[ATTRIBUTE_HTTP_STATUS] != http.StatusOK ||
+		attributes[ATTRIBUTE_REQUEST_ID] != "test" || attributes[ATTRIBUTE_BUCKET] != "bucket" || attributes[ATTRIBUTE_KEY] != "key" {
+		t.Fatalf("unexpected span of PutObject %v, ended: %v", attributes, ended)
+	}
+	attempts := tracer.find("HTTP PUT")
+	if len(attempts) != 2 || attempts[0].parent != puts[0] || attempts[1].parent != puts[0] {
+		t.Fatalf("expected 2 attempts under the span of PutObject, got %d", len(attempts))
+	}
+	if _, attributes := attempts[0].state(); attributes[ATTRIBUTE_HTTP_STATUS] != http.StatusServiceUnavailable || attributes[ATTRIBUTE_ATTEMPT] != 0 {
+		t.Fatalf("unexpected span of the failed attempt %v", attributes)
+	}
+	if traced := fs.countRequests(func(r *http.Request) bool { return r.Header.Get("traceparent") == "HTTP PUT/PutObject" }); traced != 2 {
+		t.Fatalf("expected the trace context to be injected into each attempt, got %d", traced)
+	}
+
+	getInput := &GetObjectInput{}
+	getInput.Bucket, getInput.Key = "bucket", "key"
+	output, err := client.GetObject(getInput)
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	// the span of the call ends on return, with the attributes of the attempt still being read
+	gets := tracer.find("GetObject")
+	if ended, attributes := gets[0].state(); !ended || attributes[ATTRIBUTE_HTTP_STATUS] != http.StatusOK || attributes[ATTRIBUTE_RETRY_COUNT] != 0 {
+		t.Fatalf("unexpected span of GetObject %v, ended: %v", attributes, ended)
+	}
+	io.Copy(io.Discard, output.Body)
+	output.Body.Close()
+	if ended, attributes := tracer.find("HTTP GET")[0].state(); !ended || attributes[ATTRIBUTE_BYTES_RECEIVED] != int64(10) {
+		t.Fatalf("unexpected span of the GET attempt %v, ended: %v", attributes, ended)
+	}
+
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	for _, span := range tracer.spans {
+		if span.lateAttributes != 0 {
+			t.Fatalf("expected no attributes set on %s after it is ended, got %d", span.name, span.lateAttributes)
+		}
+	}
+}
+
+func TestTracerLongRunningSpans(t *testing.T) {
+	fs := newFakeServer(t)
+	tracer := &recordingTracer{}
+	client := newTestClient(t, fs, WithTracer(tracer))
+	for _, key := range []string{"a/1", "a/2", "b"} {
+		fs.putObject("bucket", key, []byte(key))
+	}
+
+	openInput := &OpenObjectInput{}
+	openInput.Bucket, openInput.Key = "bucket", "a/1"
+	reader, err := client.OpenObject(openInput)
+	if err != nil {
+		t.Fatalf("OpenObject failed: %v", err)
+	}
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	open := tracer.find("OpenObject")[0]
+	if ended, _ := open.state(); ended {
+		t.Fatal("expected the span of OpenObject to end when the reader is closed")
+	}
+	for _, get := range tracer.find("GetObject") {
+		if get.parent != open {
+			t.Fatal("expected the range requests to be children of OpenObject")
+		}
+	}
+	reader.Close()
+	if ended, _ := open.state(); !ended {
+		t.Fatal("expected the span of OpenObject to be ended")
+	}
+
+	downloadInput := &DownloadFileInput{DownloadFile: filepath.Join(t.TempDir(), "file")}
+	downloadInput.Bucket, downloadInput.Key = "bucket", "b"
+	transfer, err := client.StartDownload(downloadInput)
+	if err != nil {
+		t.Fatalf("StartDownload failed: %v", err)
+	}
+	if _, err := transfer.Wait(); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	download := tracer.find("StartDownload")[0]
+	if ended, _ := download.state(); !ended || !tracer.find("DownloadFile")[0].descends(download) {
+		t.Fatalf("expected the span of StartDownload to be ended with the run as its child, ended: %v", ended)
+	}
+
+	listInput := &ListObjectsInput{}
+	listInput.Bucket, listInput.MaxKeys = "bucket", 2
+	paginator := NewListObjectsPaginator(client, listInput)
+	for paginator.HasNext() {
+		if _, err := paginator.Next(); err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+	}
+	pages := tracer.find("ListObjectsPaginator")
+	lists := tracer.find("ListObjects")
+	if len(pages) != 2 || len(lists) != 2 || lists[0].parent != pages[0] || lists[1].parent != pages[1] {
+		t.Fatalf("expected a span for each page, got %d pages and %d lists", len(pages), len(lists))
+	}
+
+	objects, err := client.ParallelListObjects(&ParallelListObjectsInput{Bucket: "bucket", TaskNum: 2})
+	if err != nil {
+		t.Fatalf("ParallelListObjects failed: %v", err)
+	}
+	for range objects {
+	}
+	parallel := tracer.find("ParallelListObjects")
+	if ended, _ := parallel[0].state(); !ended {
+		t.Fatal("expected the span of ParallelListObjects to be ended")
+	}
+	for _, list := range tracer.find("ListObjects")[2:] {
+		if !list.descends(parallel[0]) {
+			t.Fatal("expected the listings to be children of ParallelListObjects")
+		}
+	}
+}
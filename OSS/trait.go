@@ -606,7 +606,7 @@ func (input GetObjectInput) trans(isOSS bool) (params map[string]string, headers
 	if input.ImageProcess != "" {
 		params[PARAM_IMAGE_PROCESS] = input.ImageProcess
 	}
-	if input.RangeStart >= 0 && input.RangeEnd > input.RangeStart {
+	if input.RangeStart >= 0 && (input.RangeEnd > input.RangeStart || input.ExactRange && input.RangeEnd == input.RangeStart) {
 		headers[HEADER_RANGE] = []string{fmt.Sprintf("bytes=%d-%d", input.RangeStart, input.RangeEnd)}
 	}
 
@@ -920,6 +920,7 @@ type readerWrapper struct {
 	mark        int64
 	totalCount  int64
 	readedCount int64
+	tracker     *progressTracker
 }
 
 func (rw *readerWrapper) seek(offset int64, whence int) (int64, error) {
@@ -934,6 +935,14 @@ func (rw *readerWrapper) seek(offset int64, whence int) (int64, error) {
 }
 
 func (rw *readerWrapper) Read(p []byte) (n int, err error) {
+	n, err = rw.read(p)
+	if n > 0 {
+		rw.tracker.add(int64(n))
+	}
+	return
+}
+
+func (rw *readerWrapper) read(p []byte) (n int, err error) {
 	if rw.totalCount == 0 {
 		return 0, io.EOF
 	}
@@ -948,7 +957,14 @@ func (rw *readerWrapper) Read(p []byte) (n int, err error) {
 		rw.readedCount += remainCount
 		return int(remainCount), io.EOF
 	}
-	return rw.reader.Read(p)
+	n, err = rw.reader.Read(p)
+	rw.readedCount += int64(n)
+	return n, err
+}
+
+// rollback discards the bytes read by the last attempt from the progress.
+func (rw *readerWrapper) rollback() {
+	rw.tracker.add(-rw.readedCount)
 }
 
 type fileReaderWrapper struct {
@@ -14,13 +14,15 @@ package OSS
 
 import (
 	"bufio"
+	"context"
+	"crypto/md5"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -75,18 +77,32 @@ func (ufc *UploadCheckpoint) isValid(bucket, key, uploadFile string, fileStat os
 	return true
 }
 
+func (ufc *UploadCheckpoint) completedBytes() (completedBytes int64) {
+	for _, uploadPart := range ufc.UploadParts {
+		if uploadPart.IsCompleted {
+			completedBytes += uploadPart.PartSize
+		}
+	}
+	return
+}
+
 type uploadPartTask struct {
 	UploadPartInput
-	OSSClient        *OSSClient
-	abort            *int32
-	extensions       []extensionOptions
-	enableCheckpoint bool
+	ctx                  context.Context
+	OSSClient            *OSSClient
+	abort                *int32
+	extensions           []extensionOptions
+	enableCheckpoint     bool
+	enableIntegrityCheck bool
 }
 
 func (task *uploadPartTask) Run() interface{} {
 	if atomic.LoadInt32(task.abort) == 1 {
 		return errAbort
 	}
+	if err := task.ctx.Err(); err != nil {
+		return err
+	}
 
 	input := &UploadPartInput{}
 	input.Bucket = task.Bucket
@@ -97,8 +113,20 @@ func (task *uploadPartTask) Run() interface{} {
 	input.SourceFile = task.SourceFile
 	input.Offset = task.Offset
 	input.PartSize = task.PartSize
+	input.ProgressListener = task.ProgressListener
 	extensions := task.extensions
 
+	var partMd5 []byte
+	if task.enableIntegrityCheck {
+		var err error
+		partMd5, err = fileSectionMd5(task.SourceFile, task.Offset, task.PartSize)
+		if err != nil {
+			doLog(LEVEL_ERROR, "Failed to calculate md5 of part [%d] with error [%v].", task.PartNumber, err)
+			return err
+		}
+		input.ContentMD5 = Base64Encode(partMd5)
+	}
+
 	var output *UploadPartOutput
 	var err error
 	if len(extensions) != 0 {
@@ -110,12 +138,23 @@ func (task *uploadPartTask) Run() interface{} {
 	if err == nil {
 		if output.ETag == "" {
 			doLog(LEVEL_WARN, "Get invalid etag value after uploading part [%d].", task.PartNumber)
+			rollbackPartProgress(task.ProgressListener)
 			if !task.enableCheckpoint {
 				atomic.CompareAndSwapInt32(task.abort, 0, 1)
 				doLog(LEVEL_WARN, "Task is aborted, part number is [%d]", task.PartNumber)
 			}
 			return fmt.Errorf("get invalid etag value after uploading part [%d]", task.PartNumber)
 		}
+		if partMd5 != nil && etagIsMd5(task.SseHeader) && trimETag(output.ETag) != Hex(partMd5) {
+			doLog(LEVEL_ERROR, "The etag of part [%d] does not match the md5 of the part.", task.PartNumber)
+			rollbackPartProgress(task.ProgressListener)
+			if !task.enableCheckpoint {
+				atomic.CompareAndSwapInt32(task.abort, 0, 1)
+				doLog(LEVEL_WARN, "Task is aborted, part number is [%d]", task.PartNumber)
+			}
+			return IntegrityError{Bucket: task.Bucket, Key: task.Key, PartNumber: task.PartNumber,
+				Algorithm: integrityAlgorithmMD5, Expected: Hex(partMd5), Actual: trimETag(output.ETag)}
+		}
 		return output
 	} else if OSSError, ok := err.(OSSError); ok && OSSError.StatusCode >= 400 && OSSError.StatusCode < 500 {
 		atomic.CompareAndSwapInt32(task.abort, 0, 1)
@@ -124,38 +163,17 @@ func (task *uploadPartTask) Run() interface{} {
 	return err
 }
 
-func loadCheckpointFile(checkpointFile string, result interface{}) error {
-	ret, err := ioutil.ReadFile(checkpointFile)
-	if err != nil {
-		return err
-	}
-	if len(ret) == 0 {
-		return nil
-	}
-	return xml.Unmarshal(ret, result)
-}
-
-func updateCheckpointFile(fc interface{}, checkpointFilePath string) error {
-	result, err := xml.Marshal(fc)
-	if err != nil {
-		return err
-	}
-	err = ioutil.WriteFile(checkpointFilePath, result, 0666)
-	return err
-}
-
 func getCheckpointFile(ufc *UploadCheckpoint, uploadFileStat os.FileInfo, input *UploadFileInput, OSSClient *OSSClient, extensions []extensionOptions) (needCheckpoint bool, err error) {
 	checkpointFilePath := input.CheckpointFile
-	checkpointFileStat, err := os.Stat(checkpointFilePath)
-	if err != nil {
-		doLog(LEVEL_DEBUG, fmt.Sprintf("Stat checkpoint file failed with error: [%v].", err))
+	err = loadCheckpointFile(input.CheckpointStore, checkpointFilePath, ufc)
+	if err == ErrCheckpointNotFound {
+		doLog(LEVEL_DEBUG, fmt.Sprintf("Checkpoint file [%s] is not found.", checkpointFilePath))
 		return true, nil
 	}
-	if checkpointFileStat.IsDir() {
+	if err == errCheckpointIsFolder {
 		doLog(LEVEL_ERROR, "Checkpoint file can not be a folder.")
-		return false, errors.New("checkpoint file can not be a folder")
+		return false, err
 	}
-	err = loadCheckpointFile(checkpointFilePath, ufc)
 	if err != nil {
 		doLog(LEVEL_WARN, fmt.Sprintf("Load checkpoint file failed with error: [%v].", err))
 		return true, nil
@@ -166,7 +184,7 @@ func getCheckpointFile(ufc *UploadCheckpoint, uploadFileStat os.FileInfo, input
 				doLog(LEVEL_WARN, "Failed to abort upload task [%s].", ufc.UploadId)
 			}
 		}
-		_err := os.Remove(checkpointFilePath)
+		_err := removeCheckpointFile(input.CheckpointStore, checkpointFilePath)
 		if _err != nil {
 			doLog(LEVEL_WARN, fmt.Sprintf("Failed to remove checkpoint file with error: [%v].", _err))
 		}
@@ -246,16 +264,16 @@ func sliceFile(partSize int64, ufc *UploadCheckpoint) error {
 	return nil
 }
 
+// abortTask aborts the multipart upload. The upload may have failed because the context of the caller is
+// canceled, so the abort is sent with a context which is not canceled with it.
 func abortTask(bucket, key, uploadID string, OSSClient *OSSClient, extensions []extensionOptions) error {
 	input := &AbortMultipartUploadInput{}
 	input.Bucket = bucket
 	input.Key = key
 	input.UploadId = uploadID
-	if len(extensions) != 0 {
-		_, err := OSSClient.AbortMultipartUpload(input, extensions...)
-		return err
-	}
-	_, err := OSSClient.AbortMultipartUpload(input)
+	extensions, cancel := OSSClient.withDetachedContext(extensions, DEFAULT_ABORT_TIMEOUT)
+	defer cancel()
+	_, err := OSSClient.AbortMultipartUpload(input, extensions...)
 	return err
 }
 
@@ -273,7 +291,7 @@ func handleUploadFileResult(uploadPartError error, ufc *UploadCheckpoint, enable
 	return nil
 }
 
-func completeParts(ufc *UploadCheckpoint, enableCheckpoint bool, checkpointFilePath string, OSSClient *OSSClient, encodingType string, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+func completeParts(ufc *UploadCheckpoint, enableCheckpoint bool, checkpointStore CheckpointStore, checkpointFilePath string, OSSClient *OSSClient, encodingType string, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
 	completeInput := &CompleteMultipartUploadInput{}
 	completeInput.Bucket = ufc.Bucket
 	completeInput.Key = ufc.Key
@@ -296,7 +314,7 @@ func completeParts(ufc *UploadCheckpoint, enableCheckpoint bool, checkpointFileP
 
 	if err == nil {
 		if enableCheckpoint {
-			_err := os.Remove(checkpointFilePath)
+			_err := removeCheckpointFile(checkpointStore, checkpointFilePath)
 			if _err != nil {
 				doLog(LEVEL_WARN, "Upload file successfully, but remove checkpoint file failed with error [%v].", _err)
 			}
@@ -313,6 +331,7 @@ func completeParts(ufc *UploadCheckpoint, enableCheckpoint bool, checkpointFileP
 }
 
 func (OSSClient OSSClient) resumeUpload(input *UploadFileInput, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	listener, extensions := splitProgressListener(extensions, input.ProgressListener)
 	uploadFileStat, err := os.Stat(input.UploadFile)
 	if err != nil {
 		doLog(LEVEL_ERROR, fmt.Sprintf("Failed to stat uploadFile with error: [%v].", err))
@@ -341,7 +360,7 @@ func (OSSClient OSSClient) resumeUpload(input *UploadFileInput, extensions []ext
 		}
 
 		if enableCheckpoint {
-			err = updateCheckpointFile(ufc, checkpointFilePath)
+			err = updateCheckpointFile(input.CheckpointStore, ufc, checkpointFilePath)
 			if err != nil {
 				doLog(LEVEL_ERROR, "Failed to update checkpoint file with error [%v].", err)
 				_err := abortTask(ufc.Bucket, ufc.Key, ufc.UploadId, &OSSClient, extensions)
@@ -353,25 +372,56 @@ func (OSSClient OSSClient) resumeUpload(input *UploadFileInput, extensions []ext
 		}
 	}
 
-	uploadPartError := OSSClient.uploadPartConcurrent(ufc, checkpointFilePath, input, extensions)
+	tracker := newProgressTracker(listener, ufc.completedBytes(), ufc.FileInfo.Size)
+	tracker.started()
+	uploadPartError := OSSClient.uploadPartConcurrent(ufc, checkpointFilePath, input, tracker, extensions)
 	err = handleUploadFileResult(uploadPartError, ufc, enableCheckpoint, &OSSClient, extensions)
 	if err != nil {
+		tracker.failed()
 		return nil, err
 	}
 
-	completeOutput, err := completeParts(ufc, enableCheckpoint, checkpointFilePath, &OSSClient, input.EncodingType, extensions)
+	completeOutput, err := completeParts(ufc, enableCheckpoint, input.CheckpointStore, checkpointFilePath, &OSSClient, input.EncodingType, extensions)
+	if err == nil && input.EnableIntegrityCheck && etagIsMd5(input.SseHeader) {
+		err = checkMultipartETag(ufc, completeOutput.ETag)
+	}
+	if err != nil {
+		tracker.failed()
+	} else {
+		tracker.completed()
+	}
 
 	return completeOutput, err
 }
 
-func handleUploadTaskResult(result interface{}, ufc *UploadCheckpoint, partNum int, enableCheckpoint bool, checkpointFilePath string, lock *sync.Mutex) (err error) {
+// checkMultipartETag compares the etag of the object completed with the parts of ufc to the etag
+// calculated from the etags of the parts.
+func checkMultipartETag(ufc *UploadCheckpoint, etag string) error {
+	etags := make([]string, 0, len(ufc.UploadParts))
+	for _, uploadPart := range ufc.UploadParts {
+		etags = append(etags, uploadPart.Etag)
+	}
+	expected, ok := multipartETag(etags)
+	actual := trimETag(etag)
+	if !ok || !strings.Contains(actual, "-") {
+		doLog(LEVEL_WARN, "The etag [%s] of object [%s] is not calculated from the parts, skip the integrity check.", actual, ufc.Key)
+		return nil
+	}
+	if expected != actual {
+		doLog(LEVEL_ERROR, "The etag of object [%s] does not match the etags of the parts.", ufc.Key)
+		return IntegrityError{Bucket: ufc.Bucket, Key: ufc.Key, Algorithm: integrityAlgorithmMD5, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+func handleUploadTaskResult(result interface{}, ufc *UploadCheckpoint, partNum int, enableCheckpoint bool, checkpointStore CheckpointStore, checkpointFilePath string, lock *sync.Mutex) (err error) {
 	if uploadPartOutput, ok := result.(*UploadPartOutput); ok {
 		lock.Lock()
 		defer lock.Unlock()
 		ufc.UploadParts[partNum-1].Etag = uploadPartOutput.ETag
 		ufc.UploadParts[partNum-1].IsCompleted = true
 		if enableCheckpoint {
-			_err := updateCheckpointFile(ufc, checkpointFilePath)
+			_err := updateCheckpointFile(checkpointStore, ufc, checkpointFilePath)
 			if _err != nil {
 				doLog(LEVEL_WARN, "Failed to update checkpoint file with error [%v].", _err)
 			}
@@ -384,14 +434,63 @@ func handleUploadTaskResult(result interface{}, ufc *UploadCheckpoint, partNum i
 	return
 }
 
-func (OSSClient OSSClient) uploadPartConcurrent(ufc *UploadCheckpoint, checkpointFilePath string, input *UploadFileInput, extensions []extensionOptions) error {
-	pool := NewRoutinePool(input.TaskNum, MAX_PART_NUM)
+// extensionPool receives the pool which runs the part tasks of UploadFile or DownloadFile.
+type extensionPool func(pool Pool)
+
+// extensionSharedPool is the pool shared by several transfers, on which the part tasks of UploadFile
+// or DownloadFile run.
+type extensionSharedPool func() Pool
+
+// sharedTransferPool runs the part tasks of a transfer on a shared pool, at most taskNum of them at
+// the same time. ShutDown waits for the tasks of the transfer, and does not shut the shared pool down.
+type sharedTransferPool struct {
+	Pool
+	tokens chan struct{}
+	wg     sync.WaitGroup
+}
+
+func (pool *sharedTransferPool) ExecuteFunc(f func() interface{}) {
+	pool.tokens <- struct{}{}
+	pool.wg.Add(1)
+	pool.Pool.ExecuteFunc(func() interface{} {
+		defer func() {
+			<-pool.tokens
+			pool.wg.Done()
+		}()
+		return f()
+	})
+}
+
+func (pool *sharedTransferPool) ShutDown() {
+	pool.wg.Wait()
+}
+
+// newTransferPool creates the pool for the part tasks. If the pool is observed, the parts are
+// dispatched one by one, so that the max worker count of the pool can be changed while running.
+func newTransferPool(taskNum int, extensions []extensionOptions) Pool {
+	for _, extension := range extensions {
+		if sharedPool, ok := extension.(extensionSharedPool); ok {
+			return &sharedTransferPool{Pool: sharedPool(), tokens: make(chan struct{}, taskNum)}
+		}
+		if observer, ok := extension.(extensionPool); ok {
+			pool := NewRoutinePool(taskNum, 0)
+			pool.EnableAutoTune()
+			observer(pool)
+			return pool
+		}
+	}
+	return NewRoutinePool(taskNum, MAX_PART_NUM)
+}
+
+func (OSSClient OSSClient) uploadPartConcurrent(ufc *UploadCheckpoint, checkpointFilePath string, input *UploadFileInput, tracker *progressTracker, extensions []extensionOptions) error {
+	ctx := OSSClient.getRequestContext(extensions)
+	pool := newTransferPool(input.TaskNum, extensions)
 	var uploadPartError atomic.Value
 	var errFlag int32
 	var abort int32
 	lock := new(sync.Mutex)
 	for _, uploadPart := range ufc.UploadParts {
-		if atomic.LoadInt32(&abort) == 1 {
+		if atomic.LoadInt32(&abort) == 1 || ctx.Err() != nil {
 			break
 		}
 		if uploadPart.IsCompleted {
@@ -399,23 +498,26 @@ func (OSSClient OSSClient) uploadPartConcurrent(ufc *UploadCheckpoint, checkpoin
 		}
 		task := uploadPartTask{
 			UploadPartInput: UploadPartInput{
-				Bucket:     ufc.Bucket,
-				Key:        ufc.Key,
-				PartNumber: uploadPart.PartNumber,
-				UploadId:   ufc.UploadId,
-				SseHeader:  input.SseHeader,
-				SourceFile: input.UploadFile,
-				Offset:     uploadPart.Offset,
-				PartSize:   uploadPart.PartSize,
+				Bucket:           ufc.Bucket,
+				Key:              ufc.Key,
+				PartNumber:       uploadPart.PartNumber,
+				UploadId:         ufc.UploadId,
+				SseHeader:        input.SseHeader,
+				SourceFile:       input.UploadFile,
+				Offset:           uploadPart.Offset,
+				PartSize:         uploadPart.PartSize,
+				ProgressListener: newPartProgressListener(tracker),
 			},
-			OSSClient:        &OSSClient,
-			abort:            &abort,
-			extensions:       extensions,
-			enableCheckpoint: input.EnableCheckpoint,
+			ctx:                  ctx,
+			OSSClient:            &OSSClient,
+			abort:                &abort,
+			extensions:           extensions,
+			enableCheckpoint:     input.EnableCheckpoint,
+			enableIntegrityCheck: input.EnableIntegrityCheck,
 		}
 		pool.ExecuteFunc(func() interface{} {
 			result := task.Run()
-			err := handleUploadTaskResult(result, ufc, task.PartNumber, input.EnableCheckpoint, input.CheckpointFile, lock)
+			err := handleUploadTaskResult(result, ufc, task.PartNumber, input.EnableCheckpoint, input.CheckpointStore, input.CheckpointFile, lock)
 			if err != nil && atomic.CompareAndSwapInt32(&errFlag, 0, 1) {
 				uploadPartError.Store(err)
 			}
@@ -426,7 +528,7 @@ func (OSSClient OSSClient) uploadPartConcurrent(ufc *UploadCheckpoint, checkpoin
 	if err, ok := uploadPartError.Load().(error); ok {
 		return err
 	}
-	return nil
+	return ctx.Err()
 }
 
 // ObjectInfo defines download object info
@@ -487,20 +589,39 @@ func (dfc *DownloadCheckpoint) isValid(input *DownloadFileInput, output *GetObje
 	return true
 }
 
+func (dfc *DownloadCheckpoint) completedBytes() (completedBytes int64) {
+	for _, downloadPart := range dfc.DownloadParts {
+		if downloadPart.IsCompleted && downloadPart.RangeEnd >= downloadPart.Offset {
+			completedBytes += downloadPart.RangeEnd - downloadPart.Offset + 1
+		}
+	}
+	if completedBytes > dfc.ObjectInfo.Size {
+		completedBytes = dfc.ObjectInfo.Size
+	}
+	return
+}
+
 type downloadPartTask struct {
 	GetObjectInput
-	OSSClient        *OSSClient
-	extensions       []extensionOptions
-	abort            *int32
-	partNumber       int64
-	tempFileURL      string
-	enableCheckpoint bool
+	ctx                  context.Context
+	OSSClient            *OSSClient
+	extensions           []extensionOptions
+	abort                *int32
+	partNumber           int64
+	partSize             int64
+	tempFileURL          string
+	enableCheckpoint     bool
+	enableIntegrityCheck bool
+	progressListener     ProgressListener
 }
 
 func (task *downloadPartTask) Run() interface{} {
 	if atomic.LoadInt32(task.abort) == 1 {
 		return errAbort
 	}
+	if err := task.ctx.Err(); err != nil {
+		return err
+	}
 	getObjectInput := &GetObjectInput{}
 	getObjectInput.GetObjectMetadataInput = task.GetObjectMetadataInput
 	getObjectInput.IfMatch = task.IfMatch
@@ -509,11 +630,14 @@ func (task *downloadPartTask) Run() interface{} {
 	getObjectInput.IfUnmodifiedSince = task.IfUnmodifiedSince
 	getObjectInput.RangeStart = task.RangeStart
 	getObjectInput.RangeEnd = task.RangeEnd
+	getObjectInput.ExactRange = true
+
+	extensions := withProgressListener(task.extensions, task.progressListener)
 
 	var output *GetObjectOutput
 	var err error
-	if len(task.extensions) != 0 {
-		output, err = task.OSSClient.GetObject(getObjectInput, task.extensions...)
+	if len(extensions) != 0 {
+		output, err = task.OSSClient.GetObject(getObjectInput, extensions...)
 	} else {
 		output, err = task.OSSClient.GetObject(getObjectInput)
 	}
@@ -525,8 +649,24 @@ func (task *downloadPartTask) Run() interface{} {
 				doLog(LEVEL_WARN, "Failed to close response body.")
 			}
 		}()
-		_err := updateDownloadFile(task.tempFileURL, task.RangeStart, output)
+		var contentMd5 string
+		var md5Body *md5ReadCloser
+		if task.enableIntegrityCheck {
+			contentMd5, md5Body = newContentMd5Body(output)
+		}
+		writeCount, _err := updateDownloadFile(task.tempFileURL, task.RangeStart, output)
+		if _err == nil && task.enableIntegrityCheck && writeCount != task.partSize {
+			doLog(LEVEL_ERROR, "The size of part [%d] does not match, expect: [%d], actual: [%d]", task.partNumber, task.partSize, writeCount)
+			_err = IntegrityError{Bucket: task.Bucket, Key: task.Key, PartNumber: int(task.partNumber),
+				Algorithm: integrityAlgorithmSize, Expected: Int64ToString(task.partSize), Actual: Int64ToString(writeCount)}
+		}
+		if _err == nil && md5Body != nil && md5Body.base64Md5() != contentMd5 {
+			doLog(LEVEL_ERROR, "The md5 of part [%d] does not match, expect: [%s], actual: [%s]", task.partNumber, contentMd5, md5Body.base64Md5())
+			_err = IntegrityError{Bucket: task.Bucket, Key: task.Key, PartNumber: int(task.partNumber),
+				Algorithm: integrityAlgorithmMD5, Expected: contentMd5, Actual: md5Body.base64Md5()}
+		}
 		if _err != nil {
+			rollbackPartProgress(task.progressListener)
 			if !task.enableCheckpoint {
 				atomic.CompareAndSwapInt32(task.abort, 0, 1)
 				doLog(LEVEL_WARN, "Task is aborted, part number is [%d]", task.partNumber)
@@ -553,16 +693,15 @@ func getObjectInfo(input *DownloadFileInput, OSSClient *OSSClient, extensions []
 
 func getDownloadCheckpointFile(dfc *DownloadCheckpoint, input *DownloadFileInput, output *GetObjectMetadataOutput) (needCheckpoint bool, err error) {
 	checkpointFilePath := input.CheckpointFile
-	checkpointFileStat, err := os.Stat(checkpointFilePath)
-	if err != nil {
-		doLog(LEVEL_DEBUG, fmt.Sprintf("Stat checkpoint file failed with error: [%v].", err))
+	err = loadCheckpointFile(input.CheckpointStore, checkpointFilePath, dfc)
+	if err == ErrCheckpointNotFound {
+		doLog(LEVEL_DEBUG, fmt.Sprintf("Checkpoint file [%s] is not found.", checkpointFilePath))
 		return true, nil
 	}
-	if checkpointFileStat.IsDir() {
+	if err == errCheckpointIsFolder {
 		doLog(LEVEL_ERROR, "Checkpoint file can not be a folder.")
-		return false, errors.New("checkpoint file can not be a folder")
+		return false, err
 	}
-	err = loadCheckpointFile(checkpointFilePath, dfc)
 	if err != nil {
 		doLog(LEVEL_WARN, fmt.Sprintf("Load checkpoint file failed with error: [%v].", err))
 		return true, nil
@@ -573,7 +712,7 @@ func getDownloadCheckpointFile(dfc *DownloadCheckpoint, input *DownloadFileInput
 				doLog(LEVEL_WARN, "Failed to remove temp download file with error [%v].", _err)
 			}
 		}
-		_err := os.Remove(checkpointFilePath)
+		_err := removeCheckpointFile(input.CheckpointStore, checkpointFilePath)
 		if _err != nil {
 			doLog(LEVEL_WARN, "Failed to remove checkpoint file with error [%v].", _err)
 		}
@@ -685,6 +824,7 @@ func handleDownloadFileResult(tempFileURL string, enableCheckpoint bool, downloa
 }
 
 func (OSSClient OSSClient) resumeDownload(input *DownloadFileInput, extensions []extensionOptions) (output *GetObjectMetadataOutput, err error) {
+	listener, extensions := splitProgressListener(extensions, input.ProgressListener)
 	getObjectmetaOutput, err := getObjectInfo(input, &OSSClient, extensions)
 	if err != nil {
 		return nil, err
@@ -724,7 +864,7 @@ func (OSSClient OSSClient) resumeDownload(input *DownloadFileInput, extensions [
 		}
 
 		if enableCheckpoint {
-			_err := updateCheckpointFile(dfc, checkpointFilePath)
+			_err := updateCheckpointFile(input.CheckpointStore, dfc, checkpointFilePath)
 			if _err != nil {
 				doLog(LEVEL_ERROR, "Failed to update checkpoint file with error [%v].", _err)
 				_errMsg := os.Remove(dfc.TempFileInfo.TempFileUrl)
@@ -736,19 +876,43 @@ func (OSSClient OSSClient) resumeDownload(input *DownloadFileInput, extensions [
 		}
 	}
 
-	downloadFileError := OSSClient.downloadFileConcurrent(input, dfc, extensions)
+	tracker := newProgressTracker(listener, dfc.completedBytes(), dfc.ObjectInfo.Size)
+	tracker.started()
+	downloadFileError := OSSClient.downloadFileConcurrent(input, dfc, tracker, extensions)
 	err = handleDownloadFileResult(dfc.TempFileInfo.TempFileUrl, enableCheckpoint, downloadFileError)
 	if err != nil {
+		tracker.failed()
 		return nil, err
 	}
 
+	if input.EnableIntegrityCheck {
+		err = checkDownloadFile(dfc.TempFileInfo.TempFileUrl, getObjectmetaOutput, input)
+		if err != nil {
+			// the temp download file is corrupt, so it can not be resumed
+			_err := os.Remove(dfc.TempFileInfo.TempFileUrl)
+			if _err != nil {
+				doLog(LEVEL_WARN, "Failed to remove temp download file with error [%v].", _err)
+			}
+			if enableCheckpoint {
+				_err = removeCheckpointFile(input.CheckpointStore, checkpointFilePath)
+				if _err != nil {
+					doLog(LEVEL_WARN, "Failed to remove checkpoint file with error [%v].", _err)
+				}
+			}
+			tracker.failed()
+			return nil, err
+		}
+	}
+
 	err = os.Rename(dfc.TempFileInfo.TempFileUrl, input.DownloadFile)
 	if err != nil {
 		doLog(LEVEL_ERROR, "Failed to rename temp download file [%s] to download file [%s] with error [%v].", dfc.TempFileInfo.TempFileUrl, input.DownloadFile, err)
+		tracker.failed()
 		return nil, err
 	}
+	tracker.completed()
 	if enableCheckpoint {
-		err = os.Remove(checkpointFilePath)
+		err = removeCheckpointFile(input.CheckpointStore, checkpointFilePath)
 		if err != nil {
 			doLog(LEVEL_WARN, "Download file successfully, but remove checkpoint file failed with error [%v].", err)
 		}
@@ -757,11 +921,11 @@ func (OSSClient OSSClient) resumeDownload(input *DownloadFileInput, extensions [
 	return getObjectmetaOutput, nil
 }
 
-func updateDownloadFile(filePath string, rangeStart int64, output *GetObjectOutput) error {
+func updateDownloadFile(filePath string, rangeStart int64, output *GetObjectOutput) (writeCount int64, err error) {
 	fd, err := os.OpenFile(filePath, os.O_WRONLY, 0666)
 	if err != nil {
 		doLog(LEVEL_ERROR, "Failed to open file [%s].", filePath)
-		return err
+		return 0, err
 	}
 	defer func() {
 		errMsg := fd.Close()
@@ -772,7 +936,7 @@ func updateDownloadFile(filePath string, rangeStart int64, output *GetObjectOutp
 	_, err = fd.Seek(rangeStart, 0)
 	if err != nil {
 		doLog(LEVEL_ERROR, "Failed to seek file with error [%v].", err)
-		return err
+		return 0, err
 	}
 	fileWriter := bufio.NewWriterSize(fd, 65536)
 	part := make([]byte, 8192)
@@ -784,17 +948,18 @@ func updateDownloadFile(filePath string, rangeStart int64, output *GetObjectOutp
 			wcnt, werr := fileWriter.Write(part[0:readCount])
 			if werr != nil {
 				doLog(LEVEL_ERROR, "Failed to write to file with error [%v].", werr)
-				return werr
+				return writeCount, werr
 			}
 			if wcnt != readCount {
 				doLog(LEVEL_ERROR, "Failed to write to file [%s], expect: [%d], actual: [%d]", filePath, readCount, wcnt)
-				return fmt.Errorf("Failed to write to file [%s], expect: [%d], actual: [%d]", filePath, readCount, wcnt)
+				return writeCount, fmt.Errorf("Failed to write to file [%s], expect: [%d], actual: [%d]", filePath, readCount, wcnt)
 			}
+			writeCount += int64(wcnt)
 		}
 		if readErr != nil {
 			if readErr != io.EOF {
 				doLog(LEVEL_ERROR, "Failed to read response body with error [%v].", readErr)
-				return readErr
+				return writeCount, readErr
 			}
 			break
 		}
@@ -802,18 +967,53 @@ func updateDownloadFile(filePath string, rangeStart int64, output *GetObjectOutp
 	err = fileWriter.Flush()
 	if err != nil {
 		doLog(LEVEL_ERROR, "Failed to flush file with error [%v].", err)
+		return 0, err
+	}
+	return writeCount, nil
+}
+
+// checkDownloadFile compares the CRC64 of the downloaded file to the hash-crc64ecma of the object if the
+// service provides it, and the MD5 of the file to the etag of the object if the etag is the MD5 of the object.
+func checkDownloadFile(filePath string, output *GetObjectMetadataOutput, input *DownloadFileInput) error {
+	checked := false
+	if values, ok := output.ResponseHeaders[HEADER_HASH_CRC64]; ok && len(values) > 0 && values[0] != "" {
+		crc64Value, err := fileCrc64(filePath)
+		if err != nil {
+			doLog(LEVEL_ERROR, "Failed to calculate the crc64 of file [%s] with error [%v].", filePath, err)
+			return err
+		}
+		if values[0] != crc64Value {
+			doLog(LEVEL_ERROR, "The crc64 of the download file does not match the object [%s].", input.Key)
+			return IntegrityError{Bucket: input.Bucket, Key: input.Key, Algorithm: integrityAlgorithmCRC64, Expected: values[0], Actual: crc64Value}
+		}
+		checked = true
+	}
+	etag := trimETag(output.ETag)
+	if len(etag) != 2*md5.Size || strings.Contains(etag, "-") || !etagIsMd5(output.SseHeader) {
+		if !checked {
+			doLog(LEVEL_WARN, "The object [%s] provides no checksum, skip the integrity check.", input.Key)
+		}
+		return nil
+	}
+	md5Value, err := fileMd5(filePath)
+	if err != nil {
+		doLog(LEVEL_ERROR, "Failed to calculate the md5 of file [%s] with error [%v].", filePath, err)
 		return err
 	}
+	if !strings.EqualFold(etag, md5Value) {
+		doLog(LEVEL_ERROR, "The md5 of the download file does not match the object [%s].", input.Key)
+		return IntegrityError{Bucket: input.Bucket, Key: input.Key, Algorithm: integrityAlgorithmMD5, Expected: etag, Actual: md5Value}
+	}
 	return nil
 }
 
-func handleDownloadTaskResult(result interface{}, dfc *DownloadCheckpoint, partNum int64, enableCheckpoint bool, checkpointFile string, lock *sync.Mutex) (err error) {
+func handleDownloadTaskResult(result interface{}, dfc *DownloadCheckpoint, partNum int64, enableCheckpoint bool, checkpointStore CheckpointStore, checkpointFile string, lock *sync.Mutex) (err error) {
 	if _, ok := result.(*GetObjectOutput); ok {
 		lock.Lock()
 		defer lock.Unlock()
 		dfc.DownloadParts[partNum-1].IsCompleted = true
 		if enableCheckpoint {
-			_err := updateCheckpointFile(dfc, checkpointFile)
+			_err := updateCheckpointFile(checkpointStore, dfc, checkpointFile)
 			if _err != nil {
 				doLog(LEVEL_WARN, "Failed to update checkpoint file with error [%v].", _err)
 			}
@@ -826,14 +1026,15 @@ func handleDownloadTaskResult(result interface{}, dfc *DownloadCheckpoint, partN
 	return
 }
 
-func (OSSClient OSSClient) downloadFileConcurrent(input *DownloadFileInput, dfc *DownloadCheckpoint, extensions []extensionOptions) error {
-	pool := NewRoutinePool(input.TaskNum, MAX_PART_NUM)
+func (OSSClient OSSClient) downloadFileConcurrent(input *DownloadFileInput, dfc *DownloadCheckpoint, tracker *progressTracker, extensions []extensionOptions) error {
+	ctx := OSSClient.getRequestContext(extensions)
+	pool := newTransferPool(input.TaskNum, extensions)
 	var downloadPartError atomic.Value
 	var errFlag int32
 	var abort int32
 	lock := new(sync.Mutex)
 	for _, downloadPart := range dfc.DownloadParts {
-		if atomic.LoadInt32(&abort) == 1 {
+		if atomic.LoadInt32(&abort) == 1 || ctx.Err() != nil {
 			break
 		}
 		if downloadPart.IsCompleted {
@@ -849,16 +1050,23 @@ func (OSSClient OSSClient) downloadFileConcurrent(input *DownloadFileInput, dfc
 				RangeStart:             downloadPart.Offset,
 				RangeEnd:               downloadPart.RangeEnd,
 			},
-			OSSClient:        &OSSClient,
-			extensions:       extensions,
-			abort:            &abort,
-			partNumber:       downloadPart.PartNumber,
-			tempFileURL:      dfc.TempFileInfo.TempFileUrl,
-			enableCheckpoint: input.EnableCheckpoint,
+			ctx:                  ctx,
+			OSSClient:            &OSSClient,
+			extensions:           extensions,
+			abort:                &abort,
+			partNumber:           downloadPart.PartNumber,
+			partSize:             downloadPart.RangeEnd - downloadPart.Offset + 1,
+			tempFileURL:          dfc.TempFileInfo.TempFileUrl,
+			enableCheckpoint:     input.EnableCheckpoint,
+			enableIntegrityCheck: input.EnableIntegrityCheck,
+			progressListener:     newPartProgressListener(tracker),
+		}
+		if dfc.ObjectInfo.Size == 0 {
+			task.partSize = 0
 		}
 		pool.ExecuteFunc(func() interface{} {
 			result := task.Run()
-			err := handleDownloadTaskResult(result, dfc, task.partNumber, input.EnableCheckpoint, input.CheckpointFile, lock)
+			err := handleDownloadTaskResult(result, dfc, task.partNumber, input.EnableCheckpoint, input.CheckpointStore, input.CheckpointFile, lock)
 			if err != nil && atomic.CompareAndSwapInt32(&errFlag, 0, 1) {
 				downloadPartError.Store(err)
 			}
@@ -870,5 +1078,5 @@ func (OSSClient OSSClient) downloadFileConcurrent(input *DownloadFileInput, dfc
 		return err
 	}
 
-	return nil
+	return ctx.Err()
 }
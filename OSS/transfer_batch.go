@@ -0,0 +1,338 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// BatchOperation is the operation applied to each key by RunBatchJob, Execute is called concurrently.
+type BatchOperation interface {
+	Execute(ctx context.Context, client *OSSClient, bucket, key string) error
+}
+
+// batchOperationWithExtensions is implemented by the operations of this SDK, which pass the extensions
+// of RunBatchJob to the APIs they call.
+type batchOperationWithExtensions interface {
+	execute(client *OSSClient, bucket, key string, extensions []extensionOptions) error
+}
+
+// BatchOperationFunc adapts a function to a BatchOperation
+type BatchOperationFunc func(ctx context.Context, client *OSSClient, bucket, key string) error
+
+// Execute implements BatchOperation.
+func (fn BatchOperationFunc) Execute(ctx context.Context, client *OSSClient, bucket, key string) error {
+	return fn(ctx, client, bucket, key)
+}
+
+func executeWithContext(operation batchOperationWithExtensions, ctx context.Context, client *OSSClient, bucket, key string) error {
+	return operation.execute(client, bucket, key, []extensionOptions{WithContext(ctx)})
+}
+
+// BatchCopyObject copies each key to the same key in Input.Bucket, which defaults to the bucket of the job.
+// The other fields of Input are kept, so an in-place copy with StorageClass changes the storage class.
+type BatchCopyObject struct {
+	Input CopyObjectInput
+}
+
+// Execute implements BatchOperation.
+func (operation *BatchCopyObject) Execute(ctx context.Context, client *OSSClient, bucket, key string) error {
+	return executeWithContext(operation, ctx, client, bucket, key)
+}
+
+func (operation *BatchCopyObject) execute(client *OSSClient, bucket, key string, extensions []extensionOptions) error {
+	input := operation.Input
+	if input.Bucket == "" {
+		input.Bucket = bucket
+	}
+	input.Key = key
+	input.CopySourceBucket = bucket
+	input.CopySourceKey = key
+	_, err := client.CopyObject(&input, extensions...)
+	return err
+}
+
+// BatchSetObjectMetadata sets the metadata of each key with Input.
+type BatchSetObjectMetadata struct {
+	Input SetObjectMetadataInput
+}
+
+// Execute implements BatchOperation.
+func (operation *BatchSetObjectMetadata) Execute(ctx context.Context, client *OSSClient, bucket, key string) error {
+	return executeWithContext(operation, ctx, client, bucket, key)
+}
+
+func (operation *BatchSetObjectMetadata) execute(client *OSSClient, bucket, key string, extensions []extensionOptions) error {
+	input := operation.Input
+	input.Bucket = bucket
+	input.Key = key
+	_, err := client.SetObjectMetadata(&input, extensions...)
+	return err
+}
+
+// BatchSetObjectAcl sets the ACL of each key with Input.
+type BatchSetObjectAcl struct {
+	Input SetObjectAclInput
+}
+
+// Execute implements BatchOperation.
+func (operation *BatchSetObjectAcl) Execute(ctx context.Context, client *OSSClient, bucket, key string) error {
+	return executeWithContext(operation, ctx, client, bucket, key)
+}
+
+func (operation *BatchSetObjectAcl) execute(client *OSSClient, bucket, key string, extensions []extensionOptions) error {
+	input := operation.Input
+	input.Bucket = bucket
+	input.Key = key
+	_, err := client.SetObjectAcl(&input, extensions...)
+	return err
+}
+
+// BatchRestoreObject restores each key with Input.
+type BatchRestoreObject struct {
+	Input RestoreObjectInput
+}
+
+// Execute implements BatchOperation.
+func (operation *BatchRestoreObject) Execute(ctx context.Context, client *OSSClient, bucket, key string) error {
+	return executeWithContext(operation, ctx, client, bucket, key)
+}
+
+func (operation *BatchRestoreObject) execute(client *OSSClient, bucket, key string, extensions []extensionOptions) error {
+	input := operation.Input
+	input.Bucket = bucket
+	input.Key = key
+	_, err := client.RestoreObject(&input, extensions...)
+	return err
+}
+
+// batchResult is a record of the results manifest
+type batchResult struct {
+	Key    string                `json:"key"`
+	Status BatchResultStatusType `json:"status,omitempty"`
+	Error  string                `json:"error,omitempty"`
+}
+
+var batchResultHeader = []string{"Key", "Status", "Error"}
+
+func isJSONLManifest(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".jsonl")
+}
+
+// readBatchManifest calls fn for each key of the manifest file, only the failed keys if failedOnly is set.
+func readBatchManifest(ctx context.Context, path string, failedOnly bool, fn func(key string)) (err error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		errMsg := fd.Close()
+		if errMsg != nil {
+			doLog(LEVEL_WARN, "Failed to close manifest file with reason: %v", errMsg)
+		}
+	}()
+
+	read := func(record batchResult) {
+		if record.Key != "" && (!failedOnly || record.Status == BatchResultFailed) {
+			fn(record.Key)
+		}
+	}
+	if isJSONLManifest(path) {
+		scanner := bufio.NewScanner(fd)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			record := batchResult{}
+			if err = json.Unmarshal([]byte(line), &record); err != nil {
+				return err
+			}
+			read(record)
+		}
+		return scanner.Err()
+	}
+
+	reader := csv.NewReader(fd)
+	reader.FieldsPerRecord = -1
+	for first := true; ; first = false {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		fields, _err := reader.Read()
+		if _err == io.EOF {
+			return nil
+		}
+		if _err != nil {
+			return _err
+		}
+		if first && len(fields) >= 2 && fields[0] == batchResultHeader[0] && fields[1] == batchResultHeader[1] {
+			continue
+		}
+		record := batchResult{Key: fields[0]}
+		if len(fields) >= 2 {
+			record.Status = BatchResultStatusType(fields[1])
+		}
+		read(record)
+	}
+}
+
+// batchResultWriter writes the results manifest, it is safe for concurrent use.
+type batchResultWriter struct {
+	lock      sync.Mutex
+	fd        *os.File
+	csvWriter *csv.Writer
+	encoder   *json.Encoder
+}
+
+func newBatchResultWriter(path string) (*batchResultWriter, error) {
+	fd, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer := &batchResultWriter{fd: fd}
+	if isJSONLManifest(path) {
+		writer.encoder = json.NewEncoder(fd)
+		return writer, nil
+	}
+	writer.csvWriter = csv.NewWriter(fd)
+	if err = writer.csvWriter.Write(batchResultHeader); err == nil {
+		writer.csvWriter.Flush()
+		err = writer.csvWriter.Error()
+	}
+	if err != nil {
+		writer.close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *batchResultWriter) write(record batchResult) error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if writer.encoder != nil {
+		return writer.encoder.Encode(&record)
+	}
+	if err := writer.csvWriter.Write([]string{record.Key, string(record.Status), record.Error}); err != nil {
+		return err
+	}
+	writer.csvWriter.Flush()
+	return writer.csvWriter.Error()
+}
+
+func (writer *batchResultWriter) close() error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	return writer.fd.Close()
+}
+
+// executeBatchOperation executes the operation on the key, and retries it as the retry policy of the client decides.
+func (OSSClient OSSClient) executeBatchOperation(ctx context.Context, input *BatchJobInput, key string, extensions []extensionOptions) (err error) {
+	retryPolicy := OSSClient.conf.retryPolicy
+	for attempt := 0; ; attempt++ {
+		if operation, ok := input.Operation.(batchOperationWithExtensions); ok {
+			err = operation.execute(&OSSClient, input.Bucket, key, extensions)
+		} else {
+			err = input.Operation.Execute(ctx, &OSSClient, input.Bucket, key)
+		}
+		if err == nil || attempt >= input.MaxRetries || ctx.Err() != nil || !retryPolicy.ShouldRetry(attempt, err) {
+			return
+		}
+		doLog(LEVEL_WARN, "Failed to execute batch operation on key [%s] with error [%v], retry it.", key, err)
+		if !sleepWithContext(ctx, retryPolicy.RetryDelay(attempt, err)) {
+			return
+		}
+	}
+}
+
+func (OSSClient OSSClient) walkBatchKeys(ctx context.Context, input *BatchJobInput, extensions []extensionOptions, fn func(key string)) error {
+	if input.Keys != nil {
+		for _, key := range input.Keys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			fn(key)
+		}
+		return nil
+	}
+	if input.ManifestFile != "" {
+		return readBatchManifest(ctx, input.ManifestFile, input.RetryFailed, fn)
+	}
+	return OSSClient.walkPrefixObjects(ctx, input.Bucket, input.Prefix, nil, nil, extensions, func(relKey string, content Content) {
+		fn(content.Key)
+	})
+}
+
+func (OSSClient OSSClient) runBatchJob(input *BatchJobInput, extensions []extensionOptions) (output *BatchJobOutput, err error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	output = &BatchJobOutput{FailedKeys: make(map[string]error)}
+	var writer *batchResultWriter
+	if input.ResultFile != "" {
+		if writer, err = newBatchResultWriter(input.ResultFile); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if errMsg := writer.close(); errMsg != nil && err == nil {
+				err = errMsg
+			}
+		}()
+	}
+
+	var lock sync.Mutex
+	var writeErr error
+	pool := NewRoutinePool(input.TaskNum, 0)
+	err = OSSClient.walkBatchKeys(ctx, input, extensions, func(key string) {
+		pool.ExecuteFunc(func() interface{} {
+			err := OSSClient.executeBatchOperation(ctx, input, key, extensions)
+			record := batchResult{Key: key, Status: BatchResultSucceeded}
+			if err != nil {
+				doLog(LEVEL_WARN, "Failed to execute batch operation on key [%s] with error [%v].", key, err)
+				record.Status = BatchResultFailed
+				record.Error = err.Error()
+			}
+			var _err error
+			if writer != nil {
+				_err = writer.write(record)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				output.FailedKeys[key] = err
+			} else {
+				output.SucceededCount++
+			}
+			if _err != nil && writeErr == nil {
+				writeErr = _err
+			}
+			return nil
+		})
+	})
+	pool.ShutDown()
+	if err == nil {
+		err = writeErr
+	}
+	return output, err
+}
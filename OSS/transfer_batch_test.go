@@ -0,0 +1,130 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRunBatchJob(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	for _, key := range []string{"data/a", "data/b", "data/c"} {
+		fs.putObject("bucket", key, []byte(key))
+	}
+	fs.putObject("bucket", "other", []byte("other"))
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPut && r.URL.Path == "/dst/data/b" {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	resultFile := filepath.Join(t.TempDir(), "results.csv")
+	operation := &BatchCopyObject{}
+	operation.Input.Bucket = "dst"
+	input := &BatchJobInput{Bucket: "bucket", Prefix: "data/", Operation: operation, TaskNum: 2, ResultFile: resultFile}
+	output, err := client.RunBatchJob(input)
+	if err != nil {
+		t.Fatalf("RunBatchJob failed: %v", err)
+	}
+	if _, ok := output.FailedKeys["data/b"]; output.SucceededCount != 2 || len(output.FailedKeys) != 1 || !ok {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	if keys := fs.objectKeys("dst"); !reflect.DeepEqual(keys, []string{"data/a", "data/c"}) {
+		t.Fatalf("unexpected copies %v", keys)
+	}
+	results, err := os.ReadFile(resultFile)
+	if err != nil || !strings.HasPrefix(string(results), "Key,Status,Error\n") || !strings.Contains(string(results), "data/b,Failed,") {
+		t.Fatalf("unexpected results manifest %q, err: %v", results, err)
+	}
+
+	// the failed keys of the results manifest are retried
+	fs.setHook(nil)
+	retryInput := &BatchJobInput{Bucket: "bucket", ManifestFile: resultFile, RetryFailed: true, Operation: input.Operation,
+		ResultFile: filepath.Join(t.TempDir(), "retry.jsonl")}
+	before := fs.countRequests(func(r *http.Request) bool { return r.Method == http.MethodPut })
+	if output, err = client.RunBatchJob(retryInput); err != nil || output.SucceededCount != 1 || len(output.FailedKeys) != 0 {
+		t.Fatalf("unexpected retry %+v, %v", output, err)
+	}
+	if copies := fs.countRequests(func(r *http.Request) bool { return r.Method == http.MethodPut }) - before; copies != 1 {
+		t.Fatalf("expected only the failed key to be copied, got %d copies", copies)
+	}
+	if results, err := os.ReadFile(retryInput.ResultFile); err != nil || strings.TrimSpace(string(results)) != `{"key":"data/b","status":"Succeeded"}` {
+		t.Fatalf("unexpected results manifest %q, err: %v", results, err)
+	}
+}
+
+func TestRunBatchJobRetries(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	manifest := filepath.Join(t.TempDir(), "keys.jsonl")
+	if err := os.WriteFile(manifest, []byte("{\"key\":\"busy\"}\n\n{\"key\":\"denied\"}\n{\"key\":\"ok\"}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	var attempts []string
+	operation := BatchOperationFunc(func(ctx context.Context, client *OSSClient, bucket, key string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts = append(attempts, key)
+		// the keys are processed one by one, so busy fails in the first attempt only
+		switch {
+		case key == "busy" && len(attempts) == 1:
+			return OSSError{BaseModel: BaseModel{StatusCode: http.StatusServiceUnavailable}, Code: "ServiceUnavailable"}
+		case key == "denied":
+			return OSSError{BaseModel: BaseModel{StatusCode: http.StatusForbidden}, Code: "AccessDenied"}
+		}
+		return nil
+	})
+
+	input := &BatchJobInput{Bucket: "bucket", ManifestFile: manifest, Operation: operation, MaxRetries: 2}
+	output, err := client.RunBatchJob(input)
+	if err != nil {
+		t.Fatalf("RunBatchJob failed: %v", err)
+	}
+	if _, ok := output.FailedKeys["denied"]; output.SucceededCount != 2 || len(output.FailedKeys) != 1 || !ok {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	sort.Strings(attempts)
+	if expected := []string{"busy", "busy", "denied", "ok"}; !reflect.DeepEqual(attempts, expected) {
+		t.Fatalf("unexpected attempts %v", attempts)
+	}
+}
+
+func TestRunBatchJobInvalidInput(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	operation := &BatchSetObjectAcl{Input: SetObjectAclInput{ACL: AclPrivate}}
+	inputs := []*BatchJobInput{
+		nil,
+		{Bucket: "bucket"},
+		{Bucket: "bucket", Operation: operation, Keys: []string{"a"}, ManifestFile: "keys.csv"},
+		{Bucket: "bucket", Operation: operation, ManifestFile: "keys.csv", ResultFile: "keys.csv"},
+	}
+	for _, input := range inputs {
+		if _, err := client.RunBatchJob(input); err == nil {
+			t.Fatalf("expected the input %+v to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// walkMultipartUploads calls fn for each multipart upload of the prefix in the bucket, page by page.
+func (OSSClient OSSClient) walkMultipartUploads(ctx context.Context, bucket, prefix string, extensions []extensionOptions,
+	fn func(upload Upload)) error {
+	listInput := &ListMultipartUploadsInput{}
+	listInput.Bucket = bucket
+	listInput.Prefix = prefix
+	paginator := NewListMultipartUploadsPaginator(&OSSClient, listInput, extensions...)
+	for paginator.HasNext() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		listOutput, err := paginator.Next()
+		if err != nil {
+			return err
+		}
+		for _, upload := range listOutput.Uploads {
+			fn(upload)
+		}
+	}
+	return nil
+}
+
+// countUploadParts returns the number and the total size of the parts uploaded to the multipart upload.
+func (OSSClient OSSClient) countUploadParts(ctx context.Context, bucket string, upload Upload,
+	extensions []extensionOptions) (partCount int, size int64, err error) {
+	listInput := &ListPartsInput{}
+	listInput.Bucket = bucket
+	listInput.Key = upload.Key
+	listInput.UploadId = upload.UploadId
+	paginator := NewListPartsPaginator(&OSSClient, listInput, extensions...)
+	for paginator.HasNext() {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		listOutput, _err := paginator.Next()
+		if _err != nil {
+			return partCount, size, _err
+		}
+		for _, part := range listOutput.Parts {
+			partCount++
+			size += part.Size
+		}
+	}
+	return
+}
+
+func (OSSClient OSSClient) cleanupMultipartUpload(ctx context.Context, input *CleanupMultipartUploadsInput, upload Upload,
+	extensions []extensionOptions) CleanedMultipartUpload {
+	result := CleanedMultipartUpload{Key: upload.Key, UploadId: upload.UploadId, Initiated: upload.Initiated}
+	var err error
+	result.PartCount, result.Size, err = OSSClient.countUploadParts(ctx, input.Bucket, upload, extensions)
+	if err != nil {
+		if input.DryRun {
+			result.Err = err
+			return result
+		}
+		// the upload is aborted anyway, only the reclaimed parts are unknown
+		doLog(LEVEL_WARN, "Failed to list parts of upload [%s] with error [%v].", upload.UploadId, err)
+	}
+	if input.DryRun {
+		return result
+	}
+
+	abortInput := &AbortMultipartUploadInput{}
+	abortInput.Bucket = input.Bucket
+	abortInput.Key = upload.Key
+	abortInput.UploadId = upload.UploadId
+	_, result.Err = OSSClient.AbortMultipartUpload(abortInput, extensions...)
+	return result
+}
+
+// cleanupMultipartUploads aborts the multipart uploads older than input.OlderThan, or all of them if allUploads is set.
+// onResult is called with the lock held if it is not nil.
+func (OSSClient OSSClient) cleanupMultipartUploads(input *CleanupMultipartUploadsInput, allUploads bool,
+	onResult func(result CleanedMultipartUpload), extensions []extensionOptions) (*CleanupMultipartUploadsOutput, error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	initiatedBefore := time.Now().Add(-input.OlderThan)
+	output := &CleanupMultipartUploadsOutput{}
+	var lock sync.Mutex
+
+	pool := NewRoutinePool(input.TaskNum, 0)
+	err := OSSClient.walkMultipartUploads(ctx, input.Bucket, input.Prefix, extensions, func(upload Upload) {
+		if !allUploads && !upload.Initiated.Before(initiatedBefore) {
+			return
+		}
+		pool.ExecuteFunc(func() interface{} {
+			result := OSSClient.cleanupMultipartUpload(ctx, input, upload, extensions)
+			lock.Lock()
+			defer lock.Unlock()
+			output.Uploads = append(output.Uploads, result)
+			if onResult != nil {
+				onResult(result)
+			}
+			if result.Err != nil {
+				doLog(LEVEL_WARN, "Failed to clean up upload [%s] of key [%s] with error [%v].", result.UploadId, result.Key, result.Err)
+				output.FailedCount++
+				return nil
+			}
+			if !input.DryRun {
+				output.AbortedCount++
+			}
+			output.ReclaimedParts += result.PartCount
+			output.ReclaimedBytes += result.Size
+			return nil
+		})
+	})
+	pool.ShutDown()
+
+	sort.Slice(output.Uploads, func(i, j int) bool {
+		if output.Uploads[i].Key != output.Uploads[j].Key {
+			return output.Uploads[i].Key < output.Uploads[j].Key
+		}
+		return output.Uploads[i].UploadId < output.Uploads[j].UploadId
+	})
+	return output, err
+}
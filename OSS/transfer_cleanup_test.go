@@ -0,0 +1,70 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"testing"
+	"time"
+)
+
+// addUploadParts adds a multipart upload with the parts of the sizes to the server.
+func addUploadParts(fs *fakeServer, bucket, key string, initiated time.Time, sizes ...int) string {
+	uploadID := fs.addUpload(bucket, key, initiated)
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	for i, size := range sizes {
+		fs.uploads[uploadID].parts[i+1] = make([]byte, size)
+	}
+	return uploadID
+}
+
+func TestCleanupMultipartUploads(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	old := time.Now().Add(-48 * time.Hour)
+	addUploadParts(fs, "bucket", "tmp/a", old, 10, 20)
+	addUploadParts(fs, "bucket", "tmp/b", old)
+	addUploadParts(fs, "bucket", "tmp/c", time.Now(), 30)
+	addUploadParts(fs, "bucket", "other/d", old, 40)
+
+	// OlderThan defaults to 24 hours, so the upload in progress is kept
+	input := &CleanupMultipartUploadsInput{Bucket: "bucket", Prefix: "tmp/", TaskNum: 2, DryRun: true}
+	output, err := client.CleanupMultipartUploads(input)
+	if err != nil {
+		t.Fatalf("CleanupMultipartUploads failed: %v", err)
+	}
+	if len(output.Uploads) != 2 || output.Uploads[0].Key != "tmp/a" || output.Uploads[1].Key != "tmp/b" {
+		t.Fatalf("unexpected uploads %+v", output.Uploads)
+	}
+	if output.AbortedCount != 0 || output.ReclaimedParts != 2 || output.ReclaimedBytes != 30 || fs.uploadCount() != 4 {
+		t.Fatalf("unexpected dry run %+v", output)
+	}
+
+	input.DryRun = false
+	output, err = client.CleanupMultipartUploads(input)
+	if err != nil {
+		t.Fatalf("CleanupMultipartUploads failed: %v", err)
+	}
+	if output.AbortedCount != 2 || output.FailedCount != 0 || output.ReclaimedBytes != 30 || fs.uploadCount() != 2 {
+		t.Fatalf("unexpected output %+v", output)
+	}
+
+	input.OlderThan = time.Millisecond
+	if output, err = client.CleanupMultipartUploads(input); err != nil || output.AbortedCount != 1 || fs.uploadCount() != 1 {
+		t.Fatalf("unexpected output %+v, %v", output, err)
+	}
+	input.OlderThan = -time.Second
+	if _, err = client.CleanupMultipartUploads(input); err == nil {
+		t.Fatal("expected the negative OlderThan to be rejected")
+	}
+}
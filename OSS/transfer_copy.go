@@ -0,0 +1,470 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// CopyPartInfo defines the copy part properties
+type CopyPartInfo struct {
+	XMLName     xml.Name `xml:"CopyPart"`
+	PartNumber  int      `xml:"PartNumber"`
+	Etag        string   `xml:"Etag"`
+	RangeStart  int64    `xml:"RangeStart"`
+	RangeEnd    int64    `xml:"RangeEnd"`
+	IsCompleted bool     `xml:"IsCompleted"`
+}
+
+// CopyCheckpoint defines the copy checkpoint file properties
+type CopyCheckpoint struct {
+	XMLName             xml.Name       `xml:"CopyFileCheckpoint"`
+	Bucket              string         `xml:"Bucket"`
+	Key                 string         `xml:"Key"`
+	UploadId            string         `xml:"UploadId,omitempty"`
+	CopySourceBucket    string         `xml:"CopySourceBucket"`
+	CopySourceKey       string         `xml:"CopySourceKey"`
+	CopySourceVersionId string         `xml:"CopySourceVersionId,omitempty"`
+	ObjectInfo          ObjectInfo     `xml:"ObjectInfo"`
+	CopyParts           []CopyPartInfo `xml:"CopyParts>CopyPart"`
+}
+
+func (cfc *CopyCheckpoint) isValid(input *CopyFileInput, output *GetObjectMetadataOutput) bool {
+	if cfc.Bucket != input.Bucket || cfc.Key != input.Key || cfc.CopySourceBucket != input.CopySourceBucket ||
+		cfc.CopySourceKey != input.CopySourceKey || cfc.CopySourceVersionId != input.CopySourceVersionId {
+		doLog(LEVEL_INFO, "Checkpoint file is invalid, the bucketName or objectKey or copy source was changed. clear the record.")
+		return false
+	}
+	if cfc.ObjectInfo.LastModified != output.LastModified.Unix() || cfc.ObjectInfo.ETag != output.ETag || cfc.ObjectInfo.Size != output.ContentLength {
+		doLog(LEVEL_INFO, "Checkpoint file is invalid, the source object info was changed. clear the record.")
+		return false
+	}
+	if cfc.UploadId == "" {
+		doLog(LEVEL_INFO, "UploadId is invalid. clear the record.")
+		return false
+	}
+	return true
+}
+
+func (cfc *CopyCheckpoint) completedBytes() (completedBytes int64) {
+	for _, copyPart := range cfc.CopyParts {
+		if copyPart.IsCompleted {
+			completedBytes += copyPart.RangeEnd - copyPart.RangeStart + 1
+		}
+	}
+	return
+}
+
+type copyPartTask struct {
+	CopyPartInput
+	ctx              context.Context
+	OSSClient        *OSSClient
+	tracker          *progressTracker
+	abort            *int32
+	extensions       []extensionOptions
+	enableCheckpoint bool
+}
+
+func (task *copyPartTask) Run() interface{} {
+	if atomic.LoadInt32(task.abort) == 1 {
+		return errAbort
+	}
+	if err := task.ctx.Err(); err != nil {
+		return err
+	}
+
+	output, err := task.OSSClient.CopyPart(&task.CopyPartInput, task.extensions...)
+	if err == nil {
+		if output.ETag == "" {
+			doLog(LEVEL_WARN, "Get invalid etag value after copying part [%d].", task.PartNumber)
+			if !task.enableCheckpoint {
+				atomic.CompareAndSwapInt32(task.abort, 0, 1)
+				doLog(LEVEL_WARN, "Task is aborted, part number is [%d]", task.PartNumber)
+			}
+			return fmt.Errorf("get invalid etag value after copying part [%d]", task.PartNumber)
+		}
+		task.tracker.add(task.CopySourceRangeEnd - task.CopySourceRangeStart + 1)
+		return output
+	} else if OSSError, ok := err.(OSSError); ok && OSSError.StatusCode >= 400 && OSSError.StatusCode < 500 {
+		atomic.CompareAndSwapInt32(task.abort, 0, 1)
+		doLog(LEVEL_WARN, "Task is aborted, part number is [%d]", task.PartNumber)
+	}
+	return err
+}
+
+func getCopyCheckpointFile(cfc *CopyCheckpoint, input *CopyFileInput, output *GetObjectMetadataOutput, OSSClient *OSSClient, extensions []extensionOptions) (needCheckpoint bool, err error) {
+	checkpointFilePath := input.CheckpointFile
+	err = loadCheckpointFile(input.CheckpointStore, checkpointFilePath, cfc)
+	if err == ErrCheckpointNotFound {
+		doLog(LEVEL_DEBUG, fmt.Sprintf("Checkpoint file [%s] is not found.", checkpointFilePath))
+		return true, nil
+	}
+	if err == errCheckpointIsFolder {
+		doLog(LEVEL_ERROR, "Checkpoint file can not be a folder.")
+		return false, err
+	}
+	if err != nil {
+		doLog(LEVEL_WARN, fmt.Sprintf("Load checkpoint file failed with error: [%v].", err))
+		return true, nil
+	} else if !cfc.isValid(input, output) {
+		if cfc.Bucket != "" && cfc.Key != "" && cfc.UploadId != "" {
+			_err := abortTask(cfc.Bucket, cfc.Key, cfc.UploadId, OSSClient, extensions)
+			if _err != nil {
+				doLog(LEVEL_WARN, "Failed to abort upload task [%s].", cfc.UploadId)
+			}
+		}
+		_err := removeCheckpointFile(input.CheckpointStore, checkpointFilePath)
+		if _err != nil {
+			doLog(LEVEL_WARN, fmt.Sprintf("Failed to remove checkpoint file with error: [%v].", _err))
+		}
+	} else {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func sliceCopyObject(objectSize, partSize int64, cfc *CopyCheckpoint) error {
+	cnt := objectSize / partSize
+	if cnt >= MAX_PART_NUM {
+		partSize = objectSize / MAX_PART_NUM
+		if objectSize%MAX_PART_NUM != 0 {
+			partSize++
+		}
+		cnt = objectSize / partSize
+	}
+	if objectSize%partSize != 0 {
+		cnt++
+	}
+	if cnt > 1 && objectSize%partSize == 1 {
+		// a range of a single byte can not be expressed by CopyPartInput, and merging it into the previous
+		// part may exceed MAX_PART_SIZE, so the object is sliced into the same number of smaller parts
+		partSize = (objectSize + cnt - 1) / cnt
+	}
+
+	if partSize > MAX_PART_SIZE {
+		doLog(LEVEL_ERROR, "The source object is too large")
+		return fmt.Errorf("The source object is too large")
+	}
+
+	copyParts := make([]CopyPartInfo, 0, cnt)
+	var i int64
+	for i = 0; i < cnt; i++ {
+		copyPart := CopyPartInfo{}
+		copyPart.PartNumber = int(i) + 1
+		copyPart.RangeStart = i * partSize
+		copyPart.RangeEnd = (i+1)*partSize - 1
+		copyParts = append(copyParts, copyPart)
+	}
+	copyParts[cnt-1].RangeEnd = objectSize - 1
+	cfc.CopyParts = copyParts
+	return nil
+}
+
+func prepareCopy(cfc *CopyCheckpoint, input *CopyFileInput, sourceOutput *GetObjectMetadataOutput, OSSClient *OSSClient, extensions []extensionOptions) error {
+	initiateInput := &InitiateMultipartUploadInput{}
+	initiateInput.ObjectOperationInput = input.ObjectOperationInput
+	initiateInput.ContentType = input.ContentType
+	initiateInput.EncodingType = input.EncodingType
+	if input.MetadataDirective != ReplaceMetadata {
+		initiateInput.Metadata = sourceOutput.Metadata
+		initiateInput.ContentType = sourceOutput.ContentType
+		if initiateInput.WebsiteRedirectLocation == "" {
+			initiateInput.WebsiteRedirectLocation = sourceOutput.WebsiteRedirectLocation
+		}
+	}
+	output, err := OSSClient.InitiateMultipartUpload(initiateInput, extensions...)
+	if err != nil {
+		return err
+	}
+
+	cfc.Bucket = input.Bucket
+	cfc.Key = input.Key
+	cfc.UploadId = output.UploadId
+	cfc.CopySourceBucket = input.CopySourceBucket
+	cfc.CopySourceKey = input.CopySourceKey
+	cfc.CopySourceVersionId = input.CopySourceVersionId
+	cfc.ObjectInfo = ObjectInfo{}
+	cfc.ObjectInfo.LastModified = sourceOutput.LastModified.Unix()
+	cfc.ObjectInfo.Size = sourceOutput.ContentLength
+	cfc.ObjectInfo.ETag = sourceOutput.ETag
+
+	return sliceCopyObject(sourceOutput.ContentLength, input.PartSize, cfc)
+}
+
+// copyObjectOnce copies an object not larger than one part with a single CopyObject request.
+func (OSSClient OSSClient) copyObjectOnce(input *CopyFileInput, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	copyInput := &CopyObjectInput{}
+	copyInput.ObjectOperationInput = input.ObjectOperationInput
+	copyInput.CopySourceBucket = input.CopySourceBucket
+	copyInput.CopySourceKey = input.CopySourceKey
+	copyInput.CopySourceVersionId = input.CopySourceVersionId
+	copyInput.SourceSseHeader = input.SourceSseHeader
+	copyInput.MetadataDirective = CopyMetadata
+	if input.MetadataDirective == ReplaceMetadata {
+		copyInput.MetadataDirective = ReplaceMetadata
+		copyInput.ContentType = input.ContentType
+	}
+	copyOutput, err := OSSClient.CopyObject(copyInput, extensions...)
+	if err != nil {
+		return nil, err
+	}
+
+	output = &CompleteMultipartUploadOutput{}
+	output.BaseModel = copyOutput.BaseModel
+	output.VersionId = copyOutput.VersionId
+	output.SseHeader = copyOutput.SseHeader
+	output.Bucket = input.Bucket
+	output.Key = input.Key
+	output.ETag = copyOutput.ETag
+	return
+}
+
+// copySourceAcl applies the ACL of the source object to the destination object.
+func (OSSClient OSSClient) copySourceAcl(input *CopyFileInput, versionID string, extensions []extensionOptions) error {
+	getAclInput := &GetObjectAclInput{}
+	getAclInput.Bucket = input.CopySourceBucket
+	getAclInput.Key = input.CopySourceKey
+	getAclInput.VersionId = input.CopySourceVersionId
+	return OSSClient.copyObjectAcl(&OSSClient, getAclInput, input.Bucket, input.Key, versionID, extensions)
+}
+
+// copyObjectAcl applies the ACL of the source object read by sourceClient to the destination object,
+// the returned error is an AclCopyError.
+func (OSSClient OSSClient) copyObjectAcl(sourceClient *OSSClient, sourceInput *GetObjectAclInput, bucket, key, versionID string,
+	extensions []extensionOptions) error {
+	policy, err := OSSClient.destinationAcl(sourceClient, sourceInput, bucket, key, versionID, extensions)
+	if err == nil {
+		setAclInput := &SetObjectAclInput{}
+		setAclInput.Bucket = bucket
+		setAclInput.Key = key
+		setAclInput.VersionId = versionID
+		setAclInput.AccessControlPolicy = *policy
+		_, err = OSSClient.SetObjectAcl(setAclInput, extensions...)
+	}
+	if err != nil {
+		return AclCopyError{Bucket: bucket, Key: key, Err: err}
+	}
+	return nil
+}
+
+// destinationAcl returns the ACL of the source object for the destination object. If the objects have different
+// owners, such as the objects replicated to another account, the destination object keeps its owner, the grants
+// to the owner of the source object are given to it instead, and the grants to other accounts are dropped.
+func (OSSClient OSSClient) destinationAcl(sourceClient *OSSClient, sourceInput *GetObjectAclInput, bucket, key, versionID string,
+	extensions []extensionOptions) (*AccessControlPolicy, error) {
+	sourceOutput, err := sourceClient.GetObjectAcl(sourceInput, extensions...)
+	if err != nil {
+		return nil, err
+	}
+	getAclInput := &GetObjectAclInput{}
+	getAclInput.Bucket = bucket
+	getAclInput.Key = key
+	getAclInput.VersionId = versionID
+	getAclOutput, err := OSSClient.GetObjectAcl(getAclInput, extensions...)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := sourceOutput.AccessControlPolicy
+	owner := getAclOutput.Owner
+	if policy.Owner.ID == owner.ID {
+		return &policy, nil
+	}
+	policy.Grants = make([]Grant, 0, len(sourceOutput.Grants))
+	for _, grant := range sourceOutput.Grants {
+		if grant.Grantee.ID != "" {
+			if grant.Grantee.ID != sourceOutput.Owner.ID {
+				doLog(LEVEL_WARN, "Drop the grant of object [%s] to the account [%s] of another owner.", key, grant.Grantee.ID)
+				continue
+			}
+			grant.Grantee.ID = owner.ID
+			grant.Grantee.DisplayName = owner.DisplayName
+		}
+		policy.Grants = append(policy.Grants, grant)
+	}
+	policy.Owner = owner
+	return &policy, nil
+}
+
+func (OSSClient OSSClient) resumeCopy(input *CopyFileInput, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	listener, extensions := splitProgressListener(extensions, input.ProgressListener)
+	metadataInput := &GetObjectMetadataInput{}
+	metadataInput.Bucket = input.CopySourceBucket
+	metadataInput.Key = input.CopySourceKey
+	metadataInput.VersionId = input.CopySourceVersionId
+	metadataInput.SseHeader = input.SourceSseHeader
+	sourceOutput, err := OSSClient.GetObjectMetadata(metadataInput, extensions...)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracker *progressTracker
+	if sourceOutput.ContentLength <= input.PartSize {
+		tracker = newProgressTracker(listener, 0, sourceOutput.ContentLength)
+		tracker.started()
+		output, err = OSSClient.copyObjectOnce(input, extensions)
+		if err == nil {
+			tracker.add(sourceOutput.ContentLength)
+		}
+	} else {
+		cfc, _err := OSSClient.getCopyCheckpoint(input, sourceOutput, extensions)
+		if _err != nil {
+			return nil, _err
+		}
+		tracker = newProgressTracker(listener, cfc.completedBytes(), cfc.ObjectInfo.Size)
+		tracker.started()
+		output, err = OSSClient.copyParts(cfc, input, tracker, extensions)
+	}
+
+	if err == nil && input.CopySourceACL {
+		if err = OSSClient.copySourceAcl(input, output.VersionId, extensions); err != nil {
+			doLog(LEVEL_ERROR, "Copy file successfully, but copy the acl of the source object failed with error [%v].", err)
+		}
+	}
+	if err != nil {
+		tracker.failed()
+		return output, err
+	}
+	tracker.completed()
+	return output, nil
+}
+
+func (OSSClient OSSClient) getCopyCheckpoint(input *CopyFileInput, sourceOutput *GetObjectMetadataOutput, extensions []extensionOptions) (cfc *CopyCheckpoint, err error) {
+	cfc = &CopyCheckpoint{}
+
+	var needCheckpoint = true
+	var checkpointFilePath = input.CheckpointFile
+	var enableCheckpoint = input.EnableCheckpoint
+	if enableCheckpoint {
+		needCheckpoint, err = getCopyCheckpointFile(cfc, input, sourceOutput, &OSSClient, extensions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if needCheckpoint {
+		err = prepareCopy(cfc, input, sourceOutput, &OSSClient, extensions)
+		if err != nil {
+			return nil, err
+		}
+
+		if enableCheckpoint {
+			err = updateCheckpointFile(input.CheckpointStore, cfc, checkpointFilePath)
+			if err != nil {
+				doLog(LEVEL_ERROR, "Failed to update checkpoint file with error [%v].", err)
+				_err := abortTask(cfc.Bucket, cfc.Key, cfc.UploadId, &OSSClient, extensions)
+				if _err != nil {
+					doLog(LEVEL_WARN, "Failed to abort task [%s].", cfc.UploadId)
+				}
+				return nil, err
+			}
+		}
+	}
+	return cfc, nil
+}
+
+func (OSSClient OSSClient) copyParts(cfc *CopyCheckpoint, input *CopyFileInput, tracker *progressTracker, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	enableCheckpoint := input.EnableCheckpoint
+	copyPartError := OSSClient.copyPartConcurrent(cfc, input, tracker, extensions)
+	if copyPartError != nil {
+		if !enableCheckpoint {
+			_err := abortTask(cfc.Bucket, cfc.Key, cfc.UploadId, &OSSClient, extensions)
+			if _err != nil {
+				doLog(LEVEL_WARN, "Failed to abort task [%s].", cfc.UploadId)
+			}
+		}
+		return nil, copyPartError
+	}
+
+	ufc := &UploadCheckpoint{Bucket: cfc.Bucket, Key: cfc.Key, UploadId: cfc.UploadId}
+	ufc.UploadParts = make([]UploadPartInfo, 0, len(cfc.CopyParts))
+	for _, copyPart := range cfc.CopyParts {
+		ufc.UploadParts = append(ufc.UploadParts, UploadPartInfo{PartNumber: copyPart.PartNumber, Etag: copyPart.Etag})
+	}
+	return completeParts(ufc, enableCheckpoint, input.CheckpointStore, input.CheckpointFile, &OSSClient, input.EncodingType, extensions)
+}
+
+func handleCopyTaskResult(result interface{}, cfc *CopyCheckpoint, partNum int, enableCheckpoint bool, checkpointStore CheckpointStore, checkpointFilePath string, lock *sync.Mutex) (err error) {
+	if copyPartOutput, ok := result.(*CopyPartOutput); ok {
+		lock.Lock()
+		defer lock.Unlock()
+		cfc.CopyParts[partNum-1].Etag = copyPartOutput.ETag
+		cfc.CopyParts[partNum-1].IsCompleted = true
+		if enableCheckpoint {
+			_err := updateCheckpointFile(checkpointStore, cfc, checkpointFilePath)
+			if _err != nil {
+				doLog(LEVEL_WARN, "Failed to update checkpoint file with error [%v].", _err)
+			}
+		}
+	} else if result != errAbort {
+		if _err, ok := result.(error); ok {
+			err = _err
+		}
+	}
+	return
+}
+
+func (OSSClient OSSClient) copyPartConcurrent(cfc *CopyCheckpoint, input *CopyFileInput, tracker *progressTracker, extensions []extensionOptions) error {
+	ctx := OSSClient.getRequestContext(extensions)
+	pool := NewRoutinePool(input.TaskNum, MAX_PART_NUM)
+	var copyPartError atomic.Value
+	var errFlag int32
+	var abort int32
+	lock := new(sync.Mutex)
+	for _, copyPart := range cfc.CopyParts {
+		if atomic.LoadInt32(&abort) == 1 || ctx.Err() != nil {
+			break
+		}
+		if copyPart.IsCompleted {
+			continue
+		}
+		task := copyPartTask{
+			CopyPartInput: CopyPartInput{
+				Bucket:               cfc.Bucket,
+				Key:                  cfc.Key,
+				UploadId:             cfc.UploadId,
+				PartNumber:           copyPart.PartNumber,
+				CopySourceBucket:     cfc.CopySourceBucket,
+				CopySourceKey:        cfc.CopySourceKey,
+				CopySourceVersionId:  cfc.CopySourceVersionId,
+				CopySourceRangeStart: copyPart.RangeStart,
+				CopySourceRangeEnd:   copyPart.RangeEnd,
+				SseHeader:            input.SseHeader,
+				SourceSseHeader:      input.SourceSseHeader,
+			},
+			ctx:              ctx,
+			OSSClient:        &OSSClient,
+			tracker:          tracker,
+			abort:            &abort,
+			extensions:       extensions,
+			enableCheckpoint: input.EnableCheckpoint,
+		}
+		pool.ExecuteFunc(func() interface{} {
+			result := task.Run()
+			err := handleCopyTaskResult(result, cfc, task.PartNumber, input.EnableCheckpoint, input.CheckpointStore, input.CheckpointFile, lock)
+			if err != nil && atomic.CompareAndSwapInt32(&errFlag, 0, 1) {
+				copyPartError.Store(err)
+			}
+			return nil
+		})
+	}
+	pool.ShutDown()
+	if err, ok := copyPartError.Load().(error); ok {
+		return err
+	}
+	return ctx.Err()
+}
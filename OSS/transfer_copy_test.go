@@ -0,0 +1,115 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func isCopyPart(partNumber string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		return r.Method == http.MethodPut && r.URL.Query().Get("partNumber") == partNumber &&
+			r.Header.Get(HEADER_PREFIX+HEADER_COPY_SOURCE) != ""
+	}
+}
+
+func TestCopyFileSmallObject(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.putObject("source", "key", []byte("small object"))
+
+	input := &CopyFileInput{CopySourceBucket: "source", CopySourceKey: "key", PartSize: MIN_PART_SIZE}
+	input.Bucket, input.Key = "bucket", "copy"
+	if _, err := client.CopyFile(input); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	if object, ok := fs.getObject("bucket", "copy"); !ok || string(object.data) != "small object" {
+		t.Fatal("unexpected object")
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return r.URL.Query().Has("uploads") }); count != 0 {
+		t.Fatalf("expected a single CopyObject, got %d multipart uploads", count)
+	}
+}
+
+func TestCopyFileResumesFromCheckpoint(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := make([]byte, 3*MIN_PART_SIZE+7)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+	fs.putObject("source", "key", data)
+	checkpointFile := filepath.Join(t.TempDir(), "copy.checkpoint")
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if isCopyPart("3")(r) {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	newInput := func() *CopyFileInput {
+		input := &CopyFileInput{CopySourceBucket: "source", CopySourceKey: "key", PartSize: MIN_PART_SIZE,
+			EnableCheckpoint: true, CheckpointFile: checkpointFile}
+		input.Bucket, input.Key = "bucket", "copy"
+		return input
+	}
+	if _, err := client.CopyFile(newInput()); err == nil {
+		t.Fatal("expected the first copy to fail")
+	}
+	if _, err := os.Stat(checkpointFile); err != nil {
+		t.Fatalf("expected the checkpoint to be kept: %v", err)
+	}
+
+	fs.setHook(nil)
+	if _, err := client.CopyFile(newInput()); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	if object, ok := fs.getObject("bucket", "copy"); !ok || !bytes.Equal(object.data, data) {
+		t.Fatal("unexpected object")
+	}
+	if count := fs.countRequests(isCopyPart("1")); count != 1 {
+		t.Fatalf("expected the completed part to be skipped, it was copied %d times", count)
+	}
+	if _, err := os.Stat(checkpointFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be removed: %v", err)
+	}
+}
+
+func TestSliceCopyObjectSingleByteTail(t *testing.T) {
+	for _, c := range []struct{ objectSize, partSize int64 }{
+		{2*MAX_PART_SIZE + 1, MAX_PART_SIZE},
+		{3*MIN_PART_SIZE + 1, MIN_PART_SIZE},
+		{MAX_PART_NUM*MIN_PART_SIZE + 1, MIN_PART_SIZE},
+	} {
+		cfc := &CopyCheckpoint{}
+		if err := sliceCopyObject(c.objectSize, c.partSize, cfc); err != nil {
+			t.Fatalf("sliceCopyObject failed: %v", err)
+		}
+		var next int64
+		for _, part := range cfc.CopyParts {
+			size := part.RangeEnd - part.RangeStart + 1
+			if part.RangeStart != next || size <= 1 || size > MAX_PART_SIZE {
+				t.Fatalf("size %d: unexpected part %+v", c.objectSize, part)
+			}
+			next = part.RangeEnd + 1
+		}
+		if next != c.objectSize || len(cfc.CopyParts) > MAX_PART_NUM {
+			t.Fatalf("size %d: the %d parts do not cover the object", c.objectSize, len(cfc.CopyParts))
+		}
+	}
+}
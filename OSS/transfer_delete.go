@@ -0,0 +1,204 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// walkPrefixObjectsToDelete calls fn for each batch of the objects of the prefix to delete.
+func (OSSClient OSSClient) walkPrefixObjectsToDelete(ctx context.Context, input *DeletePrefixInput, extensions []extensionOptions,
+	fn func(objects []ObjectToDelete)) error {
+	var batch []ObjectToDelete
+	add := func(object ObjectToDelete) {
+		batch = append(batch, object)
+		if len(batch) >= input.BatchSize {
+			fn(batch)
+			batch = nil
+		}
+	}
+
+	if input.IncludeVersions {
+		listInput := &ListVersionsInput{}
+		listInput.Bucket = input.Bucket
+		listInput.Prefix = input.Prefix
+		paginator := NewListVersionsPaginator(&OSSClient, listInput, extensions...)
+		for paginator.HasNext() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			listOutput, err := paginator.Next()
+			if err != nil {
+				return err
+			}
+			for _, version := range listOutput.Versions {
+				add(ObjectToDelete{Key: version.Key, VersionId: version.VersionId})
+			}
+			if input.IncludeDeleteMarkers {
+				for _, deleteMarker := range listOutput.DeleteMarkers {
+					add(ObjectToDelete{Key: deleteMarker.Key, VersionId: deleteMarker.VersionId})
+				}
+			}
+		}
+	} else {
+		listInput := &ListObjectsInput{}
+		listInput.Bucket = input.Bucket
+		listInput.Prefix = input.Prefix
+		paginator := NewListObjectsPaginator(&OSSClient, listInput, extensions...)
+		for paginator.HasNext() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			listOutput, err := paginator.Next()
+			if err != nil {
+				return err
+			}
+			for _, content := range listOutput.Contents {
+				add(ObjectToDelete{Key: content.Key})
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		fn(batch)
+	}
+	return nil
+}
+
+// deletePrefix deletes the objects by batches, onBatch is called with the lock held if it is not nil.
+func (OSSClient OSSClient) deletePrefix(input *DeletePrefixInput, onBatch func(deletedCount, errorCount int),
+	extensions []extensionOptions) (*DeletePrefixOutput, error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	output := &DeletePrefixOutput{}
+	var lock sync.Mutex
+
+	pool := NewRoutinePool(input.TaskNum, 0)
+	err := OSSClient.walkPrefixObjectsToDelete(ctx, input, extensions, func(objects []ObjectToDelete) {
+		pool.ExecuteFunc(func() interface{} {
+			deleteInput := &DeleteObjectsInput{}
+			deleteInput.Bucket = input.Bucket
+			deleteInput.Quiet = input.Quiet
+			deleteInput.Objects = objects
+			deleteOutput, err := OSSClient.DeleteObjects(deleteInput, extensions...)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				doLog(LEVEL_WARN, "Failed to delete a batch of [%d] objects with error [%v].", len(objects), err)
+				code := ""
+				if ossError, ok := err.(OSSError); ok {
+					code = ossError.Code
+				}
+				for _, object := range objects {
+					output.Errors = append(output.Errors, Error{Key: object.Key, VersionId: object.VersionId, Code: code, Message: err.Error()})
+				}
+				if onBatch != nil {
+					onBatch(0, len(objects))
+				}
+				return nil
+			}
+			output.Deleteds = append(output.Deleteds, deleteOutput.Deleteds...)
+			output.Errors = append(output.Errors, deleteOutput.Errors...)
+			// the deleted objects are not returned in quiet mode
+			output.DeletedCount += len(objects) - len(deleteOutput.Errors)
+			if onBatch != nil {
+				onBatch(len(objects)-len(deleteOutput.Errors), len(deleteOutput.Errors))
+			}
+			return nil
+		})
+	})
+	pool.ShutDown()
+
+	sort.Slice(output.Deleteds, func(i, j int) bool {
+		if output.Deleteds[i].Key != output.Deleteds[j].Key {
+			return output.Deleteds[i].Key < output.Deleteds[j].Key
+		}
+		return output.Deleteds[i].VersionId < output.Deleteds[j].VersionId
+	})
+	sort.Slice(output.Errors, func(i, j int) bool {
+		if output.Errors[i].Key != output.Errors[j].Key {
+			return output.Errors[i].Key < output.Errors[j].Key
+		}
+		return output.Errors[i].VersionId < output.Errors[j].VersionId
+	})
+	return output, err
+}
+
+func (OSSClient OSSClient) deleteBucketForce(input *DeleteBucketForceInput, extensions []extensionOptions) (*DeleteBucketForceOutput, error) {
+	output := &DeleteBucketForceOutput{}
+	// the stages run one after another and the callbacks are called with the lock of the stage held
+	event := &DeleteBucketForceEvent{}
+	notify := func(stage DeleteBucketForceStageType) {
+		event.Stage = stage
+		if input.ProgressListener != nil {
+			input.ProgressListener.ProgressChanged(event)
+		}
+	}
+
+	notify(DeleteBucketForceAbortUploads)
+	cleanupInput := &CleanupMultipartUploadsInput{}
+	cleanupInput.Bucket = input.Bucket
+	cleanupInput.TaskNum = input.TaskNum
+	cleanupOutput, err := OSSClient.cleanupMultipartUploads(cleanupInput, true, func(result CleanedMultipartUpload) {
+		if result.Err != nil {
+			event.FailedCount++
+		} else {
+			event.AbortedUploads++
+		}
+		notify(DeleteBucketForceAbortUploads)
+	}, extensions)
+	if err != nil {
+		return output, err
+	}
+	for _, upload := range cleanupOutput.Uploads {
+		if upload.Err == nil {
+			output.AbortedUploads = append(output.AbortedUploads, upload)
+		}
+	}
+	if cleanupOutput.FailedCount > 0 {
+		return output, fmt.Errorf("Failed to abort [%d] multipart uploads of bucket [%s]", cleanupOutput.FailedCount, input.Bucket)
+	}
+
+	notify(DeleteBucketForceDeleteObjects)
+	deleteInput := &DeletePrefixInput{}
+	deleteInput.Bucket = input.Bucket
+	deleteInput.IncludeVersions = true
+	deleteInput.IncludeDeleteMarkers = true
+	deleteInput.AllowEmptyPrefix = true
+	deleteInput.Quiet = true
+	deleteInput.BatchSize = MAX_DELETE_OBJECTS_NUM
+	deleteInput.TaskNum = input.TaskNum
+	deleteOutput, err := OSSClient.deletePrefix(deleteInput, func(deletedCount, errorCount int) {
+		event.DeletedObjects += deletedCount
+		event.FailedCount += errorCount
+		notify(DeleteBucketForceDeleteObjects)
+	}, extensions)
+	output.DeletedCount = deleteOutput.DeletedCount
+	output.Errors = deleteOutput.Errors
+	if err != nil {
+		return output, err
+	}
+	if len(output.Errors) > 0 {
+		return output, fmt.Errorf("Failed to delete [%d] objects of bucket [%s]", len(output.Errors), input.Bucket)
+	}
+
+	notify(DeleteBucketForceDeleteBucket)
+	if _, err = OSSClient.DeleteBucket(input.Bucket, extensions...); err != nil {
+		return output, err
+	}
+	notify(DeleteBucketForceCompleted)
+	return output, nil
+}
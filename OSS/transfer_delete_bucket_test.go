@@ -0,0 +1,95 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type deleteBucketForceRecorder struct {
+	lock   sync.Mutex
+	stages []DeleteBucketForceStageType
+	last   DeleteBucketForceEvent
+}
+
+func (recorder *deleteBucketForceRecorder) ProgressChanged(event *DeleteBucketForceEvent) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	if count := len(recorder.stages); count == 0 || recorder.stages[count-1] != event.Stage {
+		recorder.stages = append(recorder.stages, event.Stage)
+	}
+	recorder.last = *event
+}
+
+func (fs *fakeServer) isBucketDeleted(bucket string) bool {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.deleted[bucket]
+}
+
+func TestDeleteBucketForce(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.addVersion("bucket", "a", "v1", false)
+	fs.addVersion("bucket", "a", "v2", true)
+	fs.addVersion("bucket", "b", "v1", false)
+	// the uploads in progress are aborted too
+	addUploadParts(fs, "bucket", "c", time.Now(), 10)
+	addUploadParts(fs, "bucket", "d", time.Now().Add(-48*time.Hour))
+
+	if _, err := client.DeleteBucketForce(&DeleteBucketForceInput{Bucket: "bucket", ConfirmBucket: "other"}); err == nil {
+		t.Fatal("expected the confirmation to be required")
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return true }); count != 0 {
+		t.Fatalf("expected no requests without the confirmation, got %d", count)
+	}
+
+	recorder := &deleteBucketForceRecorder{}
+	input := &DeleteBucketForceInput{Bucket: "bucket", ConfirmBucket: "bucket", TaskNum: 2, ProgressListener: recorder}
+	output, err := client.DeleteBucketForce(input)
+	if err != nil {
+		t.Fatalf("DeleteBucketForce failed: %v", err)
+	}
+	if len(output.AbortedUploads) != 2 || output.DeletedCount != 3 || fs.uploadCount() != 0 || !fs.isBucketDeleted("bucket") {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	expected := []DeleteBucketForceStageType{DeleteBucketForceAbortUploads, DeleteBucketForceDeleteObjects,
+		DeleteBucketForceDeleteBucket, DeleteBucketForceCompleted}
+	if !reflect.DeepEqual(recorder.stages, expected) || recorder.last.AbortedUploads != 2 || recorder.last.DeletedObjects != 3 {
+		t.Fatalf("unexpected progress %v, %+v", recorder.stages, recorder.last)
+	}
+}
+
+func TestDeleteBucketForceFailedUpload(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	addUploadParts(fs, "bucket", "a", time.Now())
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodDelete && r.URL.Query().Has("uploadId") {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	if _, err := client.DeleteBucketForce(&DeleteBucketForceInput{Bucket: "bucket", ConfirmBucket: "bucket"}); err == nil {
+		t.Fatal("expected the failed upload to stop the deletion")
+	}
+	if fs.isBucketDeleted("bucket") {
+		t.Fatal("expected the bucket not to be deleted")
+	}
+}
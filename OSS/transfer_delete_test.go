@@ -0,0 +1,116 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func isDeleteObjects(r *http.Request) bool {
+	return r.Method == http.MethodPost && r.URL.Query().Has("delete")
+}
+
+// versionEntries returns the remaining versions of the bucket as "key@versionId".
+func (fs *fakeServer) versionEntries(bucket string) []string {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	var entries []string
+	for _, version := range fs.versions[bucket] {
+		entries = append(entries, version.key+"@"+version.versionID)
+	}
+	return entries
+}
+
+func TestDeletePrefix(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	for i := 0; i < 7; i++ {
+		fs.putObject("bucket", fmt.Sprintf("logs/%d", i), []byte("log"))
+	}
+	fs.putObject("bucket", "keep", []byte("keep"))
+
+	input := &DeletePrefixInput{Bucket: "bucket", Prefix: "logs/", BatchSize: 3, TaskNum: 2}
+	output, err := client.DeletePrefix(input)
+	if err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if output.DeletedCount != 7 || len(output.Deleteds) != 7 || len(output.Errors) != 0 {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	if batches := fs.countRequests(isDeleteObjects); batches != 3 {
+		t.Fatalf("expected 3 batches, got %d", batches)
+	}
+	if keys := fs.objectKeys("bucket"); !reflect.DeepEqual(keys, []string{"keep"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	if _, err := client.DeletePrefix(&DeletePrefixInput{Bucket: "bucket"}); err == nil {
+		t.Fatal("expected the empty prefix to be refused")
+	}
+	output, err = client.DeletePrefix(&DeletePrefixInput{Bucket: "bucket", AllowEmptyPrefix: true, Quiet: true})
+	if err != nil || output.DeletedCount != 1 || len(output.Deleteds) != 0 || len(fs.objectKeys("bucket")) != 0 {
+		t.Fatalf("unexpected quiet output %+v, %v", output, err)
+	}
+}
+
+func TestDeletePrefixVersions(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.addVersion("bucket", "data/a", "v1", false)
+	fs.addVersion("bucket", "data/a", "v2", true)
+	fs.addVersion("bucket", "data/b", "v1", false)
+	fs.addVersion("bucket", "keep", "v1", false)
+
+	input := &DeletePrefixInput{Bucket: "bucket", Prefix: "data/", IncludeVersions: true}
+	output, err := client.DeletePrefix(input)
+	if err != nil || output.DeletedCount != 2 {
+		t.Fatalf("unexpected output %+v, %v", output, err)
+	}
+	if entries := fs.versionEntries("bucket"); !reflect.DeepEqual(entries, []string{"data/a@v2", "keep@v1"}) {
+		t.Fatalf("expected the delete marker to be kept, got %v", entries)
+	}
+
+	input.IncludeDeleteMarkers = true
+	if output, err = client.DeletePrefix(input); err != nil || output.DeletedCount != 1 {
+		t.Fatalf("unexpected output %+v, %v", output, err)
+	}
+	if entries := fs.versionEntries("bucket"); !reflect.DeepEqual(entries, []string{"keep@v1"}) {
+		t.Fatalf("unexpected versions %v", entries)
+	}
+}
+
+func TestDeletePrefixFailedBatch(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	for i := 0; i < 4; i++ {
+		fs.putObject("bucket", fmt.Sprintf("logs/%d", i), []byte("log"))
+	}
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if isDeleteObjects(r) {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	output, err := client.DeletePrefix(&DeletePrefixInput{Bucket: "bucket", Prefix: "logs/", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if output.DeletedCount != 0 || len(output.Errors) != 4 {
+		t.Fatalf("expected an error for each key, got %+v", output)
+	}
+}
@@ -0,0 +1,462 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MatchPathPattern reports whether the slash-separated path name matches the glob pattern.
+//
+// A pattern without a slash matches the last element of name, so "*.log" matches the files in any
+// directory. Otherwise the pattern matches the whole name: the elements are matched with path.Match,
+// and an element "**" matches zero or more elements, e.g. "build/**/*.o".
+func MatchPathPattern(pattern, name string) (bool, error) {
+	if !strings.Contains(pattern, "/") {
+		return path.Match(pattern, path.Base(name))
+	}
+	return matchPathElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchPathElements(patterns, names []string) (bool, error) {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			patterns = patterns[1:]
+			if len(patterns) == 0 {
+				return true, nil
+			}
+			for i := range names {
+				if ok, err := matchPathElements(patterns, names[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(names) == 0 {
+			return false, nil
+		}
+		if ok, err := path.Match(patterns[0], names[0]); !ok || err != nil {
+			return false, err
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0, nil
+}
+
+func validatePathPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := MatchPathPattern(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid pattern [%s]: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func matchAnyPathPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := MatchPathPattern(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isPathIncluded reports whether name matches any pattern of include, or include is empty,
+// and does not match any pattern of exclude.
+func isPathIncluded(include, exclude []string, name string) bool {
+	if len(include) > 0 && !matchAnyPathPattern(include, name) {
+		return false
+	}
+	return !matchAnyPathPattern(exclude, name)
+}
+
+// walkDirectoryFunc is called for each directory and regular file found by walkDirectory, and for each
+// path which can not be read with the error. Returning filepath.SkipDir for a directory skips it,
+// returning any other error stops the walk.
+type walkDirectoryFunc func(localPath, relPath string, info os.FileInfo, err error) error
+
+// walkDirectory walks the tree of root, relPath is the slash-separated path relative to root.
+// Symbolic links are skipped unless followSymlinks is set, the links to an ancestor directory are always skipped.
+func walkDirectory(root string, followSymlinks bool, fn walkDirectoryFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("[%s] is not a directory", root)
+	}
+	return walkDirectoryRecursive(root, "", followSymlinks, make(map[string]bool), fn)
+}
+
+func walkDirectoryRecursive(dir, relDir string, followSymlinks bool, ancestors map[string]bool, fn walkDirectoryFunc) error {
+	if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+		if ancestors[realDir] {
+			doLog(LEVEL_WARN, "Skip directory [%s] which links to its ancestor.", dir)
+			return nil
+		}
+		ancestors[realDir] = true
+		defer delete(ancestors, realDir)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fn(dir, relDir, nil, err)
+	}
+	for _, entry := range entries {
+		localPath := filepath.Join(dir, entry.Name())
+		relPath := path.Join(relDir, entry.Name())
+		info, err := entry.Info()
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if !followSymlinks {
+				doLog(LEVEL_INFO, "Skip symbolic link [%s].", localPath)
+				continue
+			}
+			info, err = os.Stat(localPath)
+		}
+		if err != nil {
+			if err = fn(localPath, relPath, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if info.IsDir() {
+			err = fn(localPath, relPath, info, nil)
+			if err == filepath.SkipDir {
+				continue
+			}
+			if err == nil {
+				err = walkDirectoryRecursive(localPath, relPath, followSymlinks, ancestors, fn)
+			}
+		} else if info.Mode().IsRegular() {
+			err = fn(localPath, relPath, info, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// directoryPrefix returns prefix followed by a slash unless it is empty or already ends with one, so that
+// the keys without the prefix are the paths relative to the directory.
+func directoryPrefix(prefix string) string {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		return prefix + "/"
+	}
+	return prefix
+}
+
+// localPathOfKey returns the path under dir of relKey, which must not escape dir.
+func localPathOfKey(dir, relKey string) (string, error) {
+	cleaned := path.Clean("/" + relKey)
+	if cleaned != "/"+relKey {
+		return "", fmt.Errorf("Key [%s] can not be mapped to a path under the directory", relKey)
+	}
+	return filepath.Join(dir, filepath.FromSlash(cleaned[1:])), nil
+}
+
+// listPrefixObjects returns the objects of the prefix by their keys without the prefix, the folders
+// and the keys excluded by include and exclude are skipped.
+func (OSSClient OSSClient) listPrefixObjects(ctx context.Context, bucket, prefix string, include, exclude []string,
+	extensions []extensionOptions) (map[string]Content, error) {
+	objects := make(map[string]Content)
+	err := OSSClient.walkPrefixObjects(ctx, bucket, prefix, include, exclude, extensions, func(relKey string, content Content) {
+		objects[relKey] = content
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// walkPrefixObjects calls fn for each object of the prefix with its key without the prefix, the folders
+// and the keys excluded by include and exclude are skipped.
+func (OSSClient OSSClient) walkPrefixObjects(ctx context.Context, bucket, prefix string, include, exclude []string,
+	extensions []extensionOptions, fn func(relKey string, content Content)) error {
+	listInput := &ListObjectsInput{}
+	listInput.Bucket = bucket
+	listInput.Prefix = prefix
+	paginator := NewListObjectsPaginator(&OSSClient, listInput, extensions...)
+	for paginator.HasNext() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		listOutput, err := paginator.Next()
+		if err != nil {
+			return err
+		}
+		for _, content := range listOutput.Contents {
+			relKey := strings.TrimPrefix(content.Key, prefix)
+			if relKey == "" || strings.HasSuffix(relKey, "/") {
+				continue
+			}
+			if isPathIncluded(include, exclude, relKey) {
+				fn(relKey, content)
+			}
+		}
+	}
+	return nil
+}
+
+// directoryTransferResults collects the results of the files transferred concurrently.
+type directoryTransferResults struct {
+	lock   sync.Mutex
+	output DirectoryTransferOutput
+}
+
+func (results *directoryTransferResults) add(result FileTransferResult) {
+	results.lock.Lock()
+	defer results.lock.Unlock()
+	if result.Err != nil {
+		doLog(LEVEL_WARN, "Failed to transfer file [%s] of key [%s] with error [%v].", result.LocalPath, result.Key, result.Err)
+		results.output.FailedCount++
+	} else {
+		results.output.SucceededCount++
+		results.output.TransferredBytes += result.Size
+	}
+	results.output.Results = append(results.output.Results, result)
+}
+
+// sorted returns the output with the results sorted by key.
+func (results *directoryTransferResults) sorted() *DirectoryTransferOutput {
+	results.lock.Lock()
+	defer results.lock.Unlock()
+	sort.SliceStable(results.output.Results, func(i, j int) bool {
+		return results.output.Results[i].Key < results.output.Results[j].Key
+	})
+	output := results.output
+	return &output
+}
+
+// Failed returns the results of the files which failed to be transferred.
+func (output *DirectoryTransferOutput) Failed() []FileTransferResult {
+	failed := make([]FileTransferResult, 0, output.FailedCount)
+	for _, result := range output.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// directoryTransferPool runs the transfers of the files of a directory with taskNum routines, which
+// transfer the small files and the parts of the large files alike. The parts of the large files are
+// dispatched to the routines by a routine of each file, at most taskNum of them.
+type directoryTransferPool struct {
+	pool           Pool
+	partExtensions []extensionOptions
+	multipartFiles chan struct{}
+	wg             sync.WaitGroup
+}
+
+func newDirectoryTransferPool(taskNum int, extensions []extensionOptions) *directoryTransferPool {
+	// the files are dispatched one by one, so that the walk does not go too far ahead of the transfers
+	pool := NewRoutinePool(taskNum, 0)
+	partExtensions := make([]extensionOptions, 0, len(extensions)+1)
+	partExtensions = append(partExtensions, extensions...)
+	partExtensions = append(partExtensions, extensionSharedPool(func() Pool {
+		return pool
+	}))
+	return &directoryTransferPool{pool: pool, partExtensions: partExtensions, multipartFiles: make(chan struct{}, taskNum)}
+}
+
+// transfer runs transferFile with the extensions of the transfer of a small file, or of a multipart transfer if multipart is set.
+func (dtp *directoryTransferPool) transfer(multipart bool, extensions []extensionOptions, transferFile func(extensions []extensionOptions)) {
+	if !multipart {
+		dtp.pool.ExecuteFunc(func() interface{} {
+			transferFile(extensions)
+			return nil
+		})
+		return
+	}
+	dtp.multipartFiles <- struct{}{}
+	dtp.wg.Add(1)
+	go func() {
+		defer func() {
+			<-dtp.multipartFiles
+			dtp.wg.Done()
+		}()
+		transferFile(dtp.partExtensions)
+	}()
+}
+
+func (dtp *directoryTransferPool) shutDown() {
+	dtp.wg.Wait()
+	dtp.pool.ShutDown()
+}
+
+// directoryCheckpointFile returns the checkpoint file of the upload of key under dir, which is kept
+// out of the directory being uploaded.
+func directoryCheckpointFile(dir, bucket, key string) string {
+	return filepath.Join(dir, url.QueryEscape(bucket+"/"+key)+".uploadfile_record")
+}
+
+func (OSSClient OSSClient) uploadDirectory(input *UploadDirectoryInput, extensions []extensionOptions) (*DirectoryTransferOutput, error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	results := &directoryTransferResults{}
+	if input.EnableCheckpoint {
+		if err := os.MkdirAll(input.CheckpointDir, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	checkpointDir, _ := filepath.Abs(input.CheckpointDir)
+	pool := newDirectoryTransferPool(input.TaskNum, extensions)
+
+	err := walkDirectory(input.Directory, input.FollowSymlinks, func(localPath, relPath string, info os.FileInfo, err error) error {
+		if _err := ctx.Err(); _err != nil {
+			return _err
+		}
+		key := input.Prefix + relPath
+		if err != nil {
+			results.add(FileTransferResult{Key: key, LocalPath: localPath, Err: err})
+			return nil
+		}
+		if info.IsDir() {
+			if matchAnyPathPattern(input.Exclude, relPath) {
+				return filepath.SkipDir
+			}
+			if absPath, _ := filepath.Abs(localPath); input.EnableCheckpoint && absPath == checkpointDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isPathIncluded(input.Include, input.Exclude, relPath) {
+			return nil
+		}
+
+		size := info.Size()
+		pool.transfer(size > input.MultipartThreshold, extensions, func(extensions []extensionOptions) {
+			err := OSSClient.uploadDirectoryFile(input, localPath, key, size, extensions)
+			results.add(FileTransferResult{Key: key, LocalPath: localPath, Size: size, Err: err})
+		})
+		return nil
+	})
+	pool.shutDown()
+	return results.sorted(), err
+}
+
+func (OSSClient OSSClient) uploadDirectoryFile(input *UploadDirectoryInput, localPath, key string, size int64, extensions []extensionOptions) error {
+	if size > input.MultipartThreshold {
+		_input := &UploadFileInput{}
+		_input.Bucket = input.Bucket
+		_input.Key = key
+		_input.ACL = input.ACL
+		_input.StorageClass = input.StorageClass
+		_input.SseHeader = input.SseHeader
+		_input.UploadFile = localPath
+		_input.PartSize = input.PartSize
+		_input.TaskNum = input.PartTaskNum
+		_input.EnableCheckpoint = input.EnableCheckpoint
+		if input.EnableCheckpoint {
+			_input.CheckpointFile = directoryCheckpointFile(input.CheckpointDir, input.Bucket, key)
+		}
+		_, err := OSSClient.UploadFile(_input, extensions...)
+		return err
+	}
+
+	_input := &PutFileInput{}
+	_input.Bucket = input.Bucket
+	_input.Key = key
+	_input.ACL = input.ACL
+	_input.StorageClass = input.StorageClass
+	_input.SseHeader = input.SseHeader
+	_input.SourceFile = localPath
+	_, err := OSSClient.PutFile(_input, extensions...)
+	return err
+}
+
+func (OSSClient OSSClient) downloadPrefix(input *DownloadPrefixInput, extensions []extensionOptions) (*DirectoryTransferOutput, error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	results := &directoryTransferResults{}
+	pool := newDirectoryTransferPool(input.TaskNum, extensions)
+
+	err := OSSClient.walkPrefixObjects(ctx, input.Bucket, input.Prefix, input.Include, input.Exclude, extensions, func(relKey string, content Content) {
+		key := content.Key
+		size := content.Size
+		localPath, err := localPathOfKey(input.Directory, relKey)
+		if err != nil {
+			results.add(FileTransferResult{Key: key, Size: size, Err: err})
+			return
+		}
+		pool.transfer(size > input.MultipartThreshold, extensions, func(extensions []extensionOptions) {
+			err := OSSClient.downloadPrefixFile(input, key, localPath, size, extensions)
+			results.add(FileTransferResult{Key: key, LocalPath: localPath, Size: size, Err: err})
+		})
+	})
+	pool.shutDown()
+	return results.sorted(), err
+}
+
+func (OSSClient OSSClient) downloadPrefixFile(input *DownloadPrefixInput, key, localPath string, size int64, extensions []extensionOptions) error {
+	if size > input.MultipartThreshold {
+		_input := &DownloadFileInput{}
+		_input.Bucket = input.Bucket
+		_input.Key = key
+		_input.DownloadFile = localPath
+		_input.PartSize = input.PartSize
+		_input.TaskNum = input.PartTaskNum
+		_input.EnableCheckpoint = input.EnableCheckpoint
+		_, err := OSSClient.DownloadFile(_input, extensions...)
+		return err
+	}
+
+	_input := &GetObjectInput{}
+	_input.Bucket = input.Bucket
+	_input.Key = key
+	output, err := OSSClient.GetObject(_input, extensions...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		errMsg := output.Body.Close()
+		if errMsg != nil {
+			doLog(LEVEL_WARN, "Failed to close response body.")
+		}
+	}()
+	return writeFile(localPath, output.Body)
+}
+
+// writeFile writes the data read from reader to a temp file, and renames it to filePath after all the data is written.
+func writeFile(filePath string, reader io.Reader) (err error) {
+	if err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+	tempFilePath := filePath + ".tmp"
+	fd, err := os.OpenFile(tempFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fd, reader)
+	if errMsg := fd.Close(); errMsg != nil && err == nil {
+		err = errMsg
+	}
+	if err == nil {
+		err = os.Rename(tempFilePath, filePath)
+	}
+	if err != nil {
+		if _err := os.Remove(tempFilePath); _err != nil && !os.IsNotExist(_err) {
+			doLog(LEVEL_WARN, "Failed to remove temp download file with error [%v].", _err)
+		}
+	}
+	return err
+}
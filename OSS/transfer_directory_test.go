@@ -0,0 +1,213 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeFiles writes the files of the slash-separated paths under dir.
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	for name, data := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatchPathPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		matched bool
+	}{
+		{"*.log", "a.log", true},
+		{"*.log", "dir/sub/a.log", true},
+		{"*.log", "a.txt", false},
+		{"build/**/*.o", "build/a.o", true},
+		{"build/**/*.o", "build/x/y/a.o", true},
+		{"build/**/*.o", "src/build/a.o", false},
+		{"dir/*", "dir/a/b", false},
+	}
+	for _, c := range cases {
+		if matched, err := MatchPathPattern(c.pattern, c.name); err != nil || matched != c.matched {
+			t.Errorf("MatchPathPattern(%q, %q) = %v, %v", c.pattern, c.name, matched, err)
+		}
+	}
+}
+
+func TestUploadDirectory(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	large := bytes.Repeat([]byte("0123456789"), MIN_PART_SIZE/4)
+	writeFiles(t, dir, map[string][]byte{
+		"a.txt":         []byte("a"),
+		"sub/b.txt":     []byte("b"),
+		"sub/large.bin": large,
+		"sub/c.log":     []byte("c"),
+		"tmp/d.txt":     []byte("d"),
+	})
+
+	input := &UploadDirectoryInput{Bucket: "bucket", Prefix: "backup/", Directory: dir, Exclude: []string{"*.log", "tmp"},
+		PartSize: MIN_PART_SIZE, TaskNum: 3, PartTaskNum: 2, EnableCheckpoint: true, CheckpointDir: filepath.Join(dir, "checkpoints")}
+	output, err := client.UploadDirectory(input)
+	if err != nil {
+		t.Fatalf("UploadDirectory failed: %v", err)
+	}
+	if output.SucceededCount != 3 || output.FailedCount != 0 || output.TransferredBytes != int64(len(large)+2) {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	expected := []string{"backup/a.txt", "backup/sub/b.txt", "backup/sub/large.bin"}
+	if keys := fs.objectKeys("bucket"); !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if object, _ := fs.getObject("bucket", "backup/sub/large.bin"); !bytes.Equal(object.data, large) {
+		t.Fatal("unexpected large object")
+	}
+	if parts := fs.countRequests(func(r *http.Request) bool { return r.URL.Query().Has("partNumber") }); parts != 3 {
+		t.Fatalf("expected the large file to be uploaded in 3 parts, got %d", parts)
+	}
+}
+
+func TestUploadDirectoryCheckpointOutsideDirectory(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{"large.bin": make([]byte, 2*MIN_PART_SIZE)})
+	var checkpoints int32
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Has("partNumber") {
+			matches, _ := filepath.Glob(filepath.Join(dir, "*.uploadfile_record"))
+			atomic.AddInt32(&checkpoints, int32(len(matches)))
+		}
+		return false
+	})
+
+	input := &UploadDirectoryInput{Bucket: "bucket", Directory: dir, PartSize: MIN_PART_SIZE, EnableCheckpoint: true}
+	if _, err := client.UploadDirectory(input); err != nil {
+		t.Fatalf("UploadDirectory failed: %v", err)
+	}
+	if checkpoints != 0 {
+		t.Fatal("unexpected checkpoint files in the directory")
+	}
+	if !strings.HasPrefix(input.CheckpointDir, os.TempDir()) {
+		t.Fatalf("unexpected checkpoint directory %s", input.CheckpointDir)
+	}
+}
+
+func TestUploadDirectorySharesPool(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	files := make(map[string][]byte)
+	for _, name := range []string{"a", "b", "c"} {
+		files[name+".bin"] = make([]byte, 3*MIN_PART_SIZE)
+		files[name+".txt"] = []byte(name)
+	}
+	writeFiles(t, dir, files)
+	var running, maxRunning int32
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodPut {
+			return false
+		}
+		n := atomic.AddInt32(&running, 1)
+		for max := atomic.LoadInt32(&maxRunning); n > max && !atomic.CompareAndSwapInt32(&maxRunning, max, n); max = atomic.LoadInt32(&maxRunning) {
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return false
+	})
+
+	input := &UploadDirectoryInput{Bucket: "bucket", Directory: dir, PartSize: MIN_PART_SIZE, TaskNum: 2, PartTaskNum: 2}
+	output, err := client.UploadDirectory(input)
+	if err != nil || output.SucceededCount != 6 {
+		t.Fatalf("UploadDirectory failed: %+v, %v", output, err)
+	}
+	if maxRunning != 2 {
+		t.Fatalf("expected at most 2 uploads at the same time, got %d", maxRunning)
+	}
+}
+
+func TestDownloadPrefix(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	large := bytes.Repeat([]byte("abc"), 1000)
+	fs.putObject("bucket", "data/a.txt", []byte("a"))
+	fs.putObject("bucket", "data/sub/large.bin", large)
+	fs.putObject("bucket", "data/sub/c.log", []byte("c"))
+	fs.putObject("bucket", "data/../escape", []byte("x"))
+	fs.putObject("bucket", "other/d.txt", []byte("d"))
+
+	dir := t.TempDir()
+	input := &DownloadPrefixInput{Bucket: "bucket", Prefix: "data/", Directory: dir, Exclude: []string{"*.log"},
+		PartSize: 1000, TaskNum: 2, PartTaskNum: 2}
+	output, err := client.DownloadPrefix(input)
+	if err != nil {
+		t.Fatalf("DownloadPrefix failed: %v", err)
+	}
+	if output.SucceededCount != 2 || output.FailedCount != 1 {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	for name, data := range map[string][]byte{"a.txt": []byte("a"), "sub/large.bin": large} {
+		if read, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err != nil || !bytes.Equal(read, data) {
+			t.Fatalf("unexpected file %s, err: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub", "c.log")); !os.IsNotExist(err) {
+		t.Fatal("expected the excluded object not to be downloaded")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape")); !os.IsNotExist(err) {
+		t.Fatal("expected the escaping key not to be downloaded")
+	}
+}
+
+func TestDirectoryPrefixWithoutSlash(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{"a.txt": []byte("a"), "sub/b.txt": []byte("b")})
+
+	uploadInput := &UploadDirectoryInput{Bucket: "bucket", Prefix: "backup", Directory: dir}
+	if output, err := client.UploadDirectory(uploadInput); err != nil || output.SucceededCount != 2 {
+		t.Fatalf("UploadDirectory failed: %+v, %v", output, err)
+	}
+	if keys := fs.objectKeys("bucket"); !reflect.DeepEqual(keys, []string{"backup/a.txt", "backup/sub/b.txt"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	// the objects of a sibling prefix sharing the name are not downloaded
+	fs.putObject("bucket", "backup-old/c.txt", []byte("c"))
+	downloadDir := t.TempDir()
+	downloadInput := &DownloadPrefixInput{Bucket: "bucket", Prefix: "backup", Directory: downloadDir}
+	if output, err := client.DownloadPrefix(downloadInput); err != nil || output.SucceededCount != 2 || output.FailedCount != 0 {
+		t.Fatalf("DownloadPrefix failed: %+v, %v", output, err)
+	}
+	for name, data := range map[string][]byte{"a.txt": []byte("a"), "sub/b.txt": []byte("b")} {
+		if read, err := os.ReadFile(filepath.Join(downloadDir, filepath.FromSlash(name))); err != nil || !bytes.Equal(read, data) {
+			t.Fatalf("unexpected file %s, err: %v", name, err)
+		}
+	}
+}
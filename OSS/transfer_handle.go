@@ -0,0 +1,405 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrTransferCanceled will be returned by Wait if the transfer is canceled
+var ErrTransferCanceled = errors.New("Transfer is canceled")
+
+// TransferState defines the state of a Transfer
+type TransferState int32
+
+const (
+	// TransferRunning means the parts of the transfer are being transferred
+	TransferRunning TransferState = 1 + iota
+	// TransferPaused means the transfer is paused and can be resumed from the checkpoint file
+	TransferPaused
+	// TransferCompleted means the transfer succeeds
+	TransferCompleted
+	// TransferFailed means the transfer fails, the checkpoint file is kept
+	TransferFailed
+	// TransferCanceled means the transfer is canceled and its checkpoint file is removed
+	TransferCanceled
+)
+
+func (state TransferState) String() string {
+	switch state {
+	case TransferRunning:
+		return "Running"
+	case TransferPaused:
+		return "Paused"
+	case TransferCompleted:
+		return "Completed"
+	case TransferFailed:
+		return "Failed"
+	case TransferCanceled:
+		return "Canceled"
+	}
+	return fmt.Sprintf("TransferState(%d)", int32(state))
+}
+
+// Transfer is the handle of an UploadFile or DownloadFile running in the background.
+//
+// The transfer always runs with the checkpoint enabled: Pause stops the parts in flight and keeps
+// the checkpoint file, and Resume continues from the checkpoint file. Cancel stops the transfer and
+// removes what it leaves behind, such as the multipart upload or the temp download file.
+type Transfer struct {
+	lock     sync.Mutex
+	state    TransferState
+	ctx      context.Context
+	span     Span
+	cancel   context.CancelFunc
+	runDone  chan struct{}
+	done     chan struct{}
+	err      error
+	pool     Pool
+	taskNum  int
+	progress *transferProgress
+	run      func(ctx context.Context, taskNum int, extensions []extensionOptions) error
+	cleanup  func()
+}
+
+// transferProgress records the progress of a Transfer across its runs.
+type transferProgress struct {
+	transfer      *Transfer
+	listener      ProgressListener
+	started       int32
+	consumedBytes int64
+	totalBytes    int64
+}
+
+func (progress *transferProgress) ProgressChanged(event *ProgressEvent) {
+	atomic.StoreInt64(&progress.consumedBytes, event.ConsumedBytes)
+	atomic.StoreInt64(&progress.totalBytes, event.TotalBytes)
+	if progress.listener == nil {
+		return
+	}
+	switch event.EventType {
+	case TransferStartedEvent:
+		// every run publishes a started event, only the first one is forwarded
+		if !atomic.CompareAndSwapInt32(&progress.started, 0, 1) {
+			return
+		}
+	case TransferFailedEvent:
+		// a run stopped by Pause or Cancel has not failed
+		if progress.transfer.State() != TransferRunning {
+			return
+		}
+	}
+	progress.listener.ProgressChanged(event)
+}
+
+func newTransfer(ctx context.Context, span Span, taskNum int, listener ProgressListener) *Transfer {
+	transfer := &Transfer{
+		ctx:     ctx,
+		span:    span,
+		done:    make(chan struct{}),
+		taskNum: taskNum,
+	}
+	transfer.progress = &transferProgress{transfer: transfer, listener: listener}
+	return transfer
+}
+
+// start starts a run of the transfer, the lock must be held by the caller.
+func (transfer *Transfer) start() {
+	ctx, cancel := context.WithCancel(transfer.ctx)
+	runDone := make(chan struct{})
+	transfer.state = TransferRunning
+	transfer.cancel = cancel
+	transfer.runDone = runDone
+
+	extensions := []extensionOptions{WithContext(ctx), extensionPool(transfer.setPool)}
+	taskNum := transfer.taskNum
+	go func() {
+		err := transfer.run(ctx, taskNum, extensions)
+		transfer.finish(runDone, err)
+	}()
+}
+
+func (transfer *Transfer) finish(runDone chan struct{}, err error) {
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	transfer.cancel()
+	transfer.pool = nil
+	if err == nil {
+		// the run succeeds even if it was being paused or canceled
+		transfer.state = TransferCompleted
+		transfer.end()
+	} else if transfer.state == TransferRunning {
+		transfer.state = TransferFailed
+		transfer.err = err
+		transfer.end()
+	}
+	close(runDone)
+}
+
+// end ends the span of the transfer and closes done, the lock must be held by the caller.
+func (transfer *Transfer) end() {
+	endSpan(transfer.span, transfer.err)
+	close(transfer.done)
+}
+
+func (transfer *Transfer) setPool(pool Pool) {
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	transfer.pool = pool
+	pool.AddMaxWorkerCnt(int64(transfer.taskNum) - pool.GetMaxWorkerCnt())
+}
+
+// State returns the state of the transfer.
+func (transfer *Transfer) State() TransferState {
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	return transfer.state
+}
+
+// Progress returns the bytes transferred and the total bytes of the transfer.
+// Both are 0 until the size of the file or the object is known.
+func (transfer *Transfer) Progress() (consumedBytes, totalBytes int64) {
+	return atomic.LoadInt64(&transfer.progress.consumedBytes), atomic.LoadInt64(&transfer.progress.totalBytes)
+}
+
+// Pause stops the parts in flight and returns after they are stopped.
+// The parts already transferred are kept in the checkpoint file.
+func (transfer *Transfer) Pause() error {
+	transfer.lock.Lock()
+	if transfer.state != TransferRunning {
+		state := transfer.state
+		transfer.lock.Unlock()
+		return fmt.Errorf("Can not pause a transfer in state [%s]", state)
+	}
+	transfer.state = TransferPaused
+	transfer.cancel()
+	runDone := transfer.runDone
+	transfer.lock.Unlock()
+
+	<-runDone
+	if state := transfer.State(); state != TransferPaused {
+		return fmt.Errorf("Can not pause a transfer in state [%s]", state)
+	}
+	return nil
+}
+
+// Resume continues a paused transfer from the checkpoint file.
+func (transfer *Transfer) Resume() error {
+	transfer.lock.Lock()
+	runDone := transfer.runDone
+	transfer.lock.Unlock()
+	// the previous run may still be stopping if Pause is called concurrently
+	<-runDone
+
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	if transfer.state != TransferPaused {
+		return fmt.Errorf("Can not resume a transfer in state [%s]", transfer.state)
+	}
+	if err := transfer.ctx.Err(); err != nil {
+		return err
+	}
+	transfer.start()
+	return nil
+}
+
+// Cancel stops the transfer and removes the checkpoint file, the multipart upload of an upload
+// and the temp download file of a download. Wait returns ErrTransferCanceled after the transfer is canceled.
+func (transfer *Transfer) Cancel() error {
+	transfer.lock.Lock()
+	if transfer.state != TransferRunning && transfer.state != TransferPaused {
+		state := transfer.state
+		transfer.lock.Unlock()
+		return fmt.Errorf("Can not cancel a transfer in state [%s]", state)
+	}
+	transfer.state = TransferCanceled
+	transfer.cancel()
+	runDone := transfer.runDone
+	transfer.lock.Unlock()
+
+	<-runDone
+	if state := transfer.State(); state != TransferCanceled {
+		return fmt.Errorf("Can not cancel a transfer in state [%s]", state)
+	}
+	transfer.cleanup()
+
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	transfer.err = ErrTransferCanceled
+	transfer.end()
+	return nil
+}
+
+// SetTaskNum changes the max number of the parts transferred concurrently, it takes effect
+// from the next part dispatched.
+func (transfer *Transfer) SetTaskNum(taskNum int) error {
+	if taskNum <= 0 {
+		return errors.New("TaskNum must be greater than 0")
+	}
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	transfer.taskNum = taskNum
+	if transfer.pool != nil {
+		transfer.pool.AddMaxWorkerCnt(int64(taskNum) - transfer.pool.GetMaxWorkerCnt())
+	}
+	return nil
+}
+
+// Done returns a channel which is closed when the transfer is completed, failed or canceled.
+func (transfer *Transfer) Done() <-chan struct{} {
+	return transfer.done
+}
+
+// UploadTransfer is the handle of an upload started by StartUpload.
+type UploadTransfer struct {
+	*Transfer
+	output *CompleteMultipartUploadOutput
+}
+
+// Wait waits until the upload is completed, failed or canceled.
+func (transfer *UploadTransfer) Wait() (*CompleteMultipartUploadOutput, error) {
+	<-transfer.done
+	return transfer.output, transfer.err
+}
+
+// DownloadTransfer is the handle of a download started by StartDownload.
+type DownloadTransfer struct {
+	*Transfer
+	output *GetObjectMetadataOutput
+}
+
+// Wait waits until the download is completed, failed or canceled.
+func (transfer *DownloadTransfer) Wait() (*GetObjectMetadataOutput, error) {
+	<-transfer.done
+	return transfer.output, transfer.err
+}
+
+// StartUpload starts UploadFile in the background and returns its handle.
+//
+// The checkpoint is always enabled, the CheckpointFile defaults to the same file as UploadFile.
+func (OSSClient OSSClient) StartUpload(input *UploadFileInput, extensions ...extensionOptions) (*UploadTransfer, error) {
+	if input == nil {
+		return nil, errors.New("UploadFileInput is nil")
+	}
+	_input := *input
+	_input.EnableCheckpoint = true
+	if _input.CheckpointFile == "" {
+		_input.CheckpointFile = _input.UploadFile + ".uploadfile_record"
+	}
+	if _input.TaskNum <= 0 {
+		_input.TaskNum = 1
+	}
+
+	listener, extensions := splitProgressListener(extensions, _input.ProgressListener)
+	// the span ends when the transfer is completed, failed or canceled, the runs are its children
+	extensions, span := OSSClient.startExtensionsSpan("StartUpload", _input.Bucket, _input.Key, extensions)
+	transfer := &UploadTransfer{Transfer: newTransfer(OSSClient.getRequestContext(extensions), span, _input.TaskNum, listener)}
+	_input.ProgressListener = transfer.progress
+	transfer.run = func(ctx context.Context, taskNum int, _extensions []extensionOptions) (err error) {
+		runInput := _input
+		runInput.TaskNum = taskNum
+		transfer.output, err = OSSClient.UploadFile(&runInput, append(_extensions, extensions...)...)
+		return
+	}
+	transfer.cleanup = func() {
+		OSSClient.cleanupUpload(&_input, extensions)
+	}
+
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	transfer.start()
+	return transfer, nil
+}
+
+// StartDownload starts DownloadFile in the background and returns its handle.
+//
+// The checkpoint is always enabled, the CheckpointFile defaults to the same file as DownloadFile.
+func (OSSClient OSSClient) StartDownload(input *DownloadFileInput, extensions ...extensionOptions) (*DownloadTransfer, error) {
+	if input == nil {
+		return nil, errors.New("DownloadFileInput is nil")
+	}
+	_input := *input
+	_input.EnableCheckpoint = true
+	if _input.DownloadFile == "" {
+		_input.DownloadFile = _input.Key
+	}
+	if _input.CheckpointFile == "" {
+		_input.CheckpointFile = _input.DownloadFile + ".downloadfile_record"
+	}
+	if _input.TaskNum <= 0 {
+		_input.TaskNum = 1
+	}
+
+	listener, extensions := splitProgressListener(extensions, _input.ProgressListener)
+	// the span ends when the transfer is completed, failed or canceled, the runs are its children
+	extensions, span := OSSClient.startExtensionsSpan("StartDownload", _input.Bucket, _input.Key, extensions)
+	transfer := &DownloadTransfer{Transfer: newTransfer(OSSClient.getRequestContext(extensions), span, _input.TaskNum, listener)}
+	_input.ProgressListener = transfer.progress
+	transfer.run = func(ctx context.Context, taskNum int, _extensions []extensionOptions) (err error) {
+		runInput := _input
+		runInput.TaskNum = taskNum
+		transfer.output, err = OSSClient.DownloadFile(&runInput, append(_extensions, extensions...)...)
+		return
+	}
+	transfer.cleanup = func() {
+		cleanupDownload(&_input)
+	}
+
+	transfer.lock.Lock()
+	defer transfer.lock.Unlock()
+	transfer.start()
+	return transfer, nil
+}
+
+// cleanupUpload aborts the multipart upload recorded in the checkpoint file and removes the checkpoint file.
+func (OSSClient OSSClient) cleanupUpload(input *UploadFileInput, extensions []extensionOptions) {
+	ufc := &UploadCheckpoint{}
+	if err := loadCheckpointFile(input.CheckpointStore, input.CheckpointFile, ufc); err != nil {
+		if err != ErrCheckpointNotFound {
+			doLog(LEVEL_WARN, "Failed to load checkpoint file with error [%v].", err)
+		}
+		return
+	}
+	if ufc.UploadId != "" {
+		if err := abortTask(ufc.Bucket, ufc.Key, ufc.UploadId, &OSSClient, extensions); err != nil {
+			doLog(LEVEL_WARN, "Failed to abort task [%s].", ufc.UploadId)
+		}
+	}
+	if err := removeCheckpointFile(input.CheckpointStore, input.CheckpointFile); err != nil {
+		doLog(LEVEL_WARN, "Failed to remove checkpoint file with error [%v].", err)
+	}
+}
+
+// cleanupDownload removes the temp download file recorded in the checkpoint file and the checkpoint file.
+func cleanupDownload(input *DownloadFileInput) {
+	dfc := &DownloadCheckpoint{}
+	if err := loadCheckpointFile(input.CheckpointStore, input.CheckpointFile, dfc); err != nil {
+		if err != ErrCheckpointNotFound {
+			doLog(LEVEL_WARN, "Failed to load checkpoint file with error [%v].", err)
+		}
+		return
+	}
+	if dfc.TempFileInfo.TempFileUrl != "" {
+		if err := os.Remove(dfc.TempFileInfo.TempFileUrl); err != nil && !os.IsNotExist(err) {
+			doLog(LEVEL_WARN, "Failed to remove temp download file with error [%v].", err)
+		}
+	}
+	if err := removeCheckpointFile(input.CheckpointStore, input.CheckpointFile); err != nil {
+		doLog(LEVEL_WARN, "Failed to remove checkpoint file with error [%v].", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// slowParts delays the uploads and the ranged downloads of the parts.
+func slowParts(fs *fakeServer, delay time.Duration) {
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Has("partNumber") || r.Header.Get(HEADER_RANGE) != "" {
+			time.Sleep(delay)
+		}
+		return false
+	})
+}
+
+func newUploadFile(t *testing.T, size int) (string, []byte) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 239)
+	}
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file, data
+}
+
+func waitForProgress(t *testing.T, transfer *Transfer, consumedBytes int64) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if consumed, _ := transfer.Progress(); consumed >= consumedBytes {
+			return
+		}
+	}
+	t.Fatal("the transfer makes no progress")
+}
+
+func TestUploadTransferPauseAndResume(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	slowParts(fs, 20*time.Millisecond)
+	file, data := newUploadFile(t, 6*MIN_PART_SIZE)
+
+	input := &UploadFileInput{UploadFile: file, PartSize: MIN_PART_SIZE, TaskNum: 1}
+	input.Bucket, input.Key = "bucket", "key"
+	transfer, err := client.StartUpload(input)
+	if err != nil {
+		t.Fatalf("StartUpload failed: %v", err)
+	}
+	// the second part is being uploaded, so the first one is completed
+	waitForProgress(t, transfer.Transfer, 2*MIN_PART_SIZE)
+	if err := transfer.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if state := transfer.State(); state != TransferPaused {
+		t.Fatalf("unexpected state %s", state)
+	}
+	if err := transfer.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if _, err := transfer.Wait(); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if state := transfer.State(); state != TransferCompleted {
+		t.Fatalf("unexpected state %s", state)
+	}
+	if object, ok := fs.getObject("bucket", "key"); !ok || !bytes.Equal(object.data, data) {
+		t.Fatal("unexpected object")
+	}
+	uploads := fs.countRequests(func(r *http.Request) bool { return r.URL.Query().Get("partNumber") == "1" })
+	if uploads != 1 {
+		t.Fatalf("expected the first part to be uploaded once, got %d", uploads)
+	}
+	if _, err := os.Stat(file + ".uploadfile_record"); !os.IsNotExist(err) {
+		t.Fatal("expected the checkpoint file to be removed")
+	}
+}
+
+func TestUploadTransferCancel(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	slowParts(fs, 20*time.Millisecond)
+	file, _ := newUploadFile(t, 6*MIN_PART_SIZE)
+
+	input := &UploadFileInput{UploadFile: file, PartSize: MIN_PART_SIZE, TaskNum: 2}
+	input.Bucket, input.Key = "bucket", "key"
+	transfer, err := client.StartUpload(input)
+	if err != nil {
+		t.Fatalf("StartUpload failed: %v", err)
+	}
+	waitForProgress(t, transfer.Transfer, MIN_PART_SIZE)
+	if err := transfer.Cancel(); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if _, err := transfer.Wait(); err != ErrTransferCanceled {
+		t.Fatalf("expected ErrTransferCanceled, got: %v", err)
+	}
+	if count := fs.uploadCount(); count != 0 {
+		t.Fatalf("expected the multipart upload to be aborted, %d remain", count)
+	}
+	if _, err := os.Stat(file + ".uploadfile_record"); !os.IsNotExist(err) {
+		t.Fatal("expected the checkpoint file to be removed")
+	}
+}
+
+func TestDownloadTransferPauseAndResume(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := newTestObject(fs, 10*1000)
+	slowParts(fs, 10*time.Millisecond)
+
+	input := &DownloadFileInput{DownloadFile: filepath.Join(t.TempDir(), "file"), PartSize: 1000, TaskNum: 1}
+	input.Bucket, input.Key = "bucket", "key"
+	transfer, err := client.StartDownload(input)
+	if err != nil {
+		t.Fatalf("StartDownload failed: %v", err)
+	}
+	waitForProgress(t, transfer.Transfer, 1000)
+	if err := transfer.Pause(); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if err := transfer.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if _, err := transfer.Wait(); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if read, err := os.ReadFile(input.DownloadFile); err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("unexpected file, err: %v", err)
+	}
+	if consumed, total := transfer.Progress(); consumed != int64(len(data)) || total != int64(len(data)) {
+		t.Fatalf("unexpected progress %d/%d", consumed, total)
+	}
+}
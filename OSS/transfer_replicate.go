@@ -0,0 +1,272 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// the checkpoint file is updated after this number of keys are replicated, or this interval
+	replicateCheckpointBatch    = 100
+	replicateCheckpointInterval = 5 * time.Second
+)
+
+// ReplicateCheckpoint defines the checkpoint file of ReplicatePrefix
+type ReplicateCheckpoint struct {
+	XMLName        xml.Name `xml:"ReplicatePrefixCheckpoint"`
+	SourceBucket   string   `xml:"SourceBucket"`
+	SourcePrefix   string   `xml:"SourcePrefix"`
+	Bucket         string   `xml:"Bucket"`
+	Prefix         string   `xml:"Prefix"`
+	ReplicatedKeys []string `xml:"ReplicatedKey"`
+}
+
+func (rfc *ReplicateCheckpoint) isValid(input *ReplicatePrefixInput) bool {
+	return rfc.SourceBucket == input.SourceBucket && rfc.SourcePrefix == input.SourcePrefix &&
+		rfc.Bucket == input.Bucket && rfc.Prefix == input.Prefix
+}
+
+// replicateRecorder collects the results of ReplicatePrefix and updates the checkpoint file.
+type replicateRecorder struct {
+	lock            sync.Mutex
+	output          *ReplicatePrefixOutput
+	rfc             *ReplicateCheckpoint
+	replicated      map[string]bool
+	checkpointStore CheckpointStore
+	checkpointFile  string
+	pending         int
+	lastUpdate      time.Time
+}
+
+func (recorder *replicateRecorder) isReplicated(key string) bool {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	return recorder.replicated[key]
+}
+
+func (recorder *replicateRecorder) copied(key string, size int64) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.output.CopiedKeys = append(recorder.output.CopiedKeys, key)
+	recorder.output.CopiedBytes += size
+	recorder.record(key)
+}
+
+// aclFailed records the key replicated without its ACL, the key is not replicated again when resumed.
+func (recorder *replicateRecorder) aclFailed(key string, size int64, err error) {
+	doLog(LEVEL_WARN, "Replicate object [%s] successfully, but failed to replicate its ACL with error [%v].", key, err)
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.output.CopiedKeys = append(recorder.output.CopiedKeys, key)
+	recorder.output.CopiedBytes += size
+	recorder.output.AclFailedKeys[key] = err
+	recorder.record(key)
+}
+
+func (recorder *replicateRecorder) skipped(key string) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.output.SkippedKeys = append(recorder.output.SkippedKeys, key)
+	recorder.record(key)
+}
+
+func (recorder *replicateRecorder) failed(key string, err error) {
+	doLog(LEVEL_WARN, "Failed to replicate object [%s] with error [%v].", key, err)
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.output.FailedKeys[key] = err
+}
+
+// record adds the key to the checkpoint, the lock must be held by the caller.
+func (recorder *replicateRecorder) record(key string) {
+	if recorder.checkpointFile == "" || recorder.replicated[key] {
+		return
+	}
+	recorder.replicated[key] = true
+	recorder.rfc.ReplicatedKeys = append(recorder.rfc.ReplicatedKeys, key)
+	recorder.pending++
+	if recorder.pending >= replicateCheckpointBatch || time.Since(recorder.lastUpdate) >= replicateCheckpointInterval {
+		recorder.update()
+	}
+}
+
+// update writes the checkpoint file, the lock must be held by the caller.
+func (recorder *replicateRecorder) update() {
+	if recorder.checkpointFile == "" || recorder.pending == 0 {
+		return
+	}
+	if err := updateCheckpointFile(recorder.checkpointStore, recorder.rfc, recorder.checkpointFile); err != nil {
+		doLog(LEVEL_WARN, "Failed to update checkpoint file with error [%v].", err)
+		return
+	}
+	recorder.pending = 0
+	recorder.lastUpdate = time.Now()
+}
+
+// finish writes the checkpoint file, or removes it if all the objects are replicated, and returns the output.
+func (recorder *replicateRecorder) finish(err error) *ReplicatePrefixOutput {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	if recorder.checkpointFile != "" {
+		if err == nil && len(recorder.output.FailedKeys) == 0 {
+			if _err := removeCheckpointFile(recorder.checkpointStore, recorder.checkpointFile); _err != nil {
+				doLog(LEVEL_WARN, "Replicate prefix successfully, but remove checkpoint file failed with error [%v].", _err)
+			}
+		} else {
+			recorder.update()
+		}
+	}
+	sort.Strings(recorder.output.CopiedKeys)
+	sort.Strings(recorder.output.SkippedKeys)
+	return recorder.output
+}
+
+func newReplicateRecorder(input *ReplicatePrefixInput) *replicateRecorder {
+	recorder := &replicateRecorder{
+		output:     &ReplicatePrefixOutput{FailedKeys: make(map[string]error), AclFailedKeys: make(map[string]error)},
+		rfc:        &ReplicateCheckpoint{},
+		replicated: make(map[string]bool),
+		lastUpdate: time.Now(),
+	}
+	if !input.EnableCheckpoint {
+		return recorder
+	}
+	recorder.checkpointStore = input.CheckpointStore
+	recorder.checkpointFile = input.CheckpointFile
+	if err := loadCheckpointFile(input.CheckpointStore, input.CheckpointFile, recorder.rfc); err != nil || !recorder.rfc.isValid(input) {
+		if err != nil && err != ErrCheckpointNotFound {
+			doLog(LEVEL_WARN, "Failed to load checkpoint file with error [%v].", err)
+		}
+		recorder.rfc = &ReplicateCheckpoint{
+			SourceBucket: input.SourceBucket,
+			SourcePrefix: input.SourcePrefix,
+			Bucket:       input.Bucket,
+			Prefix:       input.Prefix,
+		}
+	}
+	for _, key := range recorder.rfc.ReplicatedKeys {
+		recorder.replicated[key] = true
+	}
+	return recorder
+}
+
+// isReplicaOf reports whether the destination object is a replica of the source object. The ETag of an object
+// uploaded in parts depends on its parts, so the objects are compared by the size and the modified time instead.
+func isReplicaOf(object, source Content) bool {
+	return object.Size == source.Size && !object.LastModified.Truncate(time.Second).Before(source.LastModified.Truncate(time.Second))
+}
+
+func (OSSClient OSSClient) replicatePrefix(input *ReplicatePrefixInput, extensions []extensionOptions) (*ReplicatePrefixOutput, error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	recorder := newReplicateRecorder(input)
+
+	existing, err := OSSClient.listPrefixObjects(ctx, input.Bucket, input.Prefix, input.Include, input.Exclude, extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	serverSide := input.SourceClient.conf.endpoint == OSSClient.conf.endpoint
+	pool := NewRoutinePool(input.TaskNum, 0)
+	err = input.SourceClient.walkPrefixObjects(ctx, input.SourceBucket, input.SourcePrefix, input.Include, input.Exclude, extensions,
+		func(relKey string, content Content) {
+			if recorder.isReplicated(content.Key) {
+				recorder.skipped(content.Key)
+				return
+			}
+			if object, ok := existing[relKey]; ok && isReplicaOf(object, content) {
+				recorder.skipped(content.Key)
+				return
+			}
+			key := input.Prefix + relKey
+			pool.ExecuteFunc(func() interface{} {
+				var aclCopyError AclCopyError
+				if err := OSSClient.replicateObject(input, content, key, serverSide, extensions); errors.As(err, &aclCopyError) {
+					recorder.aclFailed(content.Key, content.Size, err)
+				} else if err != nil {
+					recorder.failed(content.Key, err)
+				} else {
+					recorder.copied(content.Key, content.Size)
+				}
+				return nil
+			})
+		})
+	pool.ShutDown()
+	return recorder.finish(err), err
+}
+
+// replicateObject copies the source object on the server side if the source and the destination are on the same
+// endpoint and the destination can read the source, otherwise it streams the object from the source to the destination.
+func (OSSClient OSSClient) replicateObject(input *ReplicatePrefixInput, content Content, key string, serverSide bool,
+	extensions []extensionOptions) error {
+	if serverSide {
+		copyInput := &CopyFileInput{}
+		copyInput.Bucket = input.Bucket
+		copyInput.Key = key
+		copyInput.CopySourceBucket = input.SourceBucket
+		copyInput.CopySourceKey = content.Key
+		copyInput.CopySourceACL = true
+		copyInput.PartSize = input.PartSize
+		copyInput.TaskNum = input.PartTaskNum
+		output, err := OSSClient.CopyFile(copyInput, extensions...)
+		if err == nil || output != nil {
+			return err
+		}
+		if ossError, ok := err.(OSSError); !ok || ossError.StatusCode != http.StatusForbidden {
+			return err
+		}
+		doLog(LEVEL_WARN, "Failed to copy object [%s] on the server side, stream it instead.", content.Key)
+	}
+
+	getInput := &GetObjectInput{}
+	getInput.Bucket = input.SourceBucket
+	getInput.Key = content.Key
+	// make sure the object is not changed since it was listed
+	getInput.IfMatch = content.ETag
+	getExtensions := make([]extensionOptions, 0, len(extensions)+1)
+	getExtensions = append(getExtensions, extensions...)
+	getExtensions = append(getExtensions, WithResumableBody())
+	getOutput, err := input.SourceClient.GetObject(getInput, getExtensions...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		errMsg := getOutput.Body.Close()
+		if errMsg != nil {
+			doLog(LEVEL_WARN, "Failed to close response body.")
+		}
+	}()
+
+	uploadInput := &UploadStreamInput{}
+	uploadInput.Bucket = input.Bucket
+	uploadInput.Key = key
+	uploadInput.Metadata = getOutput.Metadata
+	uploadInput.WebsiteRedirectLocation = getOutput.WebsiteRedirectLocation
+	uploadInput.ContentType = getOutput.ContentType
+	uploadInput.Body = getOutput.Body
+	uploadInput.PartSize = input.PartSize
+	uploadInput.TaskNum = input.PartTaskNum
+	if _, err = OSSClient.UploadStream(uploadInput, extensions...); err != nil {
+		return err
+	}
+
+	getAclInput := &GetObjectAclInput{}
+	getAclInput.Bucket = input.SourceBucket
+	getAclInput.Key = content.Key
+	return OSSClient.copyObjectAcl(input.SourceClient, getAclInput, input.Bucket, key, "", extensions)
+}
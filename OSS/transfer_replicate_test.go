@@ -0,0 +1,166 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplicatePrefixSkipsReplicas(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	modified := time.Now().UTC().Truncate(time.Second)
+	for _, key := range []string{"a", "b", "c"} {
+		fs.putObject("src", "data/"+key, []byte(key)).lastModified = modified
+	}
+	// the replica uploaded in parts has another ETag
+	replica := fs.putObject("dst", "copy/a", []byte("a"))
+	replica.etag = "\"0123456789abcdef0123456789abcdef-2\""
+	replica.lastModified = modified.Add(time.Minute)
+	// the source was modified after the replica
+	fs.putObject("dst", "copy/b", []byte("b")).lastModified = modified.Add(-time.Minute)
+
+	input := &ReplicatePrefixInput{SourceClient: client, SourceBucket: "src", SourcePrefix: "data/", Bucket: "dst", Prefix: "copy/", TaskNum: 2}
+	output, err := client.ReplicatePrefix(input)
+	if err != nil {
+		t.Fatalf("ReplicatePrefix failed: %v", err)
+	}
+	sort.Strings(output.CopiedKeys)
+	if !reflect.DeepEqual(output.CopiedKeys, []string{"data/b", "data/c"}) || !reflect.DeepEqual(output.SkippedKeys, []string{"data/a"}) {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	if copies := fs.countRequests(func(r *http.Request) bool { return r.Header.Get(HEADER_PREFIX+HEADER_COPY_SOURCE) != "" }); copies != 2 {
+		t.Fatalf("expected the objects to be copied on the server side, got %d copies", copies)
+	}
+}
+
+func TestReplicatePrefixStream(t *testing.T) {
+	source := newFakeServer(t)
+	sourceClient := newTestClient(t, source)
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	large := bytes.Repeat([]byte("0123456789"), MIN_PART_SIZE/4)
+	source.putObject("src", "large", large)
+	source.lock.Lock()
+	source.storeObject("src", "small", []byte("small"), map[string]string{HEADER_PREFIX_META + "color": "red"})
+	source.lock.Unlock()
+
+	input := &ReplicatePrefixInput{SourceClient: sourceClient, SourceBucket: "src", Bucket: "dst", PartSize: MIN_PART_SIZE, TaskNum: 2, PartTaskNum: 2}
+	output, err := client.ReplicatePrefix(input)
+	if err != nil || len(output.CopiedKeys) != 2 || len(output.FailedKeys) != 0 {
+		t.Fatalf("ReplicatePrefix failed: %+v, %v", output, err)
+	}
+	if object, _ := fs.getObject("dst", "large"); !bytes.Equal(object.data, large) {
+		t.Fatal("unexpected large object")
+	}
+	if object, _ := fs.getObject("dst", "small"); string(object.data) != "small" || object.metadata[HEADER_PREFIX_META+"color"] != "red" {
+		t.Fatalf("unexpected small object %+v", object)
+	}
+	if acls := fs.countRequests(func(r *http.Request) bool { return r.Method == http.MethodPut && r.URL.Query().Has("acl") }); acls != 2 {
+		t.Fatalf("expected the ACLs to be replicated, got %d", acls)
+	}
+
+	// the multipart replica is skipped although its ETag differs from the source
+	output, err = client.ReplicatePrefix(input)
+	if err != nil || len(output.CopiedKeys) != 0 || len(output.SkippedKeys) != 2 {
+		t.Fatalf("unexpected second replication %+v, %v", output, err)
+	}
+}
+
+func TestReplicatePrefixWithoutSlash(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.putObject("src", "data/a", []byte("a"))
+	fs.putObject("src", "data-old/b", []byte("b"))
+
+	input := &ReplicatePrefixInput{SourceBucket: "src", SourcePrefix: "data", Bucket: "dst", Prefix: "copy"}
+	output, err := client.ReplicatePrefix(input)
+	if err != nil || len(output.FailedKeys) != 0 {
+		t.Fatalf("ReplicatePrefix failed: %+v, %v", output, err)
+	}
+	if keys := fs.objectKeys("dst"); !reflect.DeepEqual(keys, []string{"copy/a"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
+
+func TestReplicatePrefixAclToAnotherAccount(t *testing.T) {
+	source := newFakeServer(t)
+	sourceClient := newTestClient(t, source)
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	source.putObject("src", "a", []byte("a"))
+	source.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodGet || !r.URL.Query().Has("acl") {
+			return false
+		}
+		writeXML(w, AccessControlPolicy{Owner: Owner{ID: "source"}, Grants: []Grant{
+			{Grantee: Grantee{Type: GranteeUser, ID: "source"}, Permission: PermissionFullControl},
+			{Grantee: Grantee{Type: GranteeUser, ID: "other"}, Permission: PermissionRead},
+			{Grantee: Grantee{Type: GranteeGroup, URI: GroupAllUsers}, Permission: PermissionRead},
+		}})
+		return true
+	})
+	var acls []AccessControlPolicy
+	var aclStatus int32 = http.StatusOK
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodPut || !r.URL.Query().Has("acl") {
+			return false
+		}
+		if status := int(atomic.LoadInt32(&aclStatus)); status != http.StatusOK {
+			writeError(w, status, "AccessDenied")
+			return true
+		}
+		var acl AccessControlPolicy
+		if err := xml.NewDecoder(r.Body).Decode(&acl); err != nil {
+			t.Errorf("unexpected acl: %v", err)
+		}
+		fs.lock.Lock()
+		acls = append(acls, acl)
+		fs.lock.Unlock()
+		return true
+	})
+
+	input := &ReplicatePrefixInput{SourceClient: sourceClient, SourceBucket: "src", Bucket: "dst"}
+	output, err := client.ReplicatePrefix(input)
+	if err != nil || len(output.CopiedKeys) != 1 || len(output.FailedKeys) != 0 || len(output.AclFailedKeys) != 0 {
+		t.Fatalf("ReplicatePrefix failed: %+v, %v", output, err)
+	}
+	// the destination object keeps its owner, which is given the grants of the source owner
+	if len(acls) != 1 || acls[0].Owner.ID != "owner" || len(acls[0].Grants) != 2 || acls[0].Grants[0].Grantee.ID != "owner" ||
+		acls[0].Grants[0].Permission != PermissionFullControl || acls[0].Grants[1].Grantee.ID != "" {
+		t.Fatalf("unexpected acls %+v", acls)
+	}
+
+	// the object replicated without its ACL is reported apart from the failed objects
+	atomic.StoreInt32(&aclStatus, http.StatusForbidden)
+	source.putObject("src", "b", []byte("b"))
+	output, err = client.ReplicatePrefix(input)
+	if err != nil || !reflect.DeepEqual(output.CopiedKeys, []string{"b"}) || len(output.FailedKeys) != 0 {
+		t.Fatalf("ReplicatePrefix failed: %+v, %v", output, err)
+	}
+	var aclCopyError AclCopyError
+	if !errors.As(output.AclFailedKeys["b"], &aclCopyError) || aclCopyError.Key != "b" {
+		t.Fatalf("expected the acl error of b, got %+v", output.AclFailedKeys)
+	}
+	if object, ok := fs.getObject("dst", "b"); !ok || string(object.data) != "b" {
+		t.Fatal("expected the object to be replicated")
+	}
+}
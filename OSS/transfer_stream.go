@@ -0,0 +1,244 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+type uploadStreamTask struct {
+	UploadPartInput
+	ctx        context.Context
+	OSSClient  *OSSClient
+	abort      *int32
+	extensions []extensionOptions
+}
+
+func (task *uploadStreamTask) Run() interface{} {
+	if atomic.LoadInt32(task.abort) == 1 {
+		return errAbort
+	}
+	if err := task.ctx.Err(); err != nil {
+		return err
+	}
+
+	output, err := task.OSSClient.UploadPart(&task.UploadPartInput, task.extensions...)
+	if err == nil && output.ETag == "" {
+		doLog(LEVEL_WARN, "Get invalid etag value after uploading part [%d].", task.PartNumber)
+		rollbackPartProgress(task.ProgressListener)
+		err = fmt.Errorf("get invalid etag value after uploading part [%d]", task.PartNumber)
+	}
+	if err != nil {
+		// the data of a stream can not be read again, so any failed part fails the whole upload
+		atomic.CompareAndSwapInt32(task.abort, 0, 1)
+		doLog(LEVEL_WARN, "Task is aborted, part number is [%d]", task.PartNumber)
+		return err
+	}
+	return output
+}
+
+// partBufferPool hands out at most maxCnt buffers of partSize, which bounds the memory used by
+// an upload stream to (TaskNum + 1) * PartSize.
+type partBufferPool struct {
+	partSize  int64
+	maxCnt    int
+	allocated int
+	buffers   chan []byte
+}
+
+func newPartBufferPool(partSize int64, maxCnt int) *partBufferPool {
+	return &partBufferPool{partSize: partSize, maxCnt: maxCnt, buffers: make(chan []byte, maxCnt)}
+}
+
+func (bp *partBufferPool) get() []byte {
+	select {
+	case buf := <-bp.buffers:
+		return buf
+	default:
+	}
+	if bp.allocated < bp.maxCnt {
+		bp.allocated++
+		return make([]byte, bp.partSize)
+	}
+	return <-bp.buffers
+}
+
+func (bp *partBufferPool) put(buf []byte) {
+	bp.buffers <- buf[:cap(buf)]
+}
+
+func (OSSClient OSSClient) putStream(input *UploadStreamInput, data []byte, listener ProgressListener, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	putInput := &PutObjectInput{}
+	putInput.ObjectOperationInput = input.ObjectOperationInput
+	putInput.ContentType = input.ContentType
+	putInput.ContentLength = int64(len(data))
+	putInput.ProgressListener = listener
+	putInput.Body = bytes.NewReader(data)
+
+	putOutput, err := OSSClient.PutObject(putInput, extensions...)
+	if err != nil {
+		return nil, err
+	}
+
+	output = &CompleteMultipartUploadOutput{}
+	output.BaseModel = putOutput.BaseModel
+	output.VersionId = putOutput.VersionId
+	output.SseHeader = putOutput.SseHeader
+	output.Bucket = input.Bucket
+	output.Key = input.Key
+	output.ETag = putOutput.ETag
+	return
+}
+
+func (OSSClient OSSClient) resumeUploadStream(input *UploadStreamInput, extensions []extensionOptions) (output *CompleteMultipartUploadOutput, err error) {
+	listener, extensions := splitProgressListener(extensions, input.ProgressListener)
+
+	bufferPool := newPartBufferPool(input.PartSize, input.TaskNum+1)
+	buf := bufferPool.get()
+	n, err := io.ReadFull(input.Body, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return OSSClient.putStream(input, buf[:n], listener, extensions)
+	} else if err != nil {
+		doLog(LEVEL_ERROR, "Failed to read the upload stream with error [%v].", err)
+		return nil, err
+	}
+
+	initiateInput := &InitiateMultipartUploadInput{}
+	initiateInput.ObjectOperationInput = input.ObjectOperationInput
+	initiateInput.ContentType = input.ContentType
+	initiateInput.EncodingType = input.EncodingType
+	initiateOutput, err := OSSClient.InitiateMultipartUpload(initiateInput, extensions...)
+	if err != nil {
+		return nil, err
+	}
+	uploadID := initiateOutput.UploadId
+
+	tracker := newProgressTracker(listener, 0, -1)
+	tracker.started()
+	parts, totalBytes, err := OSSClient.uploadStreamConcurrent(input, uploadID, buf[:n], bufferPool, tracker, extensions)
+	if err != nil {
+		tracker.failed()
+		_err := abortTask(input.Bucket, input.Key, uploadID, &OSSClient, extensions)
+		if _err != nil {
+			doLog(LEVEL_WARN, "Failed to abort task [%s].", uploadID)
+		}
+		return nil, err
+	}
+
+	completeInput := &CompleteMultipartUploadInput{}
+	completeInput.Bucket = input.Bucket
+	completeInput.Key = input.Key
+	completeInput.UploadId = uploadID
+	completeInput.EncodingType = input.EncodingType
+	completeInput.Parts = parts
+	output, err = OSSClient.CompleteMultipartUpload(completeInput, extensions...)
+	if err != nil {
+		tracker.failed()
+		_err := abortTask(input.Bucket, input.Key, uploadID, &OSSClient, extensions)
+		if _err != nil {
+			doLog(LEVEL_WARN, "Failed to abort task [%s].", uploadID)
+		}
+		return nil, err
+	}
+	tracker.setTotalBytes(totalBytes)
+	tracker.completed()
+	return output, nil
+}
+
+func (OSSClient OSSClient) uploadStreamConcurrent(input *UploadStreamInput, uploadID string, firstPart []byte, bufferPool *partBufferPool,
+	tracker *progressTracker, extensions []extensionOptions) (parts []Part, totalBytes int64, err error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	pool := NewRoutinePool(input.TaskNum, MAX_PART_NUM)
+	var uploadPartError atomic.Value
+	var errFlag int32
+	var abort int32
+	lock := new(sync.Mutex)
+
+	setError := func(err error) {
+		if atomic.CompareAndSwapInt32(&errFlag, 0, 1) {
+			uploadPartError.Store(err)
+		}
+	}
+
+	data := firstPart
+	for partNumber := 1; ; partNumber++ {
+		if atomic.LoadInt32(&abort) == 1 || ctx.Err() != nil {
+			break
+		}
+		if partNumber > MAX_PART_NUM {
+			doLog(LEVEL_ERROR, "The upload stream is too large")
+			setError(fmt.Errorf("the upload stream exceeds %d parts of size %d", MAX_PART_NUM, input.PartSize))
+			break
+		}
+
+		partData := data
+		totalBytes += int64(len(partData))
+		task := uploadStreamTask{
+			UploadPartInput: UploadPartInput{
+				Bucket:           input.Bucket,
+				Key:              input.Key,
+				PartNumber:       partNumber,
+				UploadId:         uploadID,
+				SseHeader:        input.SseHeader,
+				Body:             bytes.NewReader(partData),
+				PartSize:         int64(len(partData)),
+				ProgressListener: newPartProgressListener(tracker),
+			},
+			ctx:        ctx,
+			OSSClient:  &OSSClient,
+			abort:      &abort,
+			extensions: extensions,
+		}
+		pool.ExecuteFunc(func() interface{} {
+			result := task.Run()
+			bufferPool.put(partData)
+			if uploadPartOutput, ok := result.(*UploadPartOutput); ok {
+				lock.Lock()
+				defer lock.Unlock()
+				parts = append(parts, Part{PartNumber: task.PartNumber, ETag: uploadPartOutput.ETag})
+			} else if _err, ok := result.(error); ok && _err != errAbort {
+				setError(_err)
+			}
+			return nil
+		})
+
+		if int64(len(partData)) < input.PartSize {
+			break
+		}
+		buf := bufferPool.get()
+		n, _err := io.ReadFull(input.Body, buf)
+		if _err == io.EOF {
+			break
+		} else if _err != nil && _err != io.ErrUnexpectedEOF {
+			doLog(LEVEL_ERROR, "Failed to read the upload stream with error [%v].", _err)
+			atomic.CompareAndSwapInt32(&abort, 0, 1)
+			setError(_err)
+			break
+		}
+		data = buf[:n]
+	}
+	pool.ShutDown()
+
+	if err, ok := uploadPartError.Load().(error); ok {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	return parts, totalBytes, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// unknownLengthReader hides the length of the reader from the client.
+type unknownLengthReader struct {
+	io.Reader
+}
+
+func TestUploadStreamMultipart(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := make([]byte, 3*MIN_PART_SIZE+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	input := &UploadStreamInput{Body: unknownLengthReader{bytes.NewReader(data)}, PartSize: MIN_PART_SIZE, TaskNum: 3}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.UploadStream(input); err != nil {
+		t.Fatalf("UploadStream failed: %v", err)
+	}
+	if object, ok := fs.getObject("bucket", "key"); !ok || !bytes.Equal(object.data, data) {
+		t.Fatal("unexpected object")
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return r.URL.Query().Has("partNumber") }); count != 4 {
+		t.Fatalf("expected 4 parts, got %d", count)
+	}
+}
+
+func TestUploadStreamShortStream(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := []byte("short stream")
+
+	input := &UploadStreamInput{Body: unknownLengthReader{bytes.NewReader(data)}, PartSize: MIN_PART_SIZE}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.UploadStream(input); err != nil {
+		t.Fatalf("UploadStream failed: %v", err)
+	}
+	if object, ok := fs.getObject("bucket", "key"); !ok || !bytes.Equal(object.data, data) {
+		t.Fatal("unexpected object")
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return r.URL.Query().Has("uploads") }); count != 0 {
+		t.Fatalf("expected a single PutObject, got %d multipart uploads", count)
+	}
+}
+
+func TestUploadStreamAbortsOnFailure(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("partNumber") == "2" {
+			io.Copy(io.Discard, r.Body)
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	input := &UploadStreamInput{Body: unknownLengthReader{bytes.NewReader(make([]byte, 3*MIN_PART_SIZE))}, PartSize: MIN_PART_SIZE, TaskNum: 2}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.UploadStream(input); err == nil {
+		t.Fatal("expected an error")
+	}
+	if count := fs.uploadCount(); count != 0 {
+		t.Fatalf("expected the multipart upload to be aborted, %d remain", count)
+	}
+	if _, ok := fs.getObject("bucket", "key"); ok {
+		t.Fatal("unexpected object")
+	}
+}
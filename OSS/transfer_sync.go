@@ -0,0 +1,245 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type syncLocalFile struct {
+	localPath string
+	size      int64
+	modTime   time.Time
+}
+
+// syncLocalFiles returns the files under the directory of input by their relative paths, and the
+// relative paths which can not be read.
+func syncLocalFiles(ctx context.Context, input *SyncDirectoryInput) (files map[string]syncLocalFile, failed map[string]error, err error) {
+	files = make(map[string]syncLocalFile)
+	failed = make(map[string]error)
+	if _, err = os.Stat(input.Directory); err != nil && os.IsNotExist(err) && input.Direction == SyncDownload {
+		return files, failed, nil
+	}
+	err = walkDirectory(input.Directory, input.FollowSymlinks, func(localPath, relPath string, info os.FileInfo, err error) error {
+		if _err := ctx.Err(); _err != nil {
+			return _err
+		}
+		if err != nil {
+			failed[relPath] = err
+			return nil
+		}
+		if info.IsDir() {
+			if matchAnyPathPattern(input.Exclude, relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isPathIncluded(input.Include, input.Exclude, relPath) {
+			files[relPath] = syncLocalFile{localPath: localPath, size: info.Size(), modTime: info.ModTime()}
+		}
+		return nil
+	})
+	return
+}
+
+// syncChangedReason returns why the file and the object differ, or "" if they do not.
+func syncChangedReason(input *SyncDirectoryInput, file syncLocalFile, object Content) (string, error) {
+	if file.size != object.Size {
+		return "size changed", nil
+	}
+	etag := trimETag(object.ETag)
+	if input.CompareMode == SyncCompareChecksum && len(etag) == 2*16 && !strings.Contains(etag, "-") {
+		md5Value, err := fileSectionMd5(file.localPath, 0, file.size)
+		if err != nil {
+			return "", err
+		}
+		if hex.EncodeToString(md5Value) != strings.ToLower(etag) {
+			return "checksum changed", nil
+		}
+		return "", nil
+	}
+	// the ETag of an object uploaded in parts is not the MD5 of its content, so compare the time instead
+	localTime := file.modTime.Truncate(time.Second)
+	remoteTime := object.LastModified.Truncate(time.Second)
+	if input.Direction == SyncUpload && localTime.After(remoteTime) {
+		return "modified", nil
+	}
+	if input.Direction == SyncDownload && remoteTime.After(localTime) {
+		return "modified", nil
+	}
+	return "", nil
+}
+
+// hasFailedAncestor reports whether relPath or any of its parent directories can not be read.
+func hasFailedAncestor(failed map[string]error, relPath string) bool {
+	for failedPath := range failed {
+		if failedPath == "" || relPath == failedPath || strings.HasPrefix(relPath, failedPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (OSSClient OSSClient) planSync(ctx context.Context, input *SyncDirectoryInput, extensions []extensionOptions) (*SyncDirectoryOutput, error) {
+	files, failed, err := syncLocalFiles(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := OSSClient.listPrefixObjects(ctx, input.Bucket, input.Prefix, input.Include, input.Exclude, extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &SyncDirectoryOutput{}
+	transferType := SyncActionUpload
+	if input.Direction == SyncDownload {
+		transferType = SyncActionDownload
+	}
+	for relPath, err := range failed {
+		output.Actions = append(output.Actions, SyncAction{Type: transferType, Key: input.Prefix + relPath,
+			LocalPath: filepath.Join(input.Directory, filepath.FromSlash(relPath)), Err: err})
+	}
+
+	if input.Direction == SyncUpload {
+		for relPath, file := range files {
+			action := SyncAction{Type: SyncActionUpload, Key: input.Prefix + relPath, LocalPath: file.localPath, Size: file.size}
+			if object, ok := objects[relPath]; !ok {
+				action.Reason = "new"
+			} else if action.Reason, action.Err = syncChangedReason(input, file, object); action.Reason == "" && action.Err == nil {
+				output.UnchangedCount++
+				continue
+			}
+			output.Actions = append(output.Actions, action)
+		}
+		if input.Delete {
+			for relKey, object := range objects {
+				if _, ok := files[relKey]; ok || hasFailedAncestor(failed, relKey) {
+					continue
+				}
+				output.Actions = append(output.Actions, SyncAction{Type: SyncActionDeleteObject, Key: object.Key, Size: object.Size, Reason: "extraneous"})
+			}
+		}
+	} else {
+		for relKey, object := range objects {
+			localPath, err := localPathOfKey(input.Directory, relKey)
+			action := SyncAction{Type: SyncActionDownload, Key: object.Key, LocalPath: localPath, Size: object.Size, Err: err,
+				lastModified: object.LastModified}
+			if file, ok := files[relKey]; !ok {
+				action.Reason = "new"
+			} else if action.Reason, action.Err = syncChangedReason(input, file, object); action.Reason == "" && action.Err == nil {
+				output.UnchangedCount++
+				continue
+			}
+			output.Actions = append(output.Actions, action)
+		}
+		if input.Delete {
+			for relPath, file := range files {
+				if _, ok := objects[relPath]; ok {
+					continue
+				}
+				output.Actions = append(output.Actions, SyncAction{Type: SyncActionDeleteFile, Key: input.Prefix + relPath,
+					LocalPath: file.localPath, Size: file.size, Reason: "extraneous"})
+			}
+		}
+	}
+
+	sort.SliceStable(output.Actions, func(i, j int) bool {
+		return output.Actions[i].Key < output.Actions[j].Key
+	})
+	return output, nil
+}
+
+func (OSSClient OSSClient) syncDirectory(input *SyncDirectoryInput, extensions []extensionOptions) (*SyncDirectoryOutput, error) {
+	ctx := OSSClient.getRequestContext(extensions)
+	output, err := OSSClient.planSync(ctx, input, extensions)
+	if err != nil || input.DryRun {
+		return output, err
+	}
+
+	transferInput := &UploadDirectoryInput{
+		Bucket:             input.Bucket,
+		ACL:                input.ACL,
+		StorageClass:       input.StorageClass,
+		SseHeader:          input.SseHeader,
+		PartSize:           input.PartSize,
+		MultipartThreshold: input.MultipartThreshold,
+		PartTaskNum:        input.PartTaskNum,
+	}
+	pool := newDirectoryTransferPool(input.TaskNum, extensions)
+	var lock sync.Mutex
+	for i := range output.Actions {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		action := &output.Actions[i]
+		if action.Err != nil {
+			lock.Lock()
+			output.FailedCount++
+			lock.Unlock()
+			continue
+		}
+		multipart := (action.Type == SyncActionUpload || action.Type == SyncActionDownload) && action.Size > input.MultipartThreshold
+		pool.transfer(multipart, extensions, func(extensions []extensionOptions) {
+			err := OSSClient.executeSyncAction(input, transferInput, action, extensions)
+			lock.Lock()
+			defer lock.Unlock()
+			action.Err = err
+			if err != nil {
+				doLog(LEVEL_WARN, "Failed to %s [%s] with error [%v].", action.Type, action.Key, err)
+				output.FailedCount++
+			} else {
+				output.SucceededCount++
+				if action.Type == SyncActionUpload || action.Type == SyncActionDownload {
+					output.TransferredBytes += action.Size
+				}
+			}
+		})
+	}
+	pool.shutDown()
+	return output, err
+}
+
+func (OSSClient OSSClient) executeSyncAction(input *SyncDirectoryInput, transferInput *UploadDirectoryInput, action *SyncAction, extensions []extensionOptions) error {
+	switch action.Type {
+	case SyncActionUpload:
+		return OSSClient.uploadDirectoryFile(transferInput, action.LocalPath, action.Key, action.Size, extensions)
+	case SyncActionDownload:
+		downloadInput := &DownloadPrefixInput{
+			Bucket:             input.Bucket,
+			PartSize:           input.PartSize,
+			MultipartThreshold: input.MultipartThreshold,
+			PartTaskNum:        input.PartTaskNum,
+		}
+		if err := OSSClient.downloadPrefixFile(downloadInput, action.Key, action.LocalPath, action.Size, extensions); err != nil {
+			return err
+		}
+		// keep the last modified time of the object, so that the file is not considered modified by the next sync
+		return os.Chtimes(action.LocalPath, time.Now(), action.lastModified)
+	case SyncActionDeleteObject:
+		_input := &DeleteObjectInput{}
+		_input.Bucket = input.Bucket
+		_input.Key = action.Key
+		_, err := OSSClient.DeleteObject(_input, extensions...)
+		return err
+	case SyncActionDeleteFile:
+		return os.Remove(action.LocalPath)
+	}
+	return nil
+}
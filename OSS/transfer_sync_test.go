@@ -0,0 +1,189 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// syncActions returns the actions by their keys, formatted as "Type Reason".
+func syncActions(output *SyncDirectoryOutput) map[string]string {
+	actions := make(map[string]string)
+	for _, action := range output.Actions {
+		actions[action.Key] = string(action.Type) + " " + action.Reason
+	}
+	return actions
+}
+
+func setModTime(t *testing.T, dir, name string, modTime time.Time) {
+	if err := os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncDirectoryUpload(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{
+		"same.txt":     []byte("same"),
+		"resized.txt":  []byte("resized"),
+		"modified.txt": []byte("modified"),
+		"new.txt":      []byte("new"),
+	})
+	fs.putObject("bucket", "sync/same.txt", []byte("same"))
+	fs.putObject("bucket", "sync/resized.txt", []byte("old"))
+	fs.putObject("bucket", "sync/modified.txt", []byte("MODIFIED"))
+	fs.putObject("bucket", "sync/extra.txt", []byte("extra"))
+	fs.putObject("bucket", "sync/keep.log", []byte("keep"))
+	setModTime(t, dir, "same.txt", time.Now().Add(-time.Hour))
+	setModTime(t, dir, "resized.txt", time.Now().Add(-time.Hour))
+	setModTime(t, dir, "modified.txt", time.Now().Add(time.Hour))
+
+	input := &SyncDirectoryInput{Bucket: "bucket", Prefix: "sync/", Directory: dir, Direction: SyncUpload,
+		Exclude: []string{"*.log"}, Delete: true, DryRun: true, TaskNum: 2}
+	output, err := client.SyncDirectory(input)
+	if err != nil {
+		t.Fatalf("SyncDirectory failed: %v", err)
+	}
+	expected := map[string]string{
+		"sync/resized.txt":  "Upload size changed",
+		"sync/modified.txt": "Upload modified",
+		"sync/new.txt":      "Upload new",
+		"sync/extra.txt":    "DeleteObject extraneous",
+	}
+	if actions := syncActions(output); !reflect.DeepEqual(actions, expected) || output.UnchangedCount != 1 {
+		t.Fatalf("unexpected actions %v, unchanged: %d", actions, output.UnchangedCount)
+	}
+	if count := fs.countRequests(func(r *http.Request) bool { return r.Method != http.MethodGet }); count != 0 {
+		t.Fatalf("expected no changes in a dry run, got %d requests", count)
+	}
+
+	input.DryRun = false
+	output, err = client.SyncDirectory(input)
+	if err != nil || output.SucceededCount != 4 || output.FailedCount != 0 {
+		t.Fatalf("SyncDirectory failed: %+v, %v", output, err)
+	}
+	expectedKeys := []string{"sync/keep.log", "sync/modified.txt", "sync/new.txt", "sync/resized.txt", "sync/same.txt"}
+	if keys := fs.objectKeys("bucket"); !reflect.DeepEqual(keys, expectedKeys) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if object, _ := fs.getObject("bucket", "sync/resized.txt"); !bytes.Equal(object.data, []byte("resized")) {
+		t.Fatal("unexpected object")
+	}
+}
+
+func TestSyncDirectoryChecksum(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{"same.txt": []byte("same"), "changed.txt": []byte("AAAA")})
+	fs.putObject("bucket", "same.txt", []byte("same"))
+	fs.putObject("bucket", "changed.txt", []byte("BBBB"))
+	// the modified times would not cause uploads
+	setModTime(t, dir, "same.txt", time.Now().Add(time.Hour))
+	setModTime(t, dir, "changed.txt", time.Now().Add(-time.Hour))
+
+	input := &SyncDirectoryInput{Bucket: "bucket", Directory: dir, Direction: SyncUpload, CompareMode: SyncCompareChecksum, DryRun: true}
+	output, err := client.SyncDirectory(input)
+	if err != nil {
+		t.Fatalf("SyncDirectory failed: %v", err)
+	}
+	expected := map[string]string{"changed.txt": "Upload checksum changed"}
+	if actions := syncActions(output); !reflect.DeepEqual(actions, expected) || output.UnchangedCount != 1 {
+		t.Fatalf("unexpected actions %v, unchanged: %d", actions, output.UnchangedCount)
+	}
+}
+
+func TestSyncDirectoryDownload(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{"same.txt": []byte("same"), "extra.txt": []byte("extra")})
+	setModTime(t, dir, "same.txt", time.Now().Add(time.Hour))
+	fs.putObject("bucket", "same.txt", []byte("same"))
+	fs.putObject("bucket", "sub/new.txt", []byte("new"))
+
+	input := &SyncDirectoryInput{Bucket: "bucket", Directory: dir, Direction: SyncDownload, Delete: true, TaskNum: 2}
+	output, err := client.SyncDirectory(input)
+	if err != nil || output.SucceededCount != 2 || output.FailedCount != 0 {
+		t.Fatalf("SyncDirectory failed: %+v, %v", output, err)
+	}
+	expected := map[string]string{"sub/new.txt": "Download new", "extra.txt": "DeleteFile extraneous"}
+	if actions := syncActions(output); !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("unexpected actions %v", actions)
+	}
+	if read, err := os.ReadFile(filepath.Join(dir, "sub", "new.txt")); err != nil || string(read) != "new" {
+		t.Fatalf("unexpected file, err: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "extra.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected the extraneous file to be deleted")
+	}
+
+	// the downloaded file keeps the time of the object, so it is not downloaded again
+	input.DryRun = true
+	output, err = client.SyncDirectory(input)
+	if err != nil || len(output.Actions) != 0 || output.UnchangedCount != 2 {
+		t.Fatalf("unexpected second sync %+v, %v", output, err)
+	}
+}
+
+func TestSyncDirectoryFailedAction(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{"a.txt": []byte("a"), "b.txt": []byte("b"), "c.txt": []byte("c")})
+	fs.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == http.MethodPut && r.URL.Path == "/bucket/b.txt" {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	})
+
+	input := &SyncDirectoryInput{Bucket: "bucket", Directory: dir, Direction: SyncUpload, TaskNum: 3}
+	output, err := client.SyncDirectory(input)
+	if err != nil {
+		t.Fatalf("SyncDirectory failed: %v", err)
+	}
+	if output.SucceededCount != 2 || output.FailedCount != 1 || output.TransferredBytes != 2 {
+		t.Fatalf("unexpected output %+v", output)
+	}
+	for _, action := range output.Actions {
+		if (action.Err != nil) != (action.Key == "b.txt") {
+			t.Fatalf("unexpected error of %s: %v", action.Key, action.Err)
+		}
+	}
+}
+
+func TestSyncDirectoryPrefixWithoutSlash(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	dir := t.TempDir()
+	fs.putObject("bucket", "sync/a.txt", []byte("a"))
+
+	input := &SyncDirectoryInput{Bucket: "bucket", Prefix: "sync", Directory: dir, Direction: SyncDownload}
+	output, err := client.SyncDirectory(input)
+	if err != nil || output.SucceededCount != 1 || output.FailedCount != 0 {
+		t.Fatalf("SyncDirectory failed: %+v, %v", output, err)
+	}
+	if read, err := os.ReadFile(filepath.Join(dir, "a.txt")); err != nil || !bytes.Equal(read, []byte("a")) {
+		t.Fatalf("unexpected file, err: %v", err)
+	}
+}
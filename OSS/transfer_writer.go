@@ -0,0 +1,280 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// offsetWriter writes to an io.WriterAt from the offset on.
+type offsetWriter struct {
+	writer io.WriterAt
+	offset int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (n int, err error) {
+	n, err = ow.writer.WriteAt(p, ow.offset)
+	ow.offset += int64(n)
+	return
+}
+
+type downloadWriterTask struct {
+	GetObjectInput
+	ctx              context.Context
+	OSSClient        *OSSClient
+	extensions       []extensionOptions
+	abort            *int32
+	partNumber       int64
+	progressListener ProgressListener
+	write            func(task *downloadWriterTask, body io.Reader) error
+}
+
+func (task *downloadWriterTask) Run() interface{} {
+	if atomic.LoadInt32(task.abort) == 1 {
+		return errAbort
+	}
+	if err := task.ctx.Err(); err != nil {
+		return err
+	}
+
+	extensions := withProgressListener(task.extensions, task.progressListener)
+	output, err := task.OSSClient.GetObject(&task.GetObjectInput, extensions...)
+	if err == nil {
+		defer func() {
+			errMsg := output.Body.Close()
+			if errMsg != nil {
+				doLog(LEVEL_WARN, "Failed to close response body.")
+			}
+		}()
+		err = task.write(task, output.Body)
+		if err == nil {
+			return output
+		}
+		rollbackPartProgress(task.progressListener)
+	}
+	// without a checkpoint a failed part can not be recovered, so abort the other parts
+	atomic.CompareAndSwapInt32(task.abort, 0, 1)
+	doLog(LEVEL_WARN, "Task is aborted, part number is [%d]", task.partNumber)
+	return err
+}
+
+func (task *downloadWriterTask) partSize() int64 {
+	return task.RangeEnd - task.RangeStart + 1
+}
+
+// writeAt copies the body of a part to the offset of the part in an io.WriterAt.
+func writeAt(writer io.WriterAt) func(task *downloadWriterTask, body io.Reader) error {
+	return func(task *downloadWriterTask, body io.Reader) error {
+		n, err := io.Copy(&offsetWriter{writer: writer, offset: task.RangeStart}, body)
+		if err != nil {
+			doLog(LEVEL_ERROR, "Failed to write part [%d] with error [%v].", task.partNumber, err)
+			return err
+		}
+		if n != task.partSize() {
+			return fmt.Errorf("Failed to write part [%d], expect: [%d], actual: [%d]", task.partNumber, task.partSize(), n)
+		}
+		return nil
+	}
+}
+
+type downloadedPart struct {
+	partNumber int64
+	data       []byte
+}
+
+// sequentialWriter writes the downloaded parts to an io.Writer in order. At most windowSize parts
+// are dispatched before the first of them is written, which bounds the memory used for reordering.
+type sequentialWriter struct {
+	writer  io.Writer
+	slots   chan struct{}
+	parts   chan downloadedPart
+	stop    chan struct{}
+	once    sync.Once
+	done    chan struct{}
+	err     error
+	pending map[int64][]byte
+	next    int64
+}
+
+func newSequentialWriter(writer io.Writer, windowSize int, firstPartNumber int64) *sequentialWriter {
+	sw := &sequentialWriter{
+		writer:  writer,
+		slots:   make(chan struct{}, windowSize),
+		parts:   make(chan downloadedPart, windowSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		pending: make(map[int64][]byte, windowSize),
+		next:    firstPartNumber,
+	}
+	go sw.run()
+	return sw
+}
+
+// acquire waits until the window has room for another part, it returns false if the download is stopped.
+func (sw *sequentialWriter) acquire(ctx context.Context) bool {
+	select {
+	case sw.slots <- struct{}{}:
+		return true
+	case <-sw.stop:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (sw *sequentialWriter) cancel() {
+	sw.once.Do(func() {
+		close(sw.stop)
+	})
+}
+
+func (sw *sequentialWriter) write(task *downloadWriterTask, body io.Reader) error {
+	data := make([]byte, task.partSize())
+	if _, err := io.ReadFull(body, data); err != nil {
+		doLog(LEVEL_ERROR, "Failed to read response body with error [%v].", err)
+		return err
+	}
+	sw.parts <- downloadedPart{partNumber: task.partNumber, data: data}
+	return nil
+}
+
+func (sw *sequentialWriter) run() {
+	defer close(sw.done)
+	for part := range sw.parts {
+		sw.pending[part.partNumber] = part.data
+		for {
+			data, ok := sw.pending[sw.next]
+			if !ok {
+				break
+			}
+			delete(sw.pending, sw.next)
+			sw.next++
+			if sw.err == nil {
+				if _, err := sw.writer.Write(data); err != nil {
+					doLog(LEVEL_ERROR, "Failed to write to writer with error [%v].", err)
+					sw.err = err
+					sw.cancel()
+				}
+			}
+			<-sw.slots
+		}
+	}
+}
+
+// close waits until all the received parts are written and returns the first write error.
+func (sw *sequentialWriter) close() error {
+	close(sw.parts)
+	<-sw.done
+	return sw.err
+}
+
+func (OSSClient OSSClient) downloadToWriter(input *DownloadToWriterInput, extensions []extensionOptions) (output *GetObjectMetadataOutput, err error) {
+	listener, extensions := splitProgressListener(extensions, input.ProgressListener)
+	output, err = OSSClient.GetObjectMetadata(&input.GetObjectMetadataInput, extensions...)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := newProgressTracker(listener, 0, output.ContentLength)
+	tracker.started()
+	if output.ContentLength > 0 {
+		dfc := &DownloadCheckpoint{}
+		dfc.ObjectInfo.Size = output.ContentLength
+		sliceObject(output.ContentLength, input.PartSize, dfc)
+		ifMatch := input.IfMatch
+		if ifMatch == "" {
+			// make sure all the parts are read from the same version of the object
+			ifMatch = output.ETag
+		}
+		err = OSSClient.downloadToWriterConcurrent(input, dfc.DownloadParts, ifMatch, tracker, extensions)
+	}
+	if err != nil {
+		tracker.failed()
+		return nil, err
+	}
+	tracker.completed()
+	return output, nil
+}
+
+func (OSSClient OSSClient) downloadToWriterConcurrent(input *DownloadToWriterInput, downloadParts []DownloadPartInfo, ifMatch string,
+	tracker *progressTracker, extensions []extensionOptions) error {
+	ctx := OSSClient.getRequestContext(extensions)
+	pool := NewRoutinePool(input.TaskNum, MAX_PART_NUM)
+	var downloadPartError atomic.Value
+	var errFlag int32
+	var abort int32
+
+	var write func(task *downloadWriterTask, body io.Reader) error
+	var sw *sequentialWriter
+	if writerAt, ok := input.Writer.(io.WriterAt); ok {
+		write = writeAt(writerAt)
+	} else {
+		sw = newSequentialWriter(input.Writer, input.WindowSize, downloadParts[0].PartNumber)
+		write = sw.write
+	}
+
+	for _, downloadPart := range downloadParts {
+		if atomic.LoadInt32(&abort) == 1 || ctx.Err() != nil {
+			break
+		}
+		if sw != nil && !sw.acquire(ctx) {
+			break
+		}
+		task := downloadWriterTask{
+			GetObjectInput: GetObjectInput{
+				GetObjectMetadataInput: input.GetObjectMetadataInput,
+				IfMatch:                ifMatch,
+				IfNoneMatch:            input.IfNoneMatch,
+				IfUnmodifiedSince:      input.IfUnmodifiedSince,
+				IfModifiedSince:        input.IfModifiedSince,
+				RangeStart:             downloadPart.Offset,
+				RangeEnd:               downloadPart.RangeEnd,
+				ExactRange:             true,
+			},
+			ctx:              ctx,
+			OSSClient:        &OSSClient,
+			extensions:       extensions,
+			abort:            &abort,
+			partNumber:       downloadPart.PartNumber,
+			progressListener: newPartProgressListener(tracker),
+			write:            write,
+		}
+		pool.ExecuteFunc(func() interface{} {
+			result := task.Run()
+			if err, ok := result.(error); ok && err != errAbort {
+				if atomic.CompareAndSwapInt32(&errFlag, 0, 1) {
+					downloadPartError.Store(err)
+				}
+				if sw != nil {
+					sw.cancel()
+				}
+			}
+			return nil
+		})
+	}
+	pool.ShutDown()
+	if sw != nil {
+		if err := sw.close(); err != nil && atomic.CompareAndSwapInt32(&errFlag, 0, 1) {
+			downloadPartError.Store(err)
+		}
+	}
+	if err, ok := downloadPartError.Load().(error); ok {
+		return err
+	}
+
+	return ctx.Err()
+}
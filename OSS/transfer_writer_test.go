@@ -0,0 +1,101 @@
+// Copyright 2019 Inspur Technologies Co.,Ltd.
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not use
+// this file except in compliance with the License.  You may obtain a copy of the
+// License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software distributed
+// under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+// CONDITIONS OF ANY KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations under the License.
+
+package OSS
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// bufferWriterAt is an io.WriterAt which fails the sequential writes.
+type bufferWriterAt struct {
+	lock sync.Mutex
+	data []byte
+}
+
+func (buffer *bufferWriterAt) Write(p []byte) (int, error) {
+	return 0, errors.New("unexpected sequential write")
+}
+
+func (buffer *bufferWriterAt) WriteAt(p []byte, offset int64) (int, error) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	if end := int(offset) + len(p); end > len(buffer.data) {
+		buffer.data = append(buffer.data, make([]byte, end-len(buffer.data))...)
+	}
+	return copy(buffer.data[offset:], p), nil
+}
+
+func newTestObject(fs *fakeServer, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 241)
+	}
+	fs.putObject("bucket", "key", data)
+	return data
+}
+
+func TestDownloadToWriterSequential(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := newTestObject(fs, 10*1000+17)
+	var buffer bytes.Buffer
+
+	input := &DownloadToWriterInput{Writer: &buffer, PartSize: 1000, TaskNum: 4, WindowSize: 4}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.DownloadToWriter(input); err != nil {
+		t.Fatalf("DownloadToWriter failed: %v", err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatal("unexpected data")
+	}
+}
+
+func TestDownloadToWriterAt(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	data := newTestObject(fs, 10*1000+17)
+	buffer := &bufferWriterAt{}
+
+	input := &DownloadToWriterInput{Writer: buffer, PartSize: 1000, TaskNum: 4}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.DownloadToWriter(input); err != nil {
+		t.Fatalf("DownloadToWriter failed: %v", err)
+	}
+	if !bytes.Equal(buffer.data, data) {
+		t.Fatal("unexpected data")
+	}
+}
+
+func TestDownloadToWriterWindowSize(t *testing.T) {
+	fs := newFakeServer(t)
+	client := newTestClient(t, fs)
+	newTestObject(fs, 100)
+
+	input := &DownloadToWriterInput{Writer: &bytes.Buffer{}, TaskNum: 4, WindowSize: 2}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.DownloadToWriter(input); err == nil {
+		t.Fatal("expected an error for a WindowSize less than TaskNum")
+	}
+
+	input = &DownloadToWriterInput{Writer: &bytes.Buffer{}, TaskNum: 4}
+	input.Bucket, input.Key = "bucket", "key"
+	if _, err := client.DownloadToWriter(input); err != nil {
+		t.Fatalf("DownloadToWriter failed: %v", err)
+	}
+	if input.WindowSize != 8 {
+		t.Fatalf("expected the default WindowSize 8, got %d", input.WindowSize)
+	}
+}
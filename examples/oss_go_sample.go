@@ -67,7 +67,7 @@ func doObjectVersion() {
 	output, err := OSSClient.ListVersions(input)
 	if err == nil {
 		fmt.Printf("RequestId:%s\n", output.RequestId)
-		fmt.Printf("Versions:%s\n", output.Versions)
+		fmt.Printf("Versions:%v\n", output.Versions)
 	} else {
 		if error, ok := err.(OSS.OSSError); ok {
 			fmt.Println(error.Code)
@@ -107,7 +107,7 @@ func headObject() {
 
 }
 func doBucketDomain() {
-	fmt.Printf("set bucket domain - %s")
+	fmt.Println("set bucket domain")
 	input := &OSS.SetBucketDomainInput{}
 	input.Bucket = bucketName
 	input.Domain = "[{\"domainName\":\"www.example254313.com\",\"isWebsite\":false},{\"domainName\":\"www.example23412.com\",\"isWebsite\":false}]"
@@ -123,7 +123,7 @@ func doBucketDomain() {
 			fmt.Println(err)
 		}
 	}
-	fmt.Printf("get bucket domain - %s")
+	fmt.Println("get bucket domain")
 	output2, err2 := OSSClient.GetBucketDomain(bucketName)
 	if err2 == nil {
 		fmt.Printf("RequestId:%s\n", output2.RequestId)
@@ -136,7 +136,7 @@ func doBucketDomain() {
 			fmt.Println(err2)
 		}
 	}
-	fmt.Printf("delete bucket domain - %s")
+	fmt.Println("delete bucket domain")
 	output3, err3 := OSSClient.DeleteBucketDomain(bucketName)
 	if err3 == nil {
 		fmt.Printf("RequestId:%s\n", output3.RequestId)
@@ -412,7 +412,7 @@ func appendObject() {
 	}
 }
 func doObjectSampleOperation() {
-	fmt.Printf("put object - %s")
+	fmt.Println("put object")
 	//input := &OSS.PutFileInput{}
 	input := &OSS.PutObjectInput{}
 	input.Bucket = bucketName
@@ -427,7 +427,7 @@ func doObjectSampleOperation() {
 	fmt.Printf("Put object:%s successfully!\n", objectKey)
 	fmt.Println()
 
-	fmt.Printf("get object - %s")
+	fmt.Println("get object")
 	input2 := &OSS.GetObjectInput{}
 	input2.Bucket = bucketName
 	input2.Key = objectKey
@@ -450,7 +450,7 @@ func doObjectSampleOperation() {
 	fmt.Println(string(body))
 	fmt.Println()
 
-	fmt.Printf("set object acl - %s")
+	fmt.Println("set object acl")
 	input3 := &OSS.SetObjectAclInput{}
 	input3.Bucket = bucketName
 	input3.Key = objectKey
@@ -479,7 +479,7 @@ func doObjectSampleOperation() {
 			fmt.Println(err)
 		}
 	}
-	fmt.Printf("get object acl - %s")
+	fmt.Println("get object acl")
 	input4 := &OSS.GetObjectAclInput{}
 	input4.Bucket = bucketName
 	input4.Key = objectKey
@@ -499,7 +499,7 @@ func doObjectSampleOperation() {
 		}
 	}
 
-	fmt.Printf("set object metadata - %s")
+	fmt.Println("set object metadata")
 	input55 := &OSS.PutObjectInput{}
 	input55.Bucket = bucketName
 	input55.Key = objectKey
@@ -515,7 +515,7 @@ func doObjectSampleOperation() {
 	fmt.Println("Set object meatdata successfully!")
 	fmt.Println()
 
-	fmt.Printf("get object metadata - %s")
+	fmt.Println("get object metadata")
 	input6 := &OSS.GetObjectMetadataInput{}
 	input6.Bucket = bucketName
 	input6.Key = objectKey
@@ -612,7 +612,7 @@ func deleteBucket() {
 	fmt.Println()
 }
 func doBucketEncryption() {
-	fmt.Printf("set bucket encryption - %s")
+	fmt.Println("set bucket encryption")
 	input := &OSS.SetBucketEncryptionInput{}
 	input.Bucket = bucketName
 	// 指定传入加密算法及对应的KMS加密密钥
@@ -629,7 +629,7 @@ func doBucketEncryption() {
 			fmt.Println(err)
 		}
 	}
-	fmt.Printf("get bucket encryption - %s")
+	fmt.Println("get bucket encryption")
 	// 获取指定桶的加密配置信息
 	output2, err := OSSClient.GetBucketEncryption(bucketName)
 	if err == nil {
@@ -643,7 +643,7 @@ func doBucketEncryption() {
 			fmt.Println(err)
 		}
 	}
-	fmt.Printf("delete bucket encryption - %s")
+	fmt.Println("delete bucket encryption")
 	// 删除指定桶的加密配置信息
 	output3, err := OSSClient.DeleteBucketEncryption(bucketName)
 	if err == nil {
@@ -658,7 +658,7 @@ func doBucketEncryption() {
 	}
 }
 func doBucketPolicy() {
-	fmt.Printf("set bucket policy - %s")
+	fmt.Println("set bucket policy")
 	input := &OSS.SetBucketPolicyInput{}
 	input.Bucket = bucketName
 	input.Policy = "{\"Statement\":[{\"Principal\":\"*\",\"Effect\":\"Allow\",\"Action\":\"s3:ListBucket\",\"Resource\":\"" + bucketName + "\"}]}"
@@ -673,7 +673,7 @@ func doBucketPolicy() {
 			fmt.Println(err)
 		}
 	}
-	fmt.Printf("get bucket policy - %s")
+	fmt.Println("get bucket policy")
 
 	output2, err2 := OSSClient.GetBucketPolicy(bucketName)
 	if err2 == nil {
@@ -687,7 +687,7 @@ func doBucketPolicy() {
 			fmt.Println(err)
 		}
 	}
-	fmt.Printf("delete bucket policy - %s")
+	fmt.Println("delete bucket policy")
 	output3, err3 := OSSClient.DeleteBucketPolicy(bucketName)
 	if err3 == nil {
 		fmt.Printf("RequestId:%s\n", output3.RequestId)
@@ -801,7 +801,7 @@ func headBucket() {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("GET bucket EXIT:%s successfully!\n", exit)
+	fmt.Printf("GET bucket EXIT:%v successfully!\n", exit)
 	fmt.Println()
 }
 func doBucketLifecycleOperation() {
@@ -1021,6 +1021,6 @@ func getBucketLocation() {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("Bucket location - %s\n", output)
+	fmt.Printf("Bucket location - %s\n", output.Location)
 	fmt.Println()
 }